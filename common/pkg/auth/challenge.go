@@ -0,0 +1,30 @@
+package auth
+
+import (
+	"errors"
+	"time"
+)
+
+// PurposeTwoFactor 两步验证挑战Token的用途
+const PurposeTwoFactor = "2fa"
+
+// ChallengeTTL 两步验证挑战Token的有效期
+const ChallengeTTL = 5 * time.Minute
+
+// GenerateChallengeToken 生成两步验证挑战Token
+// 密码校验通过但需要第二因素时签发，只能用于换取访问Token
+func (m *JWTManager) GenerateChallengeToken(uid, deviceId, platform string) (string, error) {
+	return m.sign(uid, deviceId, platform, PurposeTwoFactor, ChallengeTTL)
+}
+
+// ParseChallengeToken 解析两步验证挑战Token
+func (m *JWTManager) ParseChallengeToken(tokenString string) (*Claims, error) {
+	claims, err := m.parse(tokenString)
+	if err != nil {
+		return nil, err
+	}
+	if claims.Purpose != PurposeTwoFactor {
+		return nil, errors.New("invalid challenge token")
+	}
+	return claims, nil
+}
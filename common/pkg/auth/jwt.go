@@ -5,6 +5,7 @@ import (
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
+	"github.com/redis/go-redis/v9"
 )
 
 // Claims JWT声明
@@ -12,6 +13,8 @@ type Claims struct {
 	Uid      string `json:"uid"`
 	DeviceId string `json:"device_id"`
 	Platform string `json:"platform"`
+	IssuedNs int64  `json:"iat_ns,omitempty"`  // 纳秒级签发时间，用于判断是否已被注销
+	Purpose  string `json:"purpose,omitempty"` // 非空表示不是访问Token，如两步验证挑战
 	jwt.RegisteredClaims
 }
 
@@ -19,6 +22,8 @@ type Claims struct {
 type JWTManager struct {
 	secret     []byte
 	expireHour int
+	redis      *redis.Client // 保存会话注销记录，为空时不检查
+	now        func() time.Time
 }
 
 // NewJWTManager 创建JWT管理器
@@ -26,19 +31,28 @@ func NewJWTManager(secret string, expireHour int) *JWTManager {
 	return &JWTManager{
 		secret:     []byte(secret),
 		expireHour: expireHour,
+		now:        time.Now,
 	}
 }
 
 // GenerateToken 生成Token
 func (m *JWTManager) GenerateToken(uid, deviceId, platform string) (string, error) {
+	return m.sign(uid, deviceId, platform, "", time.Duration(m.expireHour)*time.Hour)
+}
+
+// sign 签发指定用途和有效期的Token
+func (m *JWTManager) sign(uid, deviceId, platform, purpose string, ttl time.Duration) (string, error) {
+	now := m.now()
 	claims := Claims{
 		Uid:      uid,
 		DeviceId: deviceId,
 		Platform: platform,
+		IssuedNs: now.UnixNano(),
+		Purpose:  purpose,
 		RegisteredClaims: jwt.RegisteredClaims{
-			ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Duration(m.expireHour) * time.Hour)),
-			IssuedAt:  jwt.NewNumericDate(time.Now()),
-			NotBefore: jwt.NewNumericDate(time.Now()),
+			ExpiresAt: jwt.NewNumericDate(now.Add(ttl)),
+			IssuedAt:  jwt.NewNumericDate(now),
+			NotBefore: jwt.NewNumericDate(now),
 		},
 	}
 
@@ -48,12 +62,25 @@ func (m *JWTManager) GenerateToken(uid, deviceId, platform string) (string, erro
 
 // ParseToken 解析Token
 func (m *JWTManager) ParseToken(tokenString string) (*Claims, error) {
+	claims, err := m.parse(tokenString)
+	if err != nil {
+		return nil, err
+	}
+	// 挑战Token不能当作访问Token使用
+	if claims.Purpose != "" {
+		return nil, errors.New("invalid token")
+	}
+	return claims, nil
+}
+
+// parse 校验签名和有效期
+func (m *JWTManager) parse(tokenString string) (*Claims, error) {
 	token, err := jwt.ParseWithClaims(tokenString, &Claims{}, func(token *jwt.Token) (interface{}, error) {
 		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
 			return nil, errors.New("invalid signing method")
 		}
 		return m.secret, nil
-	})
+	}, jwt.WithTimeFunc(m.now))
 
 	if err != nil {
 		return nil, err
@@ -74,7 +101,7 @@ func (m *JWTManager) RefreshToken(tokenString string) (string, error) {
 	}
 
 	// 只有在过期时间前1小时内才能刷新
-	if time.Until(claims.ExpiresAt.Time) > time.Hour {
+	if claims.ExpiresAt.Time.Sub(m.now()) > time.Hour {
 		return tokenString, nil
 	}
 
@@ -1,6 +1,7 @@
 package auth
 
 import (
+	"context"
 	"testing"
 	"time"
 )
@@ -148,3 +149,60 @@ func TestMultipleTokensForSameUser(t *testing.T) {
 		t.Error("Tokens should have different device IDs")
 	}
 }
+
+func TestValidateToken_WithoutRevocation(t *testing.T) {
+	manager := NewJWTManager("test-secret-key", 24)
+
+	before := time.Now().UnixNano()
+	token, _ := manager.GenerateToken("user123", "device1", "ios")
+
+	claims, err := manager.ValidateToken(context.Background(), token)
+	if err != nil {
+		t.Fatalf("ValidateToken failed: %v", err)
+	}
+	if claims.IssuedNs < before {
+		t.Errorf("IssuedNs = %d, want >= %d", claims.IssuedNs, before)
+	}
+
+	if _, err := manager.ValidateToken(context.Background(), "not-a-valid-token"); err == nil {
+		t.Error("ValidateToken should fail for invalid token")
+	}
+
+	// 未启用注销检查时无法注销会话
+	if err := manager.RevokeSessions(context.Background(), "user123"); err == nil {
+		t.Error("RevokeSessions should fail without revocation store")
+	}
+}
+
+func TestChallengeToken(t *testing.T) {
+	manager := NewJWTManager("test-secret-key", 24)
+	now := time.Unix(1700000000, 0)
+	manager.now = func() time.Time { return now }
+
+	challenge, err := manager.GenerateChallengeToken("user123", "device456", "ios")
+	if err != nil {
+		t.Fatalf("GenerateChallengeToken failed: %v", err)
+	}
+
+	claims, err := manager.ParseChallengeToken(challenge)
+	if err != nil {
+		t.Fatalf("ParseChallengeToken failed: %v", err)
+	}
+	if claims.Uid != "user123" || claims.DeviceId != "device456" || claims.Platform != "ios" {
+		t.Errorf("unexpected claims: %+v", claims)
+	}
+
+	// 挑战Token不能作为访问Token使用，反之亦然
+	if _, err := manager.ParseToken(challenge); err == nil {
+		t.Error("ParseToken should reject challenge token")
+	}
+	token, _ := manager.GenerateToken("user123", "device456", "ios")
+	if _, err := manager.ParseChallengeToken(token); err == nil {
+		t.Error("ParseChallengeToken should reject access token")
+	}
+
+	now = now.Add(ChallengeTTL + time.Second)
+	if _, err := manager.ParseChallengeToken(challenge); err == nil {
+		t.Error("ParseChallengeToken should reject expired challenge")
+	}
+}
@@ -0,0 +1,57 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// ErrSessionRevoked 会话已被注销
+var ErrSessionRevoked = errors.New("session revoked")
+
+// WithRevocation 启用会话注销检查，注销记录保存在Redis中
+// 签发Token与校验Token的服务需使用同一个Redis
+func (m *JWTManager) WithRevocation(rdb *redis.Client) *JWTManager {
+	m.redis = rdb
+	return m
+}
+
+// revokedKey 用户会话注销时间点的Redis键
+func revokedKey(uid string) string {
+	return fmt.Sprintf("session_revoked:%s", uid)
+}
+
+// ValidateToken 解析Token并检查会话是否已被注销
+func (m *JWTManager) ValidateToken(ctx context.Context, tokenString string) (*Claims, error) {
+	claims, err := m.ParseToken(tokenString)
+	if err != nil {
+		return nil, err
+	}
+	if m.redis == nil {
+		return claims, nil
+	}
+
+	revokedAt, err := m.redis.Get(ctx, revokedKey(claims.Uid)).Int64()
+	if err != nil && err != redis.Nil {
+		return nil, err
+	}
+	if claims.IssuedNs < revokedAt {
+		return nil, ErrSessionRevoked
+	}
+
+	return claims, nil
+}
+
+// RevokeSessions 注销用户此前签发的所有Token
+// 记录保留到这些Token全部过期为止
+func (m *JWTManager) RevokeSessions(ctx context.Context, uid string) error {
+	if m.redis == nil {
+		return errors.New("session revocation not enabled")
+	}
+
+	ttl := time.Duration(m.expireHour) * time.Hour
+	return m.redis.Set(ctx, revokedKey(uid), time.Now().UnixNano(), ttl).Err()
+}
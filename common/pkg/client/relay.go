@@ -22,18 +22,21 @@ func NewRelayClient(addr string) *RelayClient {
 // StoreEventRequest 存储事件请求
 type StoreEventRequest struct {
 	Event *protocol.Event `json:"event"`
+	TTL   int64           `json:"ttl,omitempty"` // 消息过期时间（秒），0=不过期
 }
 
 // StoreEventResponse 存储事件响应
 type StoreEventResponse struct {
 	Mid       int64 `json:"mid"`
 	Timestamp int64 `json:"timestamp"`
+	RootMid   int64 `json:"root_mid,omitempty"`   // 回复消息所属线程的根消息ID
+	ExpiresAt int64 `json:"expires_at,omitempty"` // 过期时间，0表示不过期
 }
 
-// StoreEvent 存储事件
-func (c *RelayClient) StoreEvent(ctx context.Context, event *protocol.Event) (*StoreEventResponse, error) {
+// StoreEvent 存储事件，ttl大于0时事件到期后被删除
+func (c *RelayClient) StoreEvent(ctx context.Context, event *protocol.Event, ttl int64) (*StoreEventResponse, error) {
 	var resp StoreEventResponse
-	err := c.rpc.Call(ctx, "relay.storeEvent", &StoreEventRequest{Event: event}, &resp)
+	err := c.rpc.Call(ctx, "relay.storeEvent", &StoreEventRequest{Event: event, TTL: ttl}, &resp)
 	if err != nil {
 		return nil, err
 	}
@@ -43,6 +46,7 @@ func (c *RelayClient) StoreEvent(ctx context.Context, event *protocol.Event) (*S
 // QueryEventsRequest 查询事件请求
 type QueryEventsRequest struct {
 	Cid     string `json:"cid"`
+	Uid     string `json:"uid,omitempty"` // 查询者UID，用于过滤其自己删除的消息
 	LastMid int64  `json:"last_mid,omitempty"`
 	Before  int64  `json:"before,omitempty"`
 	After   int64  `json:"after,omitempty"`
@@ -58,12 +62,31 @@ type EventData struct {
 	Sender    string `json:"sender"`
 	Tags      string `json:"tags"`
 	Data      string `json:"data"`
+	Flags     int    `json:"flags"`
 	Timestamp int64  `json:"timestamp"`
+
+	RevokedBy    string `json:"revoked_by,omitempty"`
+	RevokeReason string `json:"revoke_reason,omitempty"`
+
+	ReplyMid int64 `json:"reply_mid,omitempty"`
+	RootMid  int64 `json:"root_mid,omitempty"`
+
+	ExpiresAt int64 `json:"expires_at,omitempty"`
+}
+
+// ThreadSummary 线程摘要
+type ThreadSummary struct {
+	RootMid      int64    `json:"root_mid"`
+	ReplyCount   int64    `json:"reply_count"`
+	LastReplyMid int64    `json:"last_reply_mid"`
+	LastReplyAt  int64    `json:"last_reply_at"`
+	Participants []string `json:"participants"`
 }
 
 // QueryEventsResponse 查询事件响应
 type QueryEventsResponse struct {
-	Events []EventData `json:"events"`
+	Events  []EventData     `json:"events"`
+	Threads []ThreadSummary `json:"threads,omitempty"`
 }
 
 // QueryEvents 查询事件
@@ -79,19 +102,43 @@ func (c *RelayClient) QueryEvents(ctx context.Context, req *QueryEventsRequest)
 // SyncEventsRequest 同步事件请求
 type SyncEventsRequest struct {
 	Cid   string `json:"cid"`
+	Uid   string `json:"uid,omitempty"`
 	Limit int    `json:"limit,omitempty"`
 }
 
 // SyncEvents 同步最新事件
-func (c *RelayClient) SyncEvents(ctx context.Context, cid string, limit int) (*QueryEventsResponse, error) {
+func (c *RelayClient) SyncEvents(ctx context.Context, cid, uid string, limit int) (*QueryEventsResponse, error) {
 	var resp QueryEventsResponse
-	err := c.rpc.Call(ctx, "relay.syncEvents", &SyncEventsRequest{Cid: cid, Limit: limit}, &resp)
+	err := c.rpc.Call(ctx, "relay.syncEvents", &SyncEventsRequest{Cid: cid, Uid: uid, Limit: limit}, &resp)
 	if err != nil {
 		return nil, err
 	}
 	return &resp, nil
 }
 
+// ConversationSummary 会话列表摘要
+type ConversationSummary struct {
+	Cid         string     `json:"cid"`
+	LastEvent   *EventData `json:"last_event,omitempty"` // 最后一条可展示的消息
+	LastReadMid int64      `json:"last_read_mid"`        // 用户已读水位
+	UnreadCount int64      `json:"unread_count"`         // 未读数
+}
+
+// GetConversationSummaries 批量获取用户在多个会话中的最后消息和未读数
+func (c *RelayClient) GetConversationSummaries(ctx context.Context, uid string, cids []string) ([]ConversationSummary, error) {
+	var resp struct {
+		Summaries []ConversationSummary `json:"summaries"`
+	}
+	err := c.rpc.Call(ctx, "relay.getConversationSummaries", map[string]interface{}{
+		"uid":  uid,
+		"cids": cids,
+	}, &resp)
+	if err != nil {
+		return nil, err
+	}
+	return resp.Summaries, nil
+}
+
 // GetEventRequest 获取单条事件请求
 type GetEventRequest struct {
 	Mid int64 `json:"mid"`
@@ -107,6 +154,96 @@ func (c *RelayClient) GetEvent(ctx context.Context, mid int64) (*EventData, erro
 	return &resp, nil
 }
 
+// HideEventRequest 隐藏消息请求
+type HideEventRequest struct {
+	Cid string `json:"cid"`
+	Uid string `json:"uid"`
+	Mid int64  `json:"mid"`
+}
+
+// HideEvent 为用户隐藏消息（仅自己删除）
+func (c *RelayClient) HideEvent(ctx context.Context, cid, uid string, mid int64) error {
+	return c.rpc.Call(ctx, "relay.hideEvent", &HideEventRequest{
+		Cid: cid,
+		Uid: uid,
+		Mid: mid,
+	}, nil)
+}
+
+// GetThreadRequest 获取线程回复请求
+type GetThreadRequest struct {
+	Cid     string `json:"cid"`
+	Uid     string `json:"uid,omitempty"`
+	RootMid int64  `json:"root_mid"`
+	Cursor  int64  `json:"cursor,omitempty"`
+	Limit   int    `json:"limit,omitempty"`
+}
+
+// GetThreadResponse 获取线程回复响应
+type GetThreadResponse struct {
+	Events     []EventData    `json:"events"`
+	Summary    *ThreadSummary `json:"summary,omitempty"`
+	NextCursor int64          `json:"next_cursor"`
+}
+
+// GetThread 分页获取线程回复
+func (c *RelayClient) GetThread(ctx context.Context, req *GetThreadRequest) (*GetThreadResponse, error) {
+	var resp GetThreadResponse
+	err := c.rpc.Call(ctx, "relay.getThread", req, &resp)
+	if err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// ThreadSubscriptionRequest 线程订阅请求
+type ThreadSubscriptionRequest struct {
+	Cid     string `json:"cid"`
+	Uid     string `json:"uid"`
+	RootMid int64  `json:"root_mid"`
+}
+
+// SubscribeThread 订阅线程
+func (c *RelayClient) SubscribeThread(ctx context.Context, cid, uid string, rootMid int64) error {
+	return c.rpc.Call(ctx, "relay.subscribeThread", &ThreadSubscriptionRequest{
+		Cid:     cid,
+		Uid:     uid,
+		RootMid: rootMid,
+	}, nil)
+}
+
+// UnsubscribeThread 退订线程
+func (c *RelayClient) UnsubscribeThread(ctx context.Context, cid, uid string, rootMid int64) error {
+	return c.rpc.Call(ctx, "relay.unsubscribeThread", &ThreadSubscriptionRequest{
+		Cid:     cid,
+		Uid:     uid,
+		RootMid: rootMid,
+	}, nil)
+}
+
+// ClearThreadSubscriptions 删除用户在会话中的全部线程订阅（退出或被移出群组时使用）
+func (c *RelayClient) ClearThreadSubscriptions(ctx context.Context, cid, uid string) error {
+	return c.rpc.Call(ctx, "relay.clearThreadSubscriptions", map[string]string{
+		"cid": cid,
+		"uid": uid,
+	}, nil)
+}
+
+// GetThreadSubscribers 获取线程订阅者
+func (c *RelayClient) GetThreadSubscribers(ctx context.Context, cid string, rootMid int64) ([]string, error) {
+	var resp struct {
+		Uids []string `json:"uids"`
+	}
+	err := c.rpc.Call(ctx, "relay.getThreadSubscribers", map[string]interface{}{
+		"cid":      cid,
+		"root_mid": rootMid,
+	}, &resp)
+	if err != nil {
+		return nil, err
+	}
+	return resp.Uids, nil
+}
+
 // UpdateReadReceiptRequest 更新已读回执请求
 type UpdateReadReceiptRequest struct {
 	Cid         string `json:"cid"`
@@ -114,21 +251,75 @@ type UpdateReadReceiptRequest struct {
 	LastReadMid int64  `json:"last_read_mid"`
 }
 
-// UpdateReadReceipt 更新已读回执
-func (c *RelayClient) UpdateReadReceipt(ctx context.Context, cid, uid string, lastReadMid int64) error {
-	return c.rpc.Call(ctx, "relay.updateReadReceipt", &UpdateReadReceiptRequest{
+// UpdateReadReceipt 更新已读回执（水位只前进），返回新被读到的近期消息的发送者
+func (c *RelayClient) UpdateReadReceipt(ctx context.Context, cid, uid string, lastReadMid int64) ([]string, error) {
+	var resp struct {
+		Senders []string `json:"senders"`
+	}
+	err := c.rpc.Call(ctx, "relay.updateReadReceipt", &UpdateReadReceiptRequest{
 		Cid:         cid,
 		Uid:         uid,
 		LastReadMid: lastReadMid,
-	}, nil)
+	}, &resp)
+	if err != nil {
+		return nil, err
+	}
+	return resp.Senders, nil
+}
+
+// GetReadStatus 获取已读到指定消息的用户
+func (c *RelayClient) GetReadStatus(ctx context.Context, cid string, mid int64) ([]string, error) {
+	var resp struct {
+		ReadUids []string `json:"read_uids"`
+	}
+	err := c.rpc.Call(ctx, "relay.getReadStatus", map[string]interface{}{
+		"cid": cid,
+		"mid": mid,
+	}, &resp)
+	if err != nil {
+		return nil, err
+	}
+	return resp.ReadUids, nil
+}
+
+// UpdateDeliveryReceipt 更新送达回执（水位只前进），返回新送达的近期消息的发送者
+func (c *RelayClient) UpdateDeliveryReceipt(ctx context.Context, cid, uid string, lastDeliveredMid int64) ([]string, error) {
+	var resp struct {
+		Senders []string `json:"senders"`
+	}
+	err := c.rpc.Call(ctx, "relay.updateDeliveryReceipt", map[string]interface{}{
+		"cid":                cid,
+		"uid":                uid,
+		"last_delivered_mid": lastDeliveredMid,
+	}, &resp)
+	if err != nil {
+		return nil, err
+	}
+	return resp.Senders, nil
+}
+
+// GetDeliveryStatus 获取已送达指定消息的用户（已读也视为已送达）
+func (c *RelayClient) GetDeliveryStatus(ctx context.Context, cid string, mid int64) ([]string, error) {
+	var resp struct {
+		DeliveredUids []string `json:"delivered_uids"`
+	}
+	err := c.rpc.Call(ctx, "relay.getDeliveryStatus", map[string]interface{}{
+		"cid": cid,
+		"mid": mid,
+	}, &resp)
+	if err != nil {
+		return nil, err
+	}
+	return resp.DeliveredUids, nil
 }
 
 // ValidateRevokeRequest 验证撤销请求
 type ValidateRevokeRequest struct {
-	Cid       string `json:"cid"`
-	Uid       string `json:"uid"`
-	TargetMid int64  `json:"target_mid"`
-	IsAdmin   bool   `json:"is_admin"`
+	Cid       string              `json:"cid"`
+	Uid       string              `json:"uid"`
+	TargetMid int64               `json:"target_mid"`
+	IsAdmin   bool                `json:"is_admin"`
+	Policy    *ConversationPolicy `json:"policy,omitempty"`
 }
 
 // ValidateRevokeResponse 验证撤销响应
@@ -138,13 +329,14 @@ type ValidateRevokeResponse struct {
 }
 
 // ValidateRevoke 验证撤销权限
-func (c *RelayClient) ValidateRevoke(ctx context.Context, cid, uid string, targetMid int64, isAdmin bool) (*ValidateRevokeResponse, error) {
+func (c *RelayClient) ValidateRevoke(ctx context.Context, cid, uid string, targetMid int64, isAdmin bool, policy *ConversationPolicy) (*ValidateRevokeResponse, error) {
 	var resp ValidateRevokeResponse
 	err := c.rpc.Call(ctx, "relay.validateRevoke", &ValidateRevokeRequest{
 		Cid:       cid,
 		Uid:       uid,
 		TargetMid: targetMid,
 		IsAdmin:   isAdmin,
+		Policy:    policy,
 	}, &resp)
 	if err != nil {
 		return nil, err
@@ -154,21 +346,177 @@ func (c *RelayClient) ValidateRevoke(ctx context.Context, cid, uid string, targe
 
 // ValidateEditRequest 验证编辑请求
 type ValidateEditRequest struct {
-	Cid       string `json:"cid"`
-	Uid       string `json:"uid"`
-	TargetMid int64  `json:"target_mid"`
+	Cid       string              `json:"cid"`
+	Uid       string              `json:"uid"`
+	TargetMid int64               `json:"target_mid"`
+	Policy    *ConversationPolicy `json:"policy,omitempty"`
 }
 
 // ValidateEdit 验证编辑权限
-func (c *RelayClient) ValidateEdit(ctx context.Context, cid, uid string, targetMid int64) (*ValidateRevokeResponse, error) {
+func (c *RelayClient) ValidateEdit(ctx context.Context, cid, uid string, targetMid int64, policy *ConversationPolicy) (*ValidateRevokeResponse, error) {
 	var resp ValidateRevokeResponse
 	err := c.rpc.Call(ctx, "relay.validateEdit", &ValidateEditRequest{
 		Cid:       cid,
 		Uid:       uid,
 		TargetMid: targetMid,
+		Policy:    policy,
+	}, &resp)
+	if err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// ScheduledMessage 定时消息
+type ScheduledMessage struct {
+	ID          uint            `json:"id"`
+	ScheduledAt int64           `json:"scheduled_at"`
+	Status      int             `json:"status"`
+	Mid         int64           `json:"mid,omitempty"`
+	FailReason  string          `json:"fail_reason,omitempty"`
+	Event       *protocol.Event `json:"event"`
+}
+
+// ScheduleEventRequest 创建定时消息请求
+type ScheduleEventRequest struct {
+	Event       *protocol.Event `json:"event"`
+	ScheduledAt int64           `json:"scheduled_at"`
+}
+
+// ScheduleEvent 创建定时消息
+func (c *RelayClient) ScheduleEvent(ctx context.Context, event *protocol.Event, scheduledAt int64) (*ScheduledMessage, error) {
+	var resp ScheduledMessage
+	err := c.rpc.Call(ctx, "relay.scheduleEvent", &ScheduleEventRequest{
+		Event:       event,
+		ScheduledAt: scheduledAt,
+	}, &resp)
+	if err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// ListScheduledRequest 获取定时消息请求
+type ListScheduledRequest struct {
+	Sender      string `json:"sender"`
+	Cid         string `json:"cid,omitempty"`
+	IncludeDone bool   `json:"include_done,omitempty"`
+}
+
+// ListScheduled 获取用户的定时消息
+func (c *RelayClient) ListScheduled(ctx context.Context, req *ListScheduledRequest) ([]ScheduledMessage, error) {
+	var resp struct {
+		Items []ScheduledMessage `json:"items"`
+	}
+	err := c.rpc.Call(ctx, "relay.listScheduled", req, &resp)
+	if err != nil {
+		return nil, err
+	}
+	return resp.Items, nil
+}
+
+// UpdateScheduledRequest 修改定时消息请求
+type UpdateScheduledRequest struct {
+	ID          uint            `json:"id"`
+	Sender      string          `json:"sender"`
+	Event       *protocol.Event `json:"event,omitempty"`
+	ScheduledAt int64           `json:"scheduled_at,omitempty"`
+}
+
+// UpdateScheduled 修改定时消息
+func (c *RelayClient) UpdateScheduled(ctx context.Context, req *UpdateScheduledRequest) (*ScheduledMessage, error) {
+	var resp ScheduledMessage
+	err := c.rpc.Call(ctx, "relay.updateScheduled", req, &resp)
+	if err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// CancelScheduled 取消定时消息
+func (c *RelayClient) CancelScheduled(ctx context.Context, id uint, sender string) (*ScheduledMessage, error) {
+	var resp ScheduledMessage
+	err := c.rpc.Call(ctx, "relay.cancelScheduled", map[string]interface{}{
+		"id":     id,
+		"sender": sender,
+	}, &resp)
+	if err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// CancelUserScheduled 取消用户所有等待发送的定时消息，返回取消的条数
+func (c *RelayClient) CancelUserScheduled(ctx context.Context, sender string) (int64, error) {
+	var resp struct {
+		Cancelled int64 `json:"cancelled"`
+	}
+	err := c.rpc.Call(ctx, "relay.cancelUserScheduled", map[string]string{"sender": sender}, &resp)
+	if err != nil {
+		return 0, err
+	}
+	return resp.Cancelled, nil
+}
+
+// ClaimDueScheduled 领取到期的定时消息
+func (c *RelayClient) ClaimDueScheduled(ctx context.Context, limit int) ([]ScheduledMessage, error) {
+	var resp struct {
+		Items []ScheduledMessage `json:"items"`
+	}
+	err := c.rpc.Call(ctx, "relay.claimDueScheduled", map[string]int{"limit": limit}, &resp)
+	if err != nil {
+		return nil, err
+	}
+	return resp.Items, nil
+}
+
+// SendScheduledResponse 发送定时消息响应
+type SendScheduledResponse struct {
+	StoreEventResponse
+	Duplicate bool `json:"duplicate,omitempty"` // 该定时消息此前已发送，Mid 为原消息ID
+}
+
+// SendScheduled 存储已领取的定时消息并标记为已发送（同一事务），重复调用不会重复存储
+func (c *RelayClient) SendScheduled(ctx context.Context, id uint, ttl int64) (*SendScheduledResponse, error) {
+	var resp SendScheduledResponse
+	err := c.rpc.Call(ctx, "relay.sendScheduled", map[string]interface{}{
+		"id":  id,
+		"ttl": ttl,
 	}, &resp)
 	if err != nil {
 		return nil, err
 	}
 	return &resp, nil
 }
+
+// CompleteScheduled 记录定时消息发送结果，reason非空表示发送失败
+func (c *RelayClient) CompleteScheduled(ctx context.Context, id uint, mid int64, reason string) error {
+	return c.rpc.Call(ctx, "relay.completeScheduled", map[string]interface{}{
+		"id":     id,
+		"mid":    mid,
+		"reason": reason,
+	}, nil)
+}
+
+// DeferScheduled 将已领取的定时消息放回等待状态，在 scheduledAt（Unix秒）后重新发送
+func (c *RelayClient) DeferScheduled(ctx context.Context, id uint, scheduledAt int64) error {
+	return c.rpc.Call(ctx, "relay.deferScheduled", map[string]interface{}{
+		"id":           id,
+		"scheduled_at": scheduledAt,
+	}, nil)
+}
+
+// PurgeUser 分批清理已注销用户在Relay中的数据，返回是否已全部完成
+func (c *RelayClient) PurgeUser(ctx context.Context, uid string, limit int) (bool, error) {
+	var resp struct {
+		Done bool `json:"done"`
+	}
+	err := c.rpc.Call(ctx, "relay.purgeUser", map[string]interface{}{
+		"uid":   uid,
+		"limit": limit,
+	}, &resp)
+	if err != nil {
+		return false, err
+	}
+	return resp.Done, nil
+}
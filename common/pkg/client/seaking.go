@@ -2,6 +2,7 @@ package client
 
 import (
 	"context"
+	"encoding/json"
 	"time"
 )
 
@@ -25,10 +26,14 @@ type CheckAccessRequest struct {
 
 // CheckAccessResponse 检查访问权限响应
 type CheckAccessResponse struct {
-	HasAccess bool   `json:"has_access"`
-	Role      int    `json:"role"`      // 0=普通成员, 1=管理员, 2=群主
-	Muted     bool   `json:"muted"`     // 是否被禁言
-	Reason    string `json:"reason,omitempty"`
+	HasAccess  bool   `json:"has_access"`
+	Role       int    `json:"role"`        // 0=普通成员, 1=管理员, 2=群主
+	Muted      bool   `json:"muted"`       // 是否被禁言
+	CanSend    bool   `json:"can_send"`    // 是否可以发言（未被禁言且不受全员禁言限制）
+	SlowMode   int    `json:"slow_mode"`   // 慢速模式间隔（秒），群主和管理员为0
+	Blocked    bool   `json:"blocked"`     // 单聊双方存在拉黑关系
+	MessageTTL int64  `json:"message_ttl"` // 消息过期时间（秒），0=不过期
+	Reason     string `json:"reason,omitempty"`
 }
 
 // CheckAccess 检查用户是否有权访问会话
@@ -48,11 +53,14 @@ type GetConversationRequest struct {
 
 // ConversationInfo 会话信息
 type ConversationInfo struct {
-	Cid       string   `json:"cid"`
-	Type      int      `json:"type"`       // 1=单聊, 2=群聊
-	Name      string   `json:"name"`
-	Avatar    string   `json:"avatar"`
-	MemberIds []string `json:"member_ids"`
+	Cid        string   `json:"cid"`
+	Type       int      `json:"type"` // 1=单聊, 2=群聊
+	Name       string   `json:"name"`
+	Avatar     string   `json:"avatar"`
+	MemberIds  []string `json:"member_ids"`
+	MessageTTL int64    `json:"message_ttl"`      // 消息过期时间（秒），0=不过期
+	Pinned     bool     `json:"pinned,omitempty"` // 当前用户是否置顶该会话
+	Muted      bool     `json:"muted,omitempty"`  // 当前用户是否开启免打扰
 }
 
 // GetConversation 获取会话信息
@@ -141,6 +149,146 @@ func (c *SeaKingClient) GetUserConversations(ctx context.Context, uid string) (*
 	return &resp, nil
 }
 
+// ConversationPolicy 会话消息策略
+type ConversationPolicy struct {
+	Cid            string `json:"cid"`
+	RevokeWindow   int64  `json:"revoke_window"`    // 撤销时间窗口（秒），0=不限制
+	EditWindow     int64  `json:"edit_window"`      // 编辑时间窗口（秒），0=不限制
+	EditableKinds  []int  `json:"editable_kinds"`   // 可编辑的消息类型，为空则禁止编辑
+	AdminRevokeAny bool   `json:"admin_revoke_any"` // 管理员是否可撤销任何人的消息
+}
+
+// GetConversationPolicy 获取会话消息策略
+func (c *SeaKingClient) GetConversationPolicy(ctx context.Context, cid string) (*ConversationPolicy, error) {
+	var resp ConversationPolicy
+	err := c.rpc.Call(ctx, "seaking.getConversationPolicy", map[string]string{"cid": cid}, &resp)
+	if err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// SetConversationPolicy 设置会话消息策略
+func (c *SeaKingClient) SetConversationPolicy(ctx context.Context, operatorId string, policy *ConversationPolicy) (*ConversationPolicy, error) {
+	var resp ConversationPolicy
+	err := c.rpc.Call(ctx, "seaking.setConversationPolicy", map[string]interface{}{
+		"cid":              policy.Cid,
+		"operator_id":      operatorId,
+		"revoke_window":    policy.RevokeWindow,
+		"edit_window":      policy.EditWindow,
+		"editable_kinds":   policy.EditableKinds,
+		"admin_revoke_any": policy.AdminRevokeAny,
+	}, &resp)
+	if err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// PinnedMessage 置顶消息
+type PinnedMessage struct {
+	ConversationID string `json:"conversation_id"`
+	Mid            int64  `json:"mid"`
+	Position       int    `json:"position"`
+	PinnedBy       string `json:"pinned_by"`
+}
+
+// PinMessageRequest 置顶消息请求
+type PinMessageRequest struct {
+	Cid string `json:"cid"`
+	Uid string `json:"uid"`
+	Mid int64  `json:"mid"`
+}
+
+// PinMessage 置顶消息
+func (c *SeaKingClient) PinMessage(ctx context.Context, cid, uid string, mid int64) (*PinnedMessage, error) {
+	var resp PinnedMessage
+	err := c.rpc.Call(ctx, "seaking.pinMessage", &PinMessageRequest{Cid: cid, Uid: uid, Mid: mid}, &resp)
+	if err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// UnpinMessage 取消置顶消息
+func (c *SeaKingClient) UnpinMessage(ctx context.Context, cid, uid string, mid int64) error {
+	return c.rpc.Call(ctx, "seaking.unpinMessage", &PinMessageRequest{Cid: cid, Uid: uid, Mid: mid}, nil)
+}
+
+// SetMessageTTLRequest 设置消息过期时间请求
+type SetMessageTTLRequest struct {
+	Cid string `json:"cid"`
+	Uid string `json:"uid"`
+	TTL int64  `json:"ttl"`
+}
+
+// SetMessageTTL 设置会话消息过期时间（秒），0表示关闭
+func (c *SeaKingClient) SetMessageTTL(ctx context.Context, cid, uid string, ttl int64) (int64, error) {
+	var resp struct {
+		MessageTTL int64 `json:"message_ttl"`
+	}
+	err := c.rpc.Call(ctx, "seaking.setMessageTTL", &SetMessageTTLRequest{Cid: cid, Uid: uid, TTL: ttl}, &resp)
+	if err != nil {
+		return 0, err
+	}
+	return resp.MessageTTL, nil
+}
+
+// GetPinnedMessages 获取会话置顶消息（最新置顶的在前）
+func (c *SeaKingClient) GetPinnedMessages(ctx context.Context, cid string) ([]PinnedMessage, error) {
+	var resp struct {
+		Pins []PinnedMessage `json:"pins"`
+	}
+	err := c.rpc.Call(ctx, "seaking.getPinnedMessages", map[string]string{"cid": cid}, &resp)
+	if err != nil {
+		return nil, err
+	}
+	return resp.Pins, nil
+}
+
+// Draft 会话草稿（密文）
+type Draft struct {
+	ConversationID string `json:"conversation_id"`
+	Data           string `json:"data"` // 草稿密文，为空表示已清除
+	Ts             int64  `json:"ts"`   // 客户端修改时间（毫秒）
+}
+
+// SaveDraftRequest 保存草稿请求
+type SaveDraftRequest struct {
+	Uid  string `json:"uid"`
+	Cid  string `json:"cid"`
+	Data string `json:"data"`
+	Ts   int64  `json:"ts"`
+}
+
+// SaveDraftResponse 保存草稿响应
+type SaveDraftResponse struct {
+	Draft   Draft `json:"draft"`   // 当前生效的草稿
+	Applied bool  `json:"applied"` // 本次写入是否生效（时间戳较旧时不生效）
+}
+
+// SaveDraft 保存草稿（后写者胜），data为空表示清除
+func (c *SeaKingClient) SaveDraft(ctx context.Context, req *SaveDraftRequest) (*SaveDraftResponse, error) {
+	var resp SaveDraftResponse
+	err := c.rpc.Call(ctx, "seaking.saveDraft", req, &resp)
+	if err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// GetDrafts 获取用户草稿（cid为空时返回全部会话）
+func (c *SeaKingClient) GetDrafts(ctx context.Context, uid, cid string) ([]Draft, error) {
+	var resp struct {
+		Drafts []Draft `json:"drafts"`
+	}
+	err := c.rpc.Call(ctx, "seaking.getDrafts", map[string]string{"uid": uid, "cid": cid}, &resp)
+	if err != nil {
+		return nil, err
+	}
+	return resp.Drafts, nil
+}
+
 // ValidateTokenRequest 验证Token请求
 type ValidateTokenRequest struct {
 	Token string `json:"token"`
@@ -188,6 +336,276 @@ func (c *SeaKingClient) GetUserInfo(ctx context.Context, uid string) (*UserInfo,
 	return &resp, nil
 }
 
+// UpdateProfile 更新用户资料，返回更新后的用户信息
+func (c *SeaKingClient) UpdateProfile(ctx context.Context, uid, nickname, avatar string) (*UserInfo, error) {
+	var resp UserInfo
+	err := c.rpc.Call(ctx, "seaking.updateProfile", map[string]string{
+		"uid":      uid,
+		"nickname": nickname,
+		"avatar":   avatar,
+	}, &resp)
+	if err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// ChangePasswordRequest 修改密码请求
+type ChangePasswordRequest struct {
+	Uid                 string `json:"uid"`
+	DeviceId            string `json:"device_id"`
+	Platform            string `json:"platform"`
+	OldPassword         string `json:"old_password"`
+	NewPassword         string `json:"new_password"`
+	EncryptedPrivateKey string `json:"encrypted_private_key,omitempty"` // 用新密码重新加密的私钥
+	KeySalt             string `json:"key_salt,omitempty"`              // 新的密钥派生盐值
+}
+
+// ChangePassword 修改密码，返回当前设备的新Token，其他会话全部失效
+func (c *SeaKingClient) ChangePassword(ctx context.Context, req *ChangePasswordRequest) (string, error) {
+	var resp struct {
+		Token string `json:"token"`
+	}
+	if err := c.rpc.Call(ctx, "seaking.changePassword", req, &resp); err != nil {
+		return "", err
+	}
+	return resp.Token, nil
+}
+
+// SecurityLogInfo 安全日志
+type SecurityLogInfo struct {
+	Id        uint   `json:"id"`
+	Event     string `json:"event"`
+	IP        string `json:"ip"`
+	DeviceId  string `json:"device_id"`
+	CreatedAt int64  `json:"created_at"`
+}
+
+// SecurityLogsResponse 安全日志响应
+type SecurityLogsResponse struct {
+	Logs       []SecurityLogInfo `json:"logs"`
+	NextCursor uint              `json:"next_cursor"` // 为0表示没有更多
+}
+
+// GetSecurityLogs 获取用户安全日志
+func (c *SeaKingClient) GetSecurityLogs(ctx context.Context, uid string, cursor uint, limit int) (*SecurityLogsResponse, error) {
+	var resp SecurityLogsResponse
+	err := c.rpc.Call(ctx, "seaking.getSecurityLogs", map[string]interface{}{
+		"uid":    uid,
+		"cursor": cursor,
+		"limit":  limit,
+	}, &resp)
+	if err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// UnlockLogin 解除用户名和/或IP的登录锁定
+func (c *SeaKingClient) UnlockLogin(ctx context.Context, username, ip string) error {
+	return c.rpc.Call(ctx, "seaking.unlockLogin", map[string]string{
+		"username": username,
+		"ip":       ip,
+	}, nil)
+}
+
+// DeleteAccount 申请注销账号，返回数据清理时间（Unix秒）
+func (c *SeaKingClient) DeleteAccount(ctx context.Context, uid, password, ip, deviceId string) (int64, error) {
+	var resp struct {
+		PurgeAt int64 `json:"purge_at"`
+	}
+	err := c.rpc.Call(ctx, "seaking.deleteAccount", map[string]string{
+		"uid":       uid,
+		"password":  password,
+		"ip":        ip,
+		"device_id": deviceId,
+	}, &resp)
+	if err != nil {
+		return 0, err
+	}
+	return resp.PurgeAt, nil
+}
+
+// CancelAccountDeletion 冷静期内撤销注销
+func (c *SeaKingClient) CancelAccountDeletion(ctx context.Context, uid string) error {
+	return c.rpc.Call(ctx, "seaking.cancelAccountDeletion", map[string]string{"uid": uid}, nil)
+}
+
+// ClaimDueDeletions 领取冷静期已结束的注销任务，返回用户ID
+func (c *SeaKingClient) ClaimDueDeletions(ctx context.Context, limit int) ([]string, error) {
+	var resp struct {
+		Uids []string `json:"uids"`
+	}
+	err := c.rpc.Call(ctx, "seaking.claimDueDeletions", map[string]int{"limit": limit}, &resp)
+	if err != nil {
+		return nil, err
+	}
+	return resp.Uids, nil
+}
+
+// AccountPurgeResult 注销用户退出群组的结果
+type AccountPurgeResult struct {
+	Left        []string          `json:"left"`        // 退出的群组
+	Transferred map[string]string `json:"transferred"` // 转让群主的群组 -> 新群主
+	Dissolved   []string          `json:"dissolved"`   // 解散的群组
+}
+
+// PurgeAccount 清理已注销用户在SeaKing中的数据并标记注销完成
+// 返回的退群结果包含之前中断的清理中已退出的群组，可据此补发系统事件
+func (c *SeaKingClient) PurgeAccount(ctx context.Context, uid string) (*AccountPurgeResult, error) {
+	var resp AccountPurgeResult
+	err := c.rpc.Call(ctx, "seaking.purgeAccount", map[string]string{"uid": uid}, &resp)
+	if err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// DataExportInfo 数据导出任务
+type DataExportInfo struct {
+	ID          uint   `json:"id"`
+	Uid         string `json:"uid"`
+	RequestedBy string `json:"requested_by"`
+	Status      int    `json:"status"` // protocol.ExportStatus*
+	FileKey     string `json:"file_key"`
+	Size        int64  `json:"size"`
+	Error       string `json:"error"`
+	ExpiresAt   int64  `json:"expires_at"` // 导出文件过期时间（Unix秒），未完成时为0
+	CreatedAt   int64  `json:"created_at"`
+}
+
+// dataExportsResponse 数据导出任务列表响应
+type dataExportsResponse struct {
+	Exports []DataExportInfo `json:"exports"`
+}
+
+// RequestDataExport 申请导出个人数据，已有未完成的任务时返回该任务
+func (c *SeaKingClient) RequestDataExport(ctx context.Context, uid, ip, deviceId string) (*DataExportInfo, error) {
+	var resp DataExportInfo
+	err := c.rpc.Call(ctx, "seaking.requestDataExport", map[string]string{
+		"uid":       uid,
+		"ip":        ip,
+		"device_id": deviceId,
+	}, &resp)
+	if err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// GetDataExports 获取用户最近的导出记录
+func (c *SeaKingClient) GetDataExports(ctx context.Context, uid string) ([]DataExportInfo, error) {
+	var resp dataExportsResponse
+	err := c.rpc.Call(ctx, "seaking.getDataExports", map[string]string{"uid": uid}, &resp)
+	if err != nil {
+		return nil, err
+	}
+	return resp.Exports, nil
+}
+
+// ClaimDueExports 领取待打包的导出任务
+func (c *SeaKingClient) ClaimDueExports(ctx context.Context, limit int) ([]DataExportInfo, error) {
+	var resp dataExportsResponse
+	err := c.rpc.Call(ctx, "seaking.claimDueExports", map[string]int{"limit": limit}, &resp)
+	if err != nil {
+		return nil, err
+	}
+	return resp.Exports, nil
+}
+
+// UserDataExport 用户在SeaKing中的数据，各部分原样写入导出文件
+type UserDataExport struct {
+	Profile       json.RawMessage `json:"profile"`
+	SecurityLogs  json.RawMessage `json:"security_logs"`
+	Relations     json.RawMessage `json:"relations"`
+	Groups        json.RawMessage `json:"groups"`
+	Conversations json.RawMessage `json:"conversations"`
+	Keys          json.RawMessage `json:"keys"` // 私钥和会话密钥仍为加密状态
+	Cids          []string        `json:"cids"` // 用户所在的会话
+}
+
+// ExportUserData 收集用户在SeaKing中的全部数据
+func (c *SeaKingClient) ExportUserData(ctx context.Context, uid string) (*UserDataExport, error) {
+	var resp UserDataExport
+	err := c.rpc.Call(ctx, "seaking.exportUserData", map[string]string{"uid": uid}, &resp)
+	if err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// CompleteDataExport 记录打包结果，errMsg 不为空表示失败
+func (c *SeaKingClient) CompleteDataExport(ctx context.Context, id uint, fileKey string, size int64, errMsg string) (*DataExportInfo, error) {
+	var resp DataExportInfo
+	err := c.rpc.Call(ctx, "seaking.completeDataExport", map[string]interface{}{
+		"id":       id,
+		"file_key": fileKey,
+		"size":     size,
+		"error":    errMsg,
+	}, &resp)
+	if err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// GetExpiredExports 获取文件已过期的导出任务
+func (c *SeaKingClient) GetExpiredExports(ctx context.Context, limit int) ([]DataExportInfo, error) {
+	var resp dataExportsResponse
+	err := c.rpc.Call(ctx, "seaking.getExpiredExports", map[string]int{"limit": limit}, &resp)
+	if err != nil {
+		return nil, err
+	}
+	return resp.Exports, nil
+}
+
+// ExpireDataExport 导出文件删除后标记任务已过期
+func (c *SeaKingClient) ExpireDataExport(ctx context.Context, id uint) error {
+	return c.rpc.Call(ctx, "seaking.expireDataExport", map[string]uint{"id": id}, nil)
+}
+
+// SearchUsersRequest 搜索用户请求
+type SearchUsersRequest struct {
+	Uid    string `json:"uid"`
+	Query  string `json:"query"`
+	Cursor string `json:"cursor,omitempty"`
+	Limit  int    `json:"limit,omitempty"`
+}
+
+// SearchUserInfo 搜索结果中的用户公开资料
+type SearchUserInfo struct {
+	Uid                  string `json:"uid"`
+	Username             string `json:"username"`
+	Nickname             string `json:"nickname"`
+	Avatar               string `json:"avatar"`
+	PublicKeyFingerprint string `json:"public_key_fingerprint"`
+}
+
+// SearchUsersResponse 搜索用户响应
+type SearchUsersResponse struct {
+	Users      []SearchUserInfo `json:"users"`
+	NextCursor string           `json:"next_cursor"` // 为空表示没有更多
+}
+
+// SearchUsers 按用户名、已验证邮箱或手机号搜索用户
+func (c *SeaKingClient) SearchUsers(ctx context.Context, req *SearchUsersRequest) (*SearchUsersResponse, error) {
+	var resp SearchUsersResponse
+	err := c.rpc.Call(ctx, "seaking.searchUsers", req, &resp)
+	if err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// SetDiscoverable 设置可被搜索的方式（按位组合：1=用户名, 2=邮箱, 4=手机号）
+func (c *SeaKingClient) SetDiscoverable(ctx context.Context, uid string, discoverable int) error {
+	var resp struct{}
+	return c.rpc.Call(ctx, "seaking.setDiscoverable", map[string]interface{}{
+		"uid":          uid,
+		"discoverable": discoverable,
+	}, &resp)
+}
+
 // RegisterRequest 注册请求
 type RegisterRequest struct {
 	Username string `json:"username"`
@@ -218,12 +636,18 @@ func (c *SeaKingClient) Register(ctx context.Context, req *RegisterRequest) (*Re
 type LoginRequest struct {
 	Username string `json:"username"`
 	Password string `json:"password"`
+	IP       string `json:"ip"` // 客户端IP，用于登录限流
 }
 
 // LoginResponse 登录响应
 type LoginResponse struct {
-	Token string    `json:"token"`
-	User  *UserInfo `json:"user"`
+	Token string    `json:"token,omitempty"`
+	User  *UserInfo `json:"user,omitempty"`
+
+	// 启用两步验证时不返回 Token，需用挑战Token调用 VerifyLogin
+	TwoFactorRequired bool   `json:"two_factor_required,omitempty"`
+	ChallengeToken    string `json:"challenge_token,omitempty"`
+	ExpiresIn         int    `json:"expires_in,omitempty"`
 }
 
 // Login 用户登录
@@ -234,6 +658,21 @@ func (c *SeaKingClient) Login(ctx context.Context, req *LoginRequest, deviceId,
 		"password":  req.Password,
 		"device_id": deviceId,
 		"platform":  platform,
+		"ip":        req.IP,
+	}, &resp)
+	if err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// VerifyLogin 提交两步验证码（或恢复码）完成登录
+func (c *SeaKingClient) VerifyLogin(ctx context.Context, challengeToken, code, ip string) (*LoginResponse, error) {
+	var resp LoginResponse
+	err := c.rpc.Call(ctx, "seaking.verifyLogin", map[string]string{
+		"challenge_token": challengeToken,
+		"code":            code,
+		"ip":              ip,
 	}, &resp)
 	if err != nil {
 		return nil, err
@@ -241,6 +680,77 @@ func (c *SeaKingClient) Login(ctx context.Context, req *LoginRequest, deviceId,
 	return &resp, nil
 }
 
+// TwoFactorStatus 两步验证状态
+type TwoFactorStatus struct {
+	Enabled                bool `json:"enabled"`
+	RecoveryCodesRemaining int  `json:"recovery_codes_remaining"`
+}
+
+// GetTwoFactorStatus 获取两步验证状态
+func (c *SeaKingClient) GetTwoFactorStatus(ctx context.Context, uid string) (*TwoFactorStatus, error) {
+	var resp TwoFactorStatus
+	err := c.rpc.Call(ctx, "seaking.getTwoFactorStatus", map[string]string{"uid": uid}, &resp)
+	if err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// TOTPEnrollment 两步验证注册信息
+type TOTPEnrollment struct {
+	Secret string `json:"secret"`
+	URI    string `json:"uri"` // otpauth URI，可生成二维码
+}
+
+// EnrollTOTP 开始启用两步验证
+func (c *SeaKingClient) EnrollTOTP(ctx context.Context, uid string) (*TOTPEnrollment, error) {
+	var resp TOTPEnrollment
+	err := c.rpc.Call(ctx, "seaking.enrollTOTP", map[string]string{"uid": uid}, &resp)
+	if err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// ConfirmTOTP 验证验证码并启用两步验证，返回恢复码
+func (c *SeaKingClient) ConfirmTOTP(ctx context.Context, uid, code string) ([]string, error) {
+	var resp struct {
+		RecoveryCodes []string `json:"recovery_codes"`
+	}
+	err := c.rpc.Call(ctx, "seaking.confirmTOTP", map[string]string{
+		"uid":  uid,
+		"code": code,
+	}, &resp)
+	if err != nil {
+		return nil, err
+	}
+	return resp.RecoveryCodes, nil
+}
+
+// DisableTOTP 关闭两步验证
+func (c *SeaKingClient) DisableTOTP(ctx context.Context, uid, password, code string) error {
+	return c.rpc.Call(ctx, "seaking.disableTOTP", map[string]string{
+		"uid":      uid,
+		"password": password,
+		"code":     code,
+	}, nil)
+}
+
+// RegenerateRecoveryCodes 重新生成恢复码
+func (c *SeaKingClient) RegenerateRecoveryCodes(ctx context.Context, uid, code string) ([]string, error) {
+	var resp struct {
+		RecoveryCodes []string `json:"recovery_codes"`
+	}
+	err := c.rpc.Call(ctx, "seaking.regenerateRecoveryCodes", map[string]string{
+		"uid":  uid,
+		"code": code,
+	}, &resp)
+	if err != nil {
+		return nil, err
+	}
+	return resp.RecoveryCodes, nil
+}
+
 // FriendInfo 好友信息
 type FriendInfo struct {
 	Uid      string `json:"uid"`
@@ -320,14 +830,58 @@ func (c *SeaKingClient) DeleteFriend(ctx context.Context, uid, friendId string)
 	}, &resp)
 }
 
+// BlockUser 拉黑用户
+func (c *SeaKingClient) BlockUser(ctx context.Context, uid, targetUid string) error {
+	var resp struct{}
+	return c.rpc.Call(ctx, "seaking.blockUser", map[string]string{
+		"uid":        uid,
+		"target_uid": targetUid,
+	}, &resp)
+}
+
+// UnblockUser 取消拉黑
+func (c *SeaKingClient) UnblockUser(ctx context.Context, uid, targetUid string) error {
+	var resp struct{}
+	return c.rpc.Call(ctx, "seaking.unblockUser", map[string]string{
+		"uid":        uid,
+		"target_uid": targetUid,
+	}, &resp)
+}
+
+// BlockedUserInfo 被拉黑用户信息
+type BlockedUserInfo struct {
+	Uid       string `json:"uid"`
+	Username  string `json:"username"`
+	Nickname  string `json:"nickname"`
+	Avatar    string `json:"avatar"`
+	BlockedAt int64  `json:"blocked_at"`
+}
+
+// GetBlockedUsers 获取拉黑列表
+func (c *SeaKingClient) GetBlockedUsers(ctx context.Context, uid string) ([]BlockedUserInfo, error) {
+	var resp struct {
+		Users []BlockedUserInfo `json:"users"`
+	}
+	err := c.rpc.Call(ctx, "seaking.getBlockedUsers", map[string]string{"uid": uid}, &resp)
+	if err != nil {
+		return nil, err
+	}
+	return resp.Users, nil
+}
+
 // GroupInfo 群组信息
 type GroupInfo struct {
-	Id          string `json:"id"`
-	Name        string `json:"name"`
-	Description string `json:"description"`
-	Avatar      string `json:"avatar"`
-	OwnerId     string `json:"owner_id"`
-	MaxMembers  int    `json:"max_members"`
+	Id                    string `json:"id"`
+	Name                  string `json:"name"`
+	Description           string `json:"description"`
+	Avatar                string `json:"avatar"`
+	OwnerId               string `json:"owner_id"`
+	MaxMembers            int    `json:"max_members"`
+	AnnouncementRevision  int    `json:"announcement_revision"`
+	AnnouncementEncrypted bool   `json:"announcement_encrypted"`
+	MuteAll               bool   `json:"mute_all"`
+	SlowMode              int    `json:"slow_mode"`
+	JoinRequestsEnabled   bool   `json:"join_requests_enabled"`
 }
 
 // GetUserGroups 获取用户的群组列表
@@ -378,3 +932,482 @@ func (c *SeaKingClient) GetGroupMembers(ctx context.Context, groupId string) ([]
 	}
 	return resp.Members, nil
 }
+
+// UpdateGroup 更新群组信息（管理员），空字段不修改
+func (c *SeaKingClient) UpdateGroup(ctx context.Context, groupId, operatorId, name, description, avatar string) (*GroupInfo, error) {
+	var resp GroupInfo
+	err := c.rpc.Call(ctx, "seaking.updateGroup", map[string]string{
+		"group_id":    groupId,
+		"operator_id": operatorId,
+		"name":        name,
+		"description": description,
+		"avatar":      avatar,
+	}, &resp)
+	if err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// DismissGroup 解散群组（群主）
+func (c *SeaKingClient) DismissGroup(ctx context.Context, groupId, operatorId string) error {
+	var resp struct{}
+	return c.rpc.Call(ctx, "seaking.dismissGroup", map[string]string{
+		"group_id":    groupId,
+		"operator_id": operatorId,
+	}, &resp)
+}
+
+// AddGroupMember 添加群成员（管理员）
+func (c *SeaKingClient) AddGroupMember(ctx context.Context, groupId, operatorId, uid string) error {
+	var resp struct{}
+	return c.rpc.Call(ctx, "seaking.addGroupMember", map[string]string{
+		"group_id":    groupId,
+		"operator_id": operatorId,
+		"uid":         uid,
+	}, &resp)
+}
+
+// RemoveGroupMember 移除群成员（管理员）
+func (c *SeaKingClient) RemoveGroupMember(ctx context.Context, groupId, operatorId, uid string) error {
+	var resp struct{}
+	return c.rpc.Call(ctx, "seaking.removeGroupMember", map[string]string{
+		"group_id":    groupId,
+		"operator_id": operatorId,
+		"uid":         uid,
+	}, &resp)
+}
+
+// LeaveGroup 退出群组
+func (c *SeaKingClient) LeaveGroup(ctx context.Context, groupId, uid string) error {
+	var resp struct{}
+	return c.rpc.Call(ctx, "seaking.leaveGroup", map[string]string{
+		"group_id": groupId,
+		"uid":      uid,
+	}, &resp)
+}
+
+// SetGroupAdmin 设置/取消管理员（群主）
+func (c *SeaKingClient) SetGroupAdmin(ctx context.Context, groupId, operatorId, uid string, isAdmin bool) error {
+	var resp struct{}
+	return c.rpc.Call(ctx, "seaking.setGroupAdmin", map[string]interface{}{
+		"group_id":    groupId,
+		"operator_id": operatorId,
+		"uid":         uid,
+		"is_admin":    isAdmin,
+	}, &resp)
+}
+
+// TransferGroupOwner 转让群主
+func (c *SeaKingClient) TransferGroupOwner(ctx context.Context, groupId, operatorId, newOwnerId string) error {
+	var resp struct{}
+	return c.rpc.Call(ctx, "seaking.transferGroupOwner", map[string]string{
+		"group_id":     groupId,
+		"operator_id":  operatorId,
+		"new_owner_id": newOwnerId,
+	}, &resp)
+}
+
+// MuteGroupMember 禁言群成员（管理员），duration 为禁言时长（秒），0 表示永久
+func (c *SeaKingClient) MuteGroupMember(ctx context.Context, groupId, operatorId, uid string, duration int64) error {
+	var resp struct{}
+	return c.rpc.Call(ctx, "seaking.muteGroupMember", map[string]interface{}{
+		"group_id":    groupId,
+		"operator_id": operatorId,
+		"uid":         uid,
+		"duration":    duration,
+	}, &resp)
+}
+
+// UnmuteGroupMember 取消禁言（管理员）
+func (c *SeaKingClient) UnmuteGroupMember(ctx context.Context, groupId, operatorId, uid string) error {
+	var resp struct{}
+	return c.rpc.Call(ctx, "seaking.unmuteGroupMember", map[string]string{
+		"group_id":    groupId,
+		"operator_id": operatorId,
+		"uid":         uid,
+	}, &resp)
+}
+
+// SetGroupMuteAll 开启或关闭全员禁言（管理员）
+func (c *SeaKingClient) SetGroupMuteAll(ctx context.Context, groupId, operatorId string, enabled bool) error {
+	var resp struct{}
+	return c.rpc.Call(ctx, "seaking.setGroupMuteAll", map[string]interface{}{
+		"group_id":    groupId,
+		"operator_id": operatorId,
+		"enabled":     enabled,
+	}, &resp)
+}
+
+// SetGroupJoinRequests 开启或关闭直接申请入群（管理员）
+func (c *SeaKingClient) SetGroupJoinRequests(ctx context.Context, groupId, operatorId string, enabled bool) error {
+	var resp struct{}
+	return c.rpc.Call(ctx, "seaking.setGroupJoinRequests", map[string]interface{}{
+		"group_id":    groupId,
+		"operator_id": operatorId,
+		"enabled":     enabled,
+	}, &resp)
+}
+
+// SetGroupSlowMode 设置慢速模式（管理员），seconds 为 0 表示关闭
+func (c *SeaKingClient) SetGroupSlowMode(ctx context.Context, groupId, operatorId string, seconds int) error {
+	var resp struct{}
+	return c.rpc.Call(ctx, "seaking.setGroupSlowMode", map[string]interface{}{
+		"group_id":    groupId,
+		"operator_id": operatorId,
+		"seconds":     seconds,
+	}, &resp)
+}
+
+// GroupInviteInfo 群邀请链接
+type GroupInviteInfo struct {
+	ID               uint   `json:"id"`
+	GroupId          string `json:"group_id"`
+	Token            string `json:"token"`
+	CreatedBy        string `json:"created_by"`
+	MaxUses          int    `json:"max_uses"` // 0表示不限制
+	Uses             int    `json:"uses"`
+	RequiresApproval bool   `json:"requires_approval"`
+	ExpiresAt        int64  `json:"expires_at"` // 0表示永不过期
+	CreatedAt        int64  `json:"created_at"`
+}
+
+// CreateGroupInviteRequest 创建群邀请链接请求
+type CreateGroupInviteRequest struct {
+	GroupId          string `json:"group_id"`
+	OperatorId       string `json:"operator_id"`
+	ExpiresIn        int64  `json:"expires_in,omitempty"` // 有效期（秒），0表示永不过期
+	MaxUses          int    `json:"max_uses,omitempty"`   // 0表示不限制
+	RequiresApproval bool   `json:"requires_approval,omitempty"`
+}
+
+// CreateGroupInvite 创建群邀请链接（管理员）
+func (c *SeaKingClient) CreateGroupInvite(ctx context.Context, req *CreateGroupInviteRequest) (*GroupInviteInfo, error) {
+	var resp GroupInviteInfo
+	if err := c.rpc.Call(ctx, "seaking.createGroupInvite", req, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// GetGroupInvites 获取群组未撤销的邀请链接（管理员）
+func (c *SeaKingClient) GetGroupInvites(ctx context.Context, groupId, operatorId string) ([]GroupInviteInfo, error) {
+	var resp struct {
+		Invites []GroupInviteInfo `json:"invites"`
+	}
+	err := c.rpc.Call(ctx, "seaking.getGroupInvites", map[string]string{
+		"group_id":    groupId,
+		"operator_id": operatorId,
+	}, &resp)
+	if err != nil {
+		return nil, err
+	}
+	return resp.Invites, nil
+}
+
+// RevokeGroupInvite 撤销群邀请链接（管理员）
+func (c *SeaKingClient) RevokeGroupInvite(ctx context.Context, groupId, operatorId string, inviteId uint) error {
+	var resp struct{}
+	return c.rpc.Call(ctx, "seaking.revokeGroupInvite", map[string]interface{}{
+		"group_id":    groupId,
+		"operator_id": operatorId,
+		"invite_id":   inviteId,
+	}, &resp)
+}
+
+// GroupInvitePreview 通过邀请链接看到的群组信息
+type GroupInvitePreview struct {
+	GroupId          string `json:"group_id"`
+	Name             string `json:"name"`
+	Avatar           string `json:"avatar"`
+	Description      string `json:"description"`
+	MemberCount      int64  `json:"member_count"`
+	RequiresApproval bool   `json:"requires_approval"`
+	ExpiresAt        int64  `json:"expires_at"`
+}
+
+// PreviewGroupInvite 通过邀请链接预览群组
+func (c *SeaKingClient) PreviewGroupInvite(ctx context.Context, token string) (*GroupInvitePreview, error) {
+	var resp GroupInvitePreview
+	if err := c.rpc.Call(ctx, "seaking.previewGroupInvite", map[string]string{"token": token}, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// InviteJoinResult 通过邀请链接加入的结果
+// 链接需要审批时 Request 不为空，表示已创建入群申请
+type InviteJoinResult struct {
+	GroupId string                `json:"group_id"`
+	Request *GroupJoinRequestInfo `json:"request"`
+	Admins  []string              `json:"admins"`
+}
+
+// JoinGroupByInvite 通过邀请链接加入群组
+func (c *SeaKingClient) JoinGroupByInvite(ctx context.Context, token, uid string) (*InviteJoinResult, error) {
+	var resp InviteJoinResult
+	err := c.rpc.Call(ctx, "seaking.joinGroupByInvite", map[string]string{
+		"token": token,
+		"uid":   uid,
+	}, &resp)
+	if err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// GroupJoinRequestInfo 入群申请
+type GroupJoinRequestInfo struct {
+	ID        uint   `json:"id"`
+	GroupId   string `json:"group_id"`
+	Uid       string `json:"uid"`
+	Nickname  string `json:"nickname,omitempty"`
+	Avatar    string `json:"avatar,omitempty"`
+	Message   string `json:"message"`
+	Status    int    `json:"status"` // protocol.JoinRequestStatus*
+	ViaInvite bool   `json:"via_invite"`
+	HandledBy string `json:"handled_by"`
+	CreatedAt int64  `json:"created_at"`
+}
+
+// GroupJoinRequestResult 入群申请变更结果，Admins 为需要推送的群主和管理员
+type GroupJoinRequestResult struct {
+	Request GroupJoinRequestInfo `json:"request"`
+	Admins  []string             `json:"admins"`
+}
+
+// RequestGroupJoin 申请加入群组
+func (c *SeaKingClient) RequestGroupJoin(ctx context.Context, groupId, uid, message string) (*GroupJoinRequestResult, error) {
+	var resp GroupJoinRequestResult
+	err := c.rpc.Call(ctx, "seaking.requestGroupJoin", map[string]string{
+		"group_id": groupId,
+		"uid":      uid,
+		"message":  message,
+	}, &resp)
+	if err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// GetGroupJoinRequests 获取群组待处理的入群申请（管理员）
+func (c *SeaKingClient) GetGroupJoinRequests(ctx context.Context, groupId, operatorId string) ([]GroupJoinRequestInfo, error) {
+	var resp struct {
+		Requests []GroupJoinRequestInfo `json:"requests"`
+	}
+	err := c.rpc.Call(ctx, "seaking.getGroupJoinRequests", map[string]string{
+		"group_id":    groupId,
+		"operator_id": operatorId,
+	}, &resp)
+	if err != nil {
+		return nil, err
+	}
+	return resp.Requests, nil
+}
+
+// ApproveGroupJoinRequest 同意入群申请（管理员）
+func (c *SeaKingClient) ApproveGroupJoinRequest(ctx context.Context, groupId, operatorId string, requestId uint) (*GroupJoinRequestResult, error) {
+	return c.handleGroupJoinRequest(ctx, "seaking.approveGroupJoinRequest", groupId, operatorId, requestId)
+}
+
+// RejectGroupJoinRequest 拒绝入群申请（管理员）
+func (c *SeaKingClient) RejectGroupJoinRequest(ctx context.Context, groupId, operatorId string, requestId uint) (*GroupJoinRequestResult, error) {
+	return c.handleGroupJoinRequest(ctx, "seaking.rejectGroupJoinRequest", groupId, operatorId, requestId)
+}
+
+// handleGroupJoinRequest 处理入群申请
+func (c *SeaKingClient) handleGroupJoinRequest(ctx context.Context, method, groupId, operatorId string, requestId uint) (*GroupJoinRequestResult, error) {
+	var resp GroupJoinRequestResult
+	err := c.rpc.Call(ctx, method, map[string]interface{}{
+		"group_id":    groupId,
+		"operator_id": operatorId,
+		"request_id":  requestId,
+	}, &resp)
+	if err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// GroupAnnouncement 群公告版本
+type GroupAnnouncement struct {
+	GroupId    string `json:"group_id"`
+	Revision   int    `json:"revision"`
+	Content    string `json:"content"`     // 为空表示该版本清除了公告
+	KeyVersion int    `json:"key_version"` // 加密使用的群密钥版本，0表示明文
+	AuthorId   string `json:"author_id"`
+	CreatedAt  int64  `json:"created_at"`
+}
+
+// GroupAnnouncementView 成员看到的当前群公告
+type GroupAnnouncementView struct {
+	GroupId      string `json:"group_id"`
+	Revision     int    `json:"revision"`
+	Content      string `json:"content"`
+	KeyVersion   int    `json:"key_version"`
+	Encrypted    bool   `json:"encrypted"` // 群组是否启用公告加密
+	AuthorId     string `json:"author_id"`
+	UpdatedAt    int64  `json:"updated_at"`
+	SeenRevision int    `json:"seen_revision"`
+	NeedsAck     bool   `json:"needs_ack"`
+}
+
+// SetGroupAnnouncement 发布群公告（管理员），keyVersion 为加密使用的群密钥版本，明文时为0
+func (c *SeaKingClient) SetGroupAnnouncement(ctx context.Context, groupId, operatorId, content string, keyVersion int) (*GroupAnnouncement, error) {
+	var resp GroupAnnouncement
+	err := c.rpc.Call(ctx, "seaking.setGroupAnnouncement", map[string]interface{}{
+		"group_id":    groupId,
+		"operator_id": operatorId,
+		"content":     content,
+		"key_version": keyVersion,
+	}, &resp)
+	if err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// ClearGroupAnnouncement 清除群公告（管理员）
+func (c *SeaKingClient) ClearGroupAnnouncement(ctx context.Context, groupId, operatorId string) (*GroupAnnouncement, error) {
+	var resp GroupAnnouncement
+	err := c.rpc.Call(ctx, "seaking.clearGroupAnnouncement", map[string]string{
+		"group_id":    groupId,
+		"operator_id": operatorId,
+	}, &resp)
+	if err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// GetGroupAnnouncement 获取当前群公告（成员）
+func (c *SeaKingClient) GetGroupAnnouncement(ctx context.Context, groupId, uid string) (*GroupAnnouncementView, error) {
+	var resp GroupAnnouncementView
+	err := c.rpc.Call(ctx, "seaking.getGroupAnnouncement", map[string]string{
+		"group_id": groupId,
+		"uid":      uid,
+	}, &resp)
+	if err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// AckGroupAnnouncement 确认已阅读群公告
+func (c *SeaKingClient) AckGroupAnnouncement(ctx context.Context, groupId, uid string, revision int) error {
+	var resp struct{}
+	return c.rpc.Call(ctx, "seaking.ackGroupAnnouncement", map[string]interface{}{
+		"group_id": groupId,
+		"uid":      uid,
+		"revision": revision,
+	}, &resp)
+}
+
+// GetGroupAnnouncementHistory 获取群公告历史版本（成员），按版本倒序
+func (c *SeaKingClient) GetGroupAnnouncementHistory(ctx context.Context, groupId, uid string, limit int) ([]GroupAnnouncement, error) {
+	var resp struct {
+		History []GroupAnnouncement `json:"history"`
+	}
+	err := c.rpc.Call(ctx, "seaking.getGroupAnnouncementHistory", map[string]interface{}{
+		"group_id": groupId,
+		"uid":      uid,
+		"limit":    limit,
+	}, &resp)
+	if err != nil {
+		return nil, err
+	}
+	return resp.History, nil
+}
+
+// SetGroupAnnouncementEncryption 开启或关闭群公告加密（管理员）
+func (c *SeaKingClient) SetGroupAnnouncementEncryption(ctx context.Context, groupId, operatorId string, enabled bool) error {
+	var resp struct{}
+	return c.rpc.Call(ctx, "seaking.setGroupAnnouncementEncryption", map[string]interface{}{
+		"group_id":    groupId,
+		"operator_id": operatorId,
+		"enabled":     enabled,
+	}, &resp)
+}
+
+// KeyEntry 为某个成员加密的会话密钥
+type KeyEntry struct {
+	Uid          string `json:"uid"`
+	EncryptedKey string `json:"encrypted_key"` // 用该成员公钥加密的对称密钥
+}
+
+// ChatKey 私聊会话密钥
+type ChatKey struct {
+	Cid          string `json:"cid"`
+	EncryptedKey string `json:"encrypted_key"`
+}
+
+// GroupKey 群组密钥
+type GroupKey struct {
+	GroupId      string `json:"group_id"`
+	EncryptedKey string `json:"encrypted_key"`
+	Version      int    `json:"version"`
+}
+
+// GetUserPublicKey 获取用户公钥
+func (c *SeaKingClient) GetUserPublicKey(ctx context.Context, uid string) (string, error) {
+	var resp struct {
+		PublicKey string `json:"public_key"`
+	}
+	err := c.rpc.Call(ctx, "seaking.getUserPublicKey", map[string]string{"uid": uid}, &resp)
+	if err != nil {
+		return "", err
+	}
+	return resp.PublicKey, nil
+}
+
+// GetChatKey 获取用户在私聊会话中的密钥
+func (c *SeaKingClient) GetChatKey(ctx context.Context, cid, uid string) (*ChatKey, error) {
+	var resp ChatKey
+	err := c.rpc.Call(ctx, "seaking.getChatKey", map[string]string{
+		"cid": cid,
+		"uid": uid,
+	}, &resp)
+	if err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// CreateChatKey 创建私聊会话密钥，已存在时不覆盖
+func (c *SeaKingClient) CreateChatKey(ctx context.Context, cid string, keys []KeyEntry) error {
+	var resp struct{}
+	return c.rpc.Call(ctx, "seaking.createChatKey", map[string]interface{}{
+		"cid":  cid,
+		"keys": keys,
+	}, &resp)
+}
+
+// GetGroupKey 获取用户的群组密钥，version 为 0 时返回最新版本
+func (c *SeaKingClient) GetGroupKey(ctx context.Context, groupId, uid string, version int) (*GroupKey, error) {
+	var resp GroupKey
+	err := c.rpc.Call(ctx, "seaking.getGroupKey", map[string]interface{}{
+		"group_id": groupId,
+		"uid":      uid,
+		"version":  version,
+	}, &resp)
+	if err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// CreateGroupKey 上传新版本群组密钥，version 必须是最新版本 + 1
+func (c *SeaKingClient) CreateGroupKey(ctx context.Context, groupId string, keys []KeyEntry, version int) (int, error) {
+	var resp struct {
+		Version int `json:"version"`
+	}
+	err := c.rpc.Call(ctx, "seaking.createGroupKey", map[string]interface{}{
+		"group_id": groupId,
+		"keys":     keys,
+		"version":  version,
+	}, &resp)
+	if err != nil {
+		return 0, err
+	}
+	return resp.Version, nil
+}
@@ -1,7 +1,9 @@
 package crypto
 
 import (
+	"strings"
 	"testing"
+	"time"
 )
 
 func TestHashPassword(t *testing.T) {
@@ -148,3 +150,75 @@ func TestVerifyHMACSHA256(t *testing.T) {
 		t.Error("VerifyHMACSHA256 passed for wrong data")
 	}
 }
+
+func TestTOTPCode_RFC6238(t *testing.T) {
+	// RFC 6238 附录B的SHA1测试密钥 "12345678901234567890"，取后6位
+	secret := "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+	tests := []struct {
+		unix int64
+		want string
+	}{
+		{59, "287082"},
+		{1111111109, "081804"},
+		{1234567890, "005924"},
+		{2000000000, "279037"},
+	}
+
+	for _, tt := range tests {
+		got, err := TOTPCode(secret, TOTPStep(time.Unix(tt.unix, 0)))
+		if err != nil {
+			t.Fatalf("TOTPCode failed: %v", err)
+		}
+		if got != tt.want {
+			t.Errorf("TOTPCode(%d) = %s, want %s", tt.unix, got, tt.want)
+		}
+	}
+}
+
+func TestValidateTOTP(t *testing.T) {
+	secret, err := GenerateTOTPSecret()
+	if err != nil {
+		t.Fatalf("GenerateTOTPSecret failed: %v", err)
+	}
+	now := time.Unix(1700000000, 0)
+	step := TOTPStep(now)
+	code, _ := TOTPCode(secret, step)
+
+	if got, ok := ValidateTOTP(secret, code, now, 0); !ok || got != step {
+		t.Errorf("ValidateTOTP() = %d, %v, want %d, true", got, ok, step)
+	}
+
+	// 允许一个时间步的时钟偏差
+	if _, ok := ValidateTOTP(secret, code, now.Add(TOTPPeriod*time.Second), 0); !ok {
+		t.Error("ValidateTOTP should accept code from previous step")
+	}
+	if _, ok := ValidateTOTP(secret, code, now.Add(2*TOTPPeriod*time.Second), 0); ok {
+		t.Error("ValidateTOTP should reject code older than skew")
+	}
+
+	// 已使用的时间步不能重放，即使仍在允许的偏差范围内
+	if _, ok := ValidateTOTP(secret, code, now, step); ok {
+		t.Error("ValidateTOTP should reject replayed code")
+	}
+	if _, ok := ValidateTOTP(secret, code, now.Add(TOTPPeriod*time.Second), step); ok {
+		t.Error("ValidateTOTP should reject replayed code within skew")
+	}
+	next, _ := TOTPCode(secret, step+1)
+	if got, ok := ValidateTOTP(secret, next, now.Add(TOTPPeriod*time.Second), step); !ok || got != step+1 {
+		t.Errorf("ValidateTOTP() for next step = %d, %v, want %d, true", got, ok, step+1)
+	}
+
+	if _, ok := ValidateTOTP(secret, "000000x", now, 0); ok {
+		t.Error("ValidateTOTP should reject malformed code")
+	}
+}
+
+func TestTOTPURI(t *testing.T) {
+	uri := TOTPURI("MyChat", "alice", "ABCDEF")
+	if !strings.HasPrefix(uri, "otpauth://totp/MyChat:alice?") {
+		t.Errorf("unexpected uri prefix: %s", uri)
+	}
+	if !strings.Contains(uri, "secret=ABCDEF") || !strings.Contains(uri, "issuer=MyChat") {
+		t.Errorf("uri missing parameters: %s", uri)
+	}
+}
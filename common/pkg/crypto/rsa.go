@@ -85,6 +85,22 @@ func RSAEncrypt(publicKeyB64 string, plaintext []byte) (string, error) {
 	return base64.StdEncoding.EncodeToString(ciphertext), nil
 }
 
+// PublicKeyFingerprint 计算公钥指纹（DER 编码的 SHA256 十六进制）
+// 供用户在带外渠道核对对方公钥
+func PublicKeyFingerprint(publicKeyB64 string) (string, error) {
+	publicKeyPEM, err := base64.StdEncoding.DecodeString(publicKeyB64)
+	if err != nil {
+		return "", err
+	}
+
+	block, _ := pem.Decode(publicKeyPEM)
+	if block == nil {
+		return "", errors.New("failed to decode public key PEM")
+	}
+
+	return SHA256Hash(block.Bytes), nil
+}
+
 // RSADecrypt 使用私钥解密数据
 // privateKeyB64: Base64编码的PEM格式私钥
 // ciphertextB64: Base64编码的密文
@@ -100,6 +100,30 @@ func TestRSAEncrypt_InvalidPublicKey(t *testing.T) {
 	}
 }
 
+func TestPublicKeyFingerprint(t *testing.T) {
+	pub1, _, _ := GenerateRSAKeyPair()
+	pub2, _, _ := GenerateRSAKeyPair()
+
+	fp1, err := PublicKeyFingerprint(pub1)
+	if err != nil {
+		t.Fatalf("PublicKeyFingerprint failed: %v", err)
+	}
+	if len(fp1) != 64 {
+		t.Errorf("fingerprint length = %d, want 64", len(fp1))
+	}
+
+	if again, _ := PublicKeyFingerprint(pub1); again != fp1 {
+		t.Error("fingerprint should be stable for the same key")
+	}
+	if fp2, _ := PublicKeyFingerprint(pub2); fp2 == fp1 {
+		t.Error("different keys should have different fingerprints")
+	}
+
+	if _, err := PublicKeyFingerprint("invalid-key"); err == nil {
+		t.Error("PublicKeyFingerprint should fail with invalid key")
+	}
+}
+
 func TestRSADecrypt_InvalidPrivateKey(t *testing.T) {
 	_, err := RSADecrypt("invalid-key", "invalid-ciphertext")
 	if err == nil {
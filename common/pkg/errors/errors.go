@@ -24,6 +24,9 @@ const (
 	ErrCodeUserExists      = 3002
 	ErrCodePasswordWrong   = 3003
 	ErrCodeUserDisabled    = 3004
+	ErrCodeInvalidOTP      = 3005
+	ErrCodeOTPEnabled      = 3006
+	ErrCodeOTPNotEnabled   = 3007
 
 	// 会话错误 4xxx
 	ErrCodeConversationNotFound = 4001
@@ -37,14 +40,25 @@ const (
 	ErrCodeCannotEdit        = 5004
 	ErrCodeRevokeTimeout     = 5005
 	ErrCodeEditTimeout       = 5006
+	ErrCodeCannotPin         = 5007
+	ErrCodePinLimit          = 5008
+	ErrCodeGroupMuted        = 5009
+	ErrCodeSlowMode          = 5010
 
 	// 关系错误 6xxx
-	ErrCodeNotFriend        = 6001
-	ErrCodeAlreadyFriend    = 6002
-	ErrCodeBlocked          = 6003
-	ErrCodeGroupNotFound    = 6004
-	ErrCodeNotGroupMember   = 6005
-	ErrCodeNoPermission     = 6006
+	ErrCodeNotFriend           = 6001
+	ErrCodeAlreadyFriend       = 6002
+	ErrCodeBlocked             = 6003
+	ErrCodeGroupNotFound       = 6004
+	ErrCodeNotGroupMember      = 6005
+	ErrCodeNoPermission        = 6006
+	ErrCodeInviteInvalid       = 6007
+	ErrCodeJoinRequestPending  = 6008
+	ErrCodeJoinRequestsClosed  = 6009
+	ErrCodeJoinRequestCooldown = 6010
+
+	// 密钥错误 7xxx
+	ErrCodeKeyVersionConflict = 7001
 )
 
 // Error 业务错误
@@ -87,6 +101,13 @@ var (
 	ErrPasswordWrong = New(ErrCodePasswordWrong, "wrong password")
 	ErrUserDisabled  = New(ErrCodeUserDisabled, "user disabled")
 
+	// ErrInvalidCredentials 登录失败，用户名不存在与密码错误使用同一错误码，避免枚举账号
+	ErrInvalidCredentials = New(ErrCodePasswordWrong, "invalid username or password")
+
+	ErrInvalidOTP    = New(ErrCodeInvalidOTP, "invalid verification code")
+	ErrOTPEnabled    = New(ErrCodeOTPEnabled, "two-factor authentication already enabled")
+	ErrOTPNotEnabled = New(ErrCodeOTPNotEnabled, "two-factor authentication not enabled")
+
 	ErrConversationNotFound = New(ErrCodeConversationNotFound, "conversation not found")
 	ErrNotInConversation    = New(ErrCodeNotInConversation, "not in conversation")
 	ErrConversationFull     = New(ErrCodeConversationFull, "conversation is full")
@@ -97,6 +118,10 @@ var (
 	ErrCannotEdit      = New(ErrCodeCannotEdit, "cannot edit this message")
 	ErrRevokeTimeout   = New(ErrCodeRevokeTimeout, "revoke timeout exceeded")
 	ErrEditTimeout     = New(ErrCodeEditTimeout, "edit timeout exceeded")
+	ErrCannotPin       = New(ErrCodeCannotPin, "cannot pin this message")
+	ErrPinLimit        = New(ErrCodePinLimit, "pinned message limit reached")
+	ErrGroupMuted      = New(ErrCodeGroupMuted, "only admins can send messages in this group")
+	ErrSlowMode        = New(ErrCodeSlowMode, "slow mode is enabled, please wait before sending again")
 
 	ErrNotFriend      = New(ErrCodeNotFriend, "not friend")
 	ErrAlreadyFriend  = New(ErrCodeAlreadyFriend, "already friend")
@@ -104,6 +129,13 @@ var (
 	ErrGroupNotFound  = New(ErrCodeGroupNotFound, "group not found")
 	ErrNotGroupMember = New(ErrCodeNotGroupMember, "not group member")
 	ErrNoPermission   = New(ErrCodeNoPermission, "no permission")
+
+	ErrInviteInvalid       = New(ErrCodeInviteInvalid, "invite link is invalid or expired")
+	ErrJoinRequestPending  = New(ErrCodeJoinRequestPending, "join request already pending")
+	ErrJoinRequestsClosed  = New(ErrCodeJoinRequestsClosed, "group does not accept join requests")
+	ErrJoinRequestCooldown = New(ErrCodeJoinRequestCooldown, "join request rejected recently, please try again later")
+
+	ErrKeyVersionConflict = New(ErrCodeKeyVersionConflict, "key version conflict")
 )
 
 // IsError 判断是否为指定错误码
@@ -91,6 +91,10 @@ func TestErrorCodes(t *testing.T) {
 		ErrCodeNoPermission:      "ErrCodeNoPermission",
 		ErrCodeCannotRevoke:      "ErrCodeCannotRevoke",
 		ErrCodeCannotEdit:        "ErrCodeCannotEdit",
+		ErrCodeCannotPin:         "ErrCodeCannotPin",
+		ErrCodePinLimit:          "ErrCodePinLimit",
+		ErrCodeGroupMuted:        "ErrCodeGroupMuted",
+		ErrCodeSlowMode:          "ErrCodeSlowMode",
 	}
 
 	// Check that we have the expected number of unique codes
@@ -56,3 +56,36 @@ func DecodeEventFromBody(body interface{}) (*Event, error) {
 	}
 	return DecodeEvent(data)
 }
+
+// ToInt64 将解码得到的数值转换为int64
+// MsgPack解码会产生各种宽度的整数，JSON解码会产生float64
+func ToInt64(v interface{}) (int64, bool) {
+	switch n := v.(type) {
+	case int:
+		return int64(n), true
+	case int8:
+		return int64(n), true
+	case int16:
+		return int64(n), true
+	case int32:
+		return int64(n), true
+	case int64:
+		return n, true
+	case uint:
+		return int64(n), true
+	case uint8:
+		return int64(n), true
+	case uint16:
+		return int64(n), true
+	case uint32:
+		return int64(n), true
+	case uint64:
+		return int64(n), true
+	case float32:
+		return int64(n), true
+	case float64:
+		return int64(n), true
+	default:
+		return 0, false
+	}
+}
@@ -146,3 +146,28 @@ func TestEncodeDecodeEvent(t *testing.T) {
 		t.Errorf("Text mismatch: got %s, want Hello, World!", decoded.GetText())
 	}
 }
+
+func TestToInt64(t *testing.T) {
+	tests := []struct {
+		name   string
+		value  interface{}
+		want   int64
+		wantOk bool
+	}{
+		{"int64", int64(42), 42, true},
+		{"int8 from msgpack", int8(7), 7, true},
+		{"uint32 from msgpack", uint32(70000), 70000, true},
+		{"float64 from json", float64(123), 123, true},
+		{"string", "123", 0, false},
+		{"nil", nil, 0, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := ToInt64(tt.value)
+			if got != tt.want || ok != tt.wantOk {
+				t.Errorf("ToInt64(%v) = (%d, %v), want (%d, %v)", tt.value, got, ok, tt.want, tt.wantOk)
+			}
+		})
+	}
+}
@@ -26,6 +26,20 @@ const (
 	CmdSearch = "search"
 	// CmdSearchResult 搜索结果
 	CmdSearchResult = "search_result"
+	// CmdThreadUpdate 线程更新通知（仅推送给线程订阅者）
+	CmdThreadUpdate = "thread_update"
+	// CmdScheduleUpdate 定时消息状态变更（推送给作者的所有设备）
+	CmdScheduleUpdate = "schedule_update"
+	// CmdDraftUpdate 草稿变更（用户级推送，同步到该用户的所有设备）
+	CmdDraftUpdate = "draft_update"
+	// CmdReadStatus 已读进度更新（推送给被读消息的发送者）
+	CmdReadStatus = "read_status"
+	// CmdDeliveryStatus 送达进度更新（推送给被送达消息的发送者）
+	CmdDeliveryStatus = "delivery_status"
+	// CmdExportUpdate 数据导出状态变更（推送给用户的所有设备）
+	CmdExportUpdate = "export_update"
+	// CmdJoinRequestUpdate 入群申请变更（新申请推送给群主和管理员，处理结果同时推送给申请者）
+	CmdJoinRequestUpdate = "join_request_update"
 
 	// 好友相关命令
 	// CmdGetFriends 获取好友列表
@@ -94,9 +108,10 @@ type AckBody struct {
 
 // ErrorBody 错误消息体
 type ErrorBody struct {
-	Code    int    `msgpack:"0" json:"code"`    // 错误码
-	Message string `msgpack:"1" json:"message"` // 错误信息
-	Seq     int64  `msgpack:"2" json:"seq"`     // 关联的请求序列号
+	Code       int    `msgpack:"0" json:"code"`                  // 错误码
+	Message    string `msgpack:"1" json:"message"`               // 错误信息
+	Seq        int64  `msgpack:"2" json:"seq"`                   // 关联的请求序列号
+	RetryAfter int64  `msgpack:"3" json:"retry_after,omitempty"` // 可重试前需等待的秒数（慢速模式等）
 }
 
 // AuthBody 认证请求体
@@ -148,3 +163,83 @@ type SearchItem struct {
 	Timestamp int64  `msgpack:"4" json:"t"`
 	Highlight string `msgpack:"5" json:"highlight"`
 }
+
+// ThreadUpdateBody 线程更新通知体
+type ThreadUpdateBody struct {
+	Cid     string `msgpack:"0" json:"cid"`      // 会话ID
+	RootMid int64  `msgpack:"1" json:"root_mid"` // 线程根消息ID
+	Mid     int64  `msgpack:"2" json:"mid"`      // 新回复的消息ID
+	Sender  string `msgpack:"3" json:"sender"`   // 回复者
+}
+
+// 定时消息状态
+const (
+	ScheduleStatusPending    = 0 // 等待发送
+	ScheduleStatusProcessing = 1 // 发送中
+	ScheduleStatusSent       = 2 // 已发送
+	ScheduleStatusCancelled  = 3 // 已取消
+	ScheduleStatusFailed     = 4 // 发送失败
+)
+
+// ScheduleUpdateBody 定时消息状态变更通知体
+type ScheduleUpdateBody struct {
+	ID     uint   `msgpack:"0" json:"id"`               // 定时消息ID
+	Cid    string `msgpack:"1" json:"cid"`              // 会话ID
+	Status int    `msgpack:"2" json:"status"`           // 当前状态
+	Mid    int64  `msgpack:"3" json:"mid,omitempty"`    // 发送成功后的消息ID
+	Reason string `msgpack:"4" json:"reason,omitempty"` // 失败原因
+}
+
+// DraftUpdateBody 草稿变更通知体
+type DraftUpdateBody struct {
+	Cid  string `msgpack:"0" json:"cid"`            // 会话ID
+	Data string `msgpack:"1" json:"data,omitempty"` // 草稿密文，为空表示已清除
+	Ts   int64  `msgpack:"2" json:"ts"`             // 客户端修改时间（毫秒）
+}
+
+// ReadStatusBody 已读/送达进度更新通知体（群聊中合并一段时间内的多次更新）
+type ReadStatusBody struct {
+	Cid     string         `msgpack:"0" json:"cid"`     // 会话ID
+	Readers []ReadPosition `msgpack:"1" json:"readers"` // 已读进度有变化的成员
+}
+
+// ReadPosition 成员已读/送达水位
+type ReadPosition struct {
+	Uid         string `msgpack:"0" json:"uid"`           // 成员ID
+	LastReadMid int64  `msgpack:"1" json:"last_read_mid"` // 已读（或已送达）到的消息ID
+}
+
+// 数据导出任务状态
+const (
+	ExportStatusPending    = 0 // 等待打包
+	ExportStatusProcessing = 1 // 打包中
+	ExportStatusCompleted  = 2 // 已完成，可下载
+	ExportStatusFailed     = 3 // 失败
+	ExportStatusExpired    = 4 // 已过期，文件已删除
+)
+
+// ExportUpdateBody 数据导出状态变更通知体
+type ExportUpdateBody struct {
+	ID        uint   `msgpack:"0" json:"id"`                   // 导出任务ID
+	Status    int    `msgpack:"1" json:"status"`               // 当前状态
+	URL       string `msgpack:"2" json:"url,omitempty"`        // 限时下载链接
+	ExpiresAt int64  `msgpack:"3" json:"expires_at,omitempty"` // 下载链接过期时间（Unix秒）
+	Reason    string `msgpack:"4" json:"reason,omitempty"`     // 失败原因
+}
+
+// 入群申请状态
+const (
+	JoinRequestStatusPending  = 0 // 待处理
+	JoinRequestStatusApproved = 1 // 已同意
+	JoinRequestStatusRejected = 2 // 已拒绝
+)
+
+// JoinRequestUpdateBody 入群申请变更通知体
+type JoinRequestUpdateBody struct {
+	ID        uint   `msgpack:"0" json:"id"`                   // 申请ID
+	GroupId   string `msgpack:"1" json:"group_id"`             // 群组ID
+	Uid       string `msgpack:"2" json:"uid"`                  // 申请者
+	Status    int    `msgpack:"3" json:"status"`               // 当前状态
+	Message   string `msgpack:"4" json:"message,omitempty"`    // 申请附言
+	HandledBy string `msgpack:"5" json:"handled_by,omitempty"` // 处理人
+}
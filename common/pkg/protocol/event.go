@@ -15,6 +15,17 @@ type Event struct {
 	Ext       map[string]interface{} `msgpack:"15" json:"ext"`   // 扩展字段
 }
 
+// 事件标志位
+const (
+	// FlagRevoked 已撤销（原内容已被服务端清除）
+	FlagRevoked = 1 << 0
+	// FlagSenderDeleted 发送者已注销（发送者被匿名化，原内容已被服务端清除）
+	FlagSenderDeleted = 1 << 1
+)
+
+// DeletedSender 已注销用户的事件被匿名化后的发送者
+const DeletedSender = "deleted"
+
 // NewEvent 创建新事件
 func NewEvent(kind int, cid string, sender string) *Event {
 	return &Event{
@@ -86,6 +97,12 @@ func (e *Event) GetFileData() *FileData {
 	return fd
 }
 
+// 撤销范围
+const (
+	RevokeScopeAll  = 1 // 全体可见（为所有人删除）
+	RevokeScopeSelf = 2 // 仅自己（为自己删除）
+)
+
 // SetRevokeData 设置撤销数据（Kind=5）
 func (e *Event) SetRevokeData(targetMid int64, scope int, reason string) *Event {
 	e.Tags = append(e.Tags, NewTargetTag(targetMid))
@@ -94,6 +111,16 @@ func (e *Event) SetRevokeData(targetMid int64, scope int, reason string) *Event
 	return e
 }
 
+// GetRevokeData 获取撤销范围和原因，未指定范围时视为全体可见
+func (e *Event) GetRevokeData() (int, string) {
+	scope := RevokeScopeAll
+	if v, ok := ToInt64(e.Data[0]); ok && int(v) == RevokeScopeSelf {
+		scope = RevokeScopeSelf
+	}
+	reason, _ := e.Data[1].(string)
+	return scope, reason
+}
+
 // SetEditData 设置编辑数据（Kind=7）
 func (e *Event) SetEditData(targetMid int64, newContent string, version int) *Event {
 	e.Tags = append(e.Tags, NewTargetTag(targetMid))
@@ -108,6 +135,12 @@ func (e *Event) SetReadReceipt(lastReadMid int64) *Event {
 	return e
 }
 
+// SetDelivered 设置送达回执数据（Kind=16）
+func (e *Event) SetDelivered(lastDeliveredMid int64) *Event {
+	e.Data[0] = lastDeliveredMid
+	return e
+}
+
 // SetTyping 设置正在输入状态（Kind=11）
 func (e *Event) SetTyping(state int) *Event {
 	e.Data[0] = state
@@ -139,6 +172,85 @@ func (e *Event) SetForward(sourceCid string, sourceMid int64, forwardType int, s
 	return e
 }
 
+// 置顶操作
+const (
+	PinActionPin   = 1 // 置顶
+	PinActionUnpin = 2 // 取消置顶
+)
+
+// SetPinData 设置置顶数据（Kind=14）
+func (e *Event) SetPinData(targetMid int64, action int) *Event {
+	e.Tags = append(e.Tags, NewTargetTag(targetMid))
+	e.Data[0] = action
+	return e
+}
+
+// GetPinAction 获取置顶操作，未指定时视为置顶
+func (e *Event) GetPinAction() int {
+	if v, ok := ToInt64(e.Data[0]); ok && int(v) == PinActionUnpin {
+		return PinActionUnpin
+	}
+	return PinActionPin
+}
+
+// SetMessageTTLData 设置消息过期时间（Kind=15），ttl为秒，0表示关闭
+func (e *Event) SetMessageTTLData(ttl int64) *Event {
+	e.Data[0] = ttl
+	return e
+}
+
+// GetMessageTTL 获取消息过期时间（秒）
+func (e *Event) GetMessageTTL() (int64, bool) {
+	return ToInt64(e.Data[0])
+}
+
+// SystemData 系统事件载荷（明文，Kind 100-199）
+type SystemData struct {
+	Targets []string               // 被操作的成员
+	Attrs   map[string]interface{} // 变更详情，如 name、role、muted、duration、version
+}
+
+// SetSystemData 设置系统事件载荷，操作者即事件的 Sender
+func (e *Event) SetSystemData(targets []string, attrs map[string]interface{}) *Event {
+	if targets == nil {
+		targets = []string{}
+	}
+	if attrs == nil {
+		attrs = map[string]interface{}{}
+	}
+	e.Data[0] = targets
+	e.Data[1] = attrs
+	return e
+}
+
+// GetSystemData 获取系统事件载荷（兼容 JSON/msgpack 解码后的类型）
+func (e *Event) GetSystemData() *SystemData {
+	d := &SystemData{Attrs: map[string]interface{}{}}
+
+	switch v := e.Data[0].(type) {
+	case []string:
+		d.Targets = v
+	case []interface{}:
+		for _, t := range v {
+			if s, ok := t.(string); ok {
+				d.Targets = append(d.Targets, s)
+			}
+		}
+	}
+
+	switch v := e.Data[1].(type) {
+	case map[string]interface{}:
+		d.Attrs = v
+	case map[interface{}]interface{}:
+		for k, val := range v {
+			if s, ok := k.(string); ok {
+				d.Attrs[s] = val
+			}
+		}
+	}
+	return d
+}
+
 // AddReplyTag 添加回复标签
 func (e *Event) AddReplyTag(mid int64) *Event {
 	e.Tags = append(e.Tags, NewReplyTag(mid))
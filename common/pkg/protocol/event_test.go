@@ -126,6 +126,66 @@ func TestSetRevokeData(t *testing.T) {
 	}
 }
 
+func TestGetRevokeData(t *testing.T) {
+	event := NewEvent(KindRevoke, "conv123", "user456")
+	event.SetRevokeData(100, RevokeScopeSelf, "typo")
+
+	scope, reason := event.GetRevokeData()
+	if scope != RevokeScopeSelf {
+		t.Errorf("Scope mismatch: got %d, want %d", scope, RevokeScopeSelf)
+	}
+	if reason != "typo" {
+		t.Errorf("Reason mismatch: got %s, want typo", reason)
+	}
+
+	// JSON解码后数值为float64
+	event.Data[0] = float64(RevokeScopeSelf)
+	if scope, _ := event.GetRevokeData(); scope != RevokeScopeSelf {
+		t.Errorf("Scope from float64 mismatch: got %d, want %d", scope, RevokeScopeSelf)
+	}
+
+	// 未指定范围时默认全体可见
+	empty := NewEvent(KindRevoke, "conv123", "user456")
+	if scope, _ := empty.GetRevokeData(); scope != RevokeScopeAll {
+		t.Errorf("Default scope mismatch: got %d, want %d", scope, RevokeScopeAll)
+	}
+}
+
+func TestSetPinData(t *testing.T) {
+	event := NewEvent(KindPin, "conv123", "user456")
+	event.SetPinData(100, PinActionUnpin)
+
+	targetMid, ok := GetTargetMid(event.Tags)
+	if !ok || targetMid != 100 {
+		t.Errorf("Target mid mismatch: got %d", targetMid)
+	}
+	if event.GetPinAction() != PinActionUnpin {
+		t.Errorf("Action mismatch: got %d, want %d", event.GetPinAction(), PinActionUnpin)
+	}
+
+	// 未指定操作时默认置顶
+	empty := NewEvent(KindPin, "conv123", "user456")
+	if empty.GetPinAction() != PinActionPin {
+		t.Errorf("Default action mismatch: got %d, want %d", empty.GetPinAction(), PinActionPin)
+	}
+}
+
+func TestSetMessageTTLData(t *testing.T) {
+	event := NewEvent(KindMessageTTL, "conv123", "user456")
+	event.SetMessageTTLData(86400)
+
+	ttl, ok := event.GetMessageTTL()
+	if !ok || ttl != 86400 {
+		t.Errorf("TTL mismatch: got %d", ttl)
+	}
+
+	// 0 表示关闭自动过期
+	event.SetMessageTTLData(0)
+	if ttl, ok := event.GetMessageTTL(); !ok || ttl != 0 {
+		t.Errorf("TTL mismatch: got %d, want 0", ttl)
+	}
+}
+
 func TestSetEditData(t *testing.T) {
 	event := NewEvent(KindEdit, "conv123", "user456")
 	event.SetEditData(200, "updated content", 2)
@@ -157,6 +217,18 @@ func TestSetReadReceipt(t *testing.T) {
 	}
 }
 
+func TestSetDelivered(t *testing.T) {
+	event := NewEvent(KindDelivered, "conv123", "user456")
+	event.SetDelivered(300)
+
+	if event.Data[0] != int64(300) {
+		t.Errorf("LastDeliveredMid mismatch: got %v, want 300", event.Data[0])
+	}
+	if IsPersistent(KindDelivered) {
+		t.Error("delivery receipts should not be stored as events")
+	}
+}
+
 func TestSetTyping(t *testing.T) {
 	event := NewEvent(KindTyping, "conv123", "user456")
 	event.SetTyping(1)
@@ -227,3 +299,40 @@ func TestAddMentionAllTag(t *testing.T) {
 		t.Errorf("Tag value mismatch: got %v, want all", tag.Value)
 	}
 }
+
+func TestSetSystemData(t *testing.T) {
+	event := NewEvent(KindMemberJoined, "g:group1", "owner")
+	event.SetSystemData([]string{"alice", "bob"}, map[string]interface{}{"role": 0})
+
+	if !IsSystemKind(event.Kind) || !IsPersistent(event.Kind) {
+		t.Fatalf("kind %d should be a persistent system kind", event.Kind)
+	}
+	if IsSystemKind(KindText) || IsSystemKind(KindSystemMax+1) {
+		t.Error("non-system kinds must not be in the reserved range")
+	}
+
+	d := event.GetSystemData()
+	if len(d.Targets) != 2 || d.Targets[0] != "alice" || d.Targets[1] != "bob" {
+		t.Errorf("Targets mismatch: got %v", d.Targets)
+	}
+	if d.Attrs["role"] != 0 {
+		t.Errorf("Attrs mismatch: got %v", d.Attrs)
+	}
+
+	// 编解码后载荷类型变为通用类型
+	data, err := EncodeEvent(event)
+	if err != nil {
+		t.Fatalf("EncodeEvent failed: %v", err)
+	}
+	decoded, err := DecodeEvent(data)
+	if err != nil {
+		t.Fatalf("DecodeEvent failed: %v", err)
+	}
+	d = decoded.GetSystemData()
+	if len(d.Targets) != 2 || d.Targets[1] != "bob" {
+		t.Errorf("decoded Targets mismatch: got %v", d.Targets)
+	}
+	if role, ok := ToInt64(d.Attrs["role"]); !ok || role != 0 {
+		t.Errorf("decoded role mismatch: got %v", d.Attrs["role"])
+	}
+}
@@ -18,8 +18,46 @@ const (
 	KindReaction = 12
 	// KindForward 转发消息
 	KindForward = 13
+	// KindPin 置顶/取消置顶消息
+	KindPin = 14
+	// KindMessageTTL 会话消息过期时间变更
+	KindMessageTTL = 15
+	// KindDelivered 送达回执（仅记录水位，不存储事件）
+	KindDelivered = 16
 )
 
+// 系统事件（由服务端生成，载荷为明文结构化数据，客户端不可发送）
+const (
+	// KindSystemMin 系统事件保留区间下界
+	KindSystemMin = 100
+	// KindSystemMax 系统事件保留区间上界
+	KindSystemMax = 199
+
+	// KindGroupCreated 群组创建
+	KindGroupCreated = 100
+	// KindGroupUpdated 群资料变更（群名/简介/头像）
+	KindGroupUpdated = 101
+	// KindGroupDismissed 群组解散
+	KindGroupDismissed = 102
+	// KindMemberJoined 成员加入
+	KindMemberJoined = 103
+	// KindMemberLeft 成员退出或被移除
+	KindMemberLeft = 104
+	// KindMemberRoleChanged 成员角色变更（设置/取消管理员、转让群主）
+	KindMemberRoleChanged = 105
+	// KindMemberMuted 成员禁言/取消禁言
+	KindMemberMuted = 106
+	// KindKeyRotated 群组密钥轮换
+	KindKeyRotated = 107
+	// KindAnnouncementUpdated 群公告发布新版本或被清除
+	KindAnnouncementUpdated = 108
+)
+
+// IsSystemKind 判断是否为系统事件
+func IsSystemKind(kind int) bool {
+	return kind >= KindSystemMin && kind <= KindSystemMax
+}
+
 // KindName 获取Kind名称
 func KindName(kind int) string {
 	switch kind {
@@ -39,6 +77,30 @@ func KindName(kind int) string {
 		return "reaction"
 	case KindForward:
 		return "forward"
+	case KindPin:
+		return "pin"
+	case KindMessageTTL:
+		return "message_ttl"
+	case KindDelivered:
+		return "delivered"
+	case KindGroupCreated:
+		return "group_created"
+	case KindGroupUpdated:
+		return "group_updated"
+	case KindGroupDismissed:
+		return "group_dismissed"
+	case KindMemberJoined:
+		return "member_joined"
+	case KindMemberLeft:
+		return "member_left"
+	case KindMemberRoleChanged:
+		return "member_role_changed"
+	case KindMemberMuted:
+		return "member_muted"
+	case KindKeyRotated:
+		return "key_rotated"
+	case KindAnnouncementUpdated:
+		return "announcement_updated"
 	default:
 		return "unknown"
 	}
@@ -47,9 +109,10 @@ func KindName(kind int) string {
 // IsPersistent 判断消息类型是否需要持久化
 func IsPersistent(kind int) bool {
 	switch kind {
-	case KindTyping:
+	case KindTyping, KindDelivered:
 		return false
 	default:
+		// 系统事件均需持久化，作为会话变更的审计记录
 		return true
 	}
 }
@@ -63,7 +63,7 @@ func ParseTags(tags []Tag) map[int][]interface{} {
 func GetReplyMid(tags []Tag) (int64, bool) {
 	for _, tag := range tags {
 		if tag.Type == TagReply {
-			if mid, ok := tag.Value.(int64); ok {
+			if mid, ok := ToInt64(tag.Value); ok {
 				return mid, true
 			}
 		}
@@ -75,7 +75,7 @@ func GetReplyMid(tags []Tag) (int64, bool) {
 func GetTargetMid(tags []Tag) (int64, bool) {
 	for _, tag := range tags {
 		if tag.Type == TagTarget {
-			if mid, ok := tag.Value.(int64); ok {
+			if mid, ok := ToInt64(tag.Value); ok {
 				return mid, true
 			}
 		}
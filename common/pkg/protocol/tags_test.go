@@ -135,6 +135,14 @@ func TestGetTargetMid(t *testing.T) {
 			wantMid: 999,
 			wantOk:  true,
 		},
+		{
+			name: "target tag decoded from json",
+			tags: []Tag{
+				{Type: TagTarget, Value: float64(321)},
+			},
+			wantMid: 321,
+			wantOk:  true,
+		},
 		{
 			name: "no target tag",
 			tags: []Tag{
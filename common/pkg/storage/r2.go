@@ -61,14 +61,20 @@ func NewR2Storage(cfg config.R2Configuration) (*R2Storage, error) {
 
 // UploadFile 上传文件到 R2
 func (r *R2Storage) UploadFile(ctx context.Context, data []byte, filename string) (string, error) {
+	return r.UploadStream(ctx, bytes.NewReader(data), int64(len(data)), filename)
+}
+
+// UploadStream 上传文件到 R2（从可定位的流读取，如临时文件，避免整个文件驻留内存）
+func (r *R2Storage) UploadStream(ctx context.Context, body io.ReadSeeker, size int64, filename string) (string, error) {
 	// 生成文件路径: YYYY/MM/DD/UUID-filename
 	key := fmt.Sprintf("%s/%s-%s", time.Now().Format("2006/01/02"), uuid.New().String(), filename)
 
 	_, err := r.client.PutObject(ctx, &s3.PutObjectInput{
-		Bucket:      aws.String(r.bucketName),
-		Key:         aws.String(key),
-		Body:        bytes.NewReader(data),
-		ContentType: aws.String(getContentType(filename)),
+		Bucket:        aws.String(r.bucketName),
+		Key:           aws.String(key),
+		Body:          body,
+		ContentLength: aws.Int64(size),
+		ContentType:   aws.String(getContentType(filename)),
 	})
 
 	if err != nil {
@@ -125,6 +131,20 @@ func (r *R2Storage) GetFileURL(key string) string {
 	return fmt.Sprintf("https://%s/%s", r.exportEndpoint, key)
 }
 
+// PresignDownloadURL 生成限时下载链接，filename 为下载时保存的文件名
+func (r *R2Storage) PresignDownloadURL(ctx context.Context, key, filename string, ttl time.Duration) (string, error) {
+	presigner := s3.NewPresignClient(r.client)
+	req, err := presigner.PresignGetObject(ctx, &s3.GetObjectInput{
+		Bucket:                     aws.String(r.bucketName),
+		Key:                        aws.String(key),
+		ResponseContentDisposition: aws.String(fmt.Sprintf("attachment; filename=%q", filename)),
+	}, s3.WithPresignExpires(ttl))
+	if err != nil {
+		return "", fmt.Errorf("failed to presign R2 url: %w", err)
+	}
+	return req.URL, nil
+}
+
 // getContentType 根据文件扩展名返回 MIME 类型
 func getContentType(filename string) string {
 	ext := strings.ToLower(filepath.Ext(filename))
@@ -27,4 +27,10 @@ type GatewayConfiguration struct {
 
 	// 上传限制
 	UploadRateLimit int `mapstructure:"UploadRateLimit"` // 每小时每用户最大上传次数，0 表示不限制
+
+	// 定时消息
+	ScheduleInterval int `mapstructure:"ScheduleInterval"` // 扫描到期定时消息的间隔（秒），默认5秒
+
+	// 反向代理
+	TrustedProxies []string `mapstructure:"TrustedProxies"` // 信任的代理地址（IP或CIDR），为空时忽略 X-Forwarded-For，使用连接地址作为客户端IP
 }
@@ -0,0 +1,96 @@
+package handler
+
+import (
+	"context"
+	"time"
+
+	"github.com/my-chat/common/pkg/log"
+	"github.com/my-chat/common/pkg/protocol"
+)
+
+const (
+	// deletionBatchSize 每轮最多领取的注销任务数
+	deletionBatchSize = 10
+	// relayPurgeBatch 每次调用Relay清理时每张表最多处理的记录数
+	relayPurgeBatch = 500
+	// groupRoleOwner 群主角色（与 SeaKing 一致）
+	groupRoleOwner = 2
+)
+
+// RunAccountPurge 定时清理冷静期已结束的注销账号
+func (h *Handler) RunAccountPurge(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
+		h.purgeDueAccounts(ctx)
+		cancel()
+	}
+}
+
+// purgeDueAccounts 清理一批冷静期已结束的注销账号
+func (h *Handler) purgeDueAccounts(ctx context.Context) {
+	uids, err := h.seakingClient.ClaimDueDeletions(ctx, deletionBatchSize)
+	if err != nil {
+		log.Error().Err(err).Msg("failed to claim account deletions")
+		return
+	}
+
+	for _, uid := range uids {
+		h.purgeAccount(ctx, uid)
+	}
+}
+
+// purgeAccount 先清理Relay中的数据，再清理SeaKing中的数据并标记完成
+// 任一步失败时保持领取状态，超时后被重新领取并从头执行（每一步都可重复执行）
+// SeaKing 记录已退出的群组，重新执行时仍返回完整的退群结果，系统事件在全部清理成功后发送
+func (h *Handler) purgeAccount(ctx context.Context, uid string) {
+	for {
+		done, err := h.relayClient.PurgeUser(ctx, uid, relayPurgeBatch)
+		if err != nil {
+			log.Error().Err(err).Str("uid", uid).Msg("failed to purge user events")
+			return
+		}
+		if done {
+			break
+		}
+	}
+
+	result, err := h.seakingClient.PurgeAccount(ctx, uid)
+	if err != nil {
+		log.Error().Err(err).Str("uid", uid).Msg("failed to purge account")
+		return
+	}
+
+	// 在线成员需要感知群成员变化；事件中不再出现已注销用户的ID
+	for _, groupID := range result.Left {
+		cid := "g:" + groupID
+		if newOwner, ok := result.Transferred[groupID]; ok {
+			h.storeSystemEvent(ctx, cid, protocol.KindMemberRoleChanged, []string{newOwner},
+				map[string]any{"role": groupRoleOwner})
+		}
+		h.storeSystemEvent(ctx, cid, protocol.KindMemberLeft, []string{protocol.DeletedSender},
+			map[string]any{"reason": "deleted"})
+	}
+
+	log.Info().Str("uid", uid).
+		Int("groups_left", len(result.Left)).
+		Int("groups_dissolved", len(result.Dissolved)).
+		Msg("account purged")
+}
+
+// storeSystemEvent 以已注销用户的名义存储并广播系统事件
+func (h *Handler) storeSystemEvent(ctx context.Context, cid string, kind int, targets []string, attrs map[string]any) {
+	event := protocol.NewEvent(kind, cid, protocol.DeletedSender).SetSystemData(targets, attrs)
+
+	resp, err := h.relayClient.StoreEvent(ctx, event, 0)
+	if err != nil {
+		log.Error().Err(err).Str("cid", cid).Str("kind", protocol.KindName(kind)).Msg("failed to store system event")
+		return
+	}
+	event.Mid = resp.Mid
+	event.Timestamp = resp.Timestamp
+
+	h.broadcastEvent(event)
+}
@@ -0,0 +1,308 @@
+package handler
+
+import (
+	"archive/zip"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/my-chat/common/pkg/client"
+	"github.com/my-chat/common/pkg/log"
+	"github.com/my-chat/common/pkg/protocol"
+	"github.com/my-chat/common/pkg/storage"
+)
+
+const (
+	// ExportFormatVersion 导出文件格式版本，结构不兼容变更时递增
+	ExportFormatVersion = 1
+	// exportBatchSize 每轮最多领取的导出任务数
+	exportBatchSize = 2
+	// exportEventBatch 每次从Relay拉取的事件数
+	exportEventBatch = 500
+	// exportTimeout 单个导出任务的打包时间上限（需小于 SeaKing 的领取超时）
+	exportTimeout = 10 * time.Minute
+	// exportLinkTTL 下载链接有效期上限，不超过导出文件的过期时间
+	exportLinkTTL = 24 * time.Hour
+)
+
+// exportManifest 导出文件清单（manifest.json）
+type exportManifest struct {
+	Version     int                 `json:"version"`
+	ExportID    uint                `json:"export_id"`
+	Uid         string              `json:"uid"`
+	GeneratedAt int64               `json:"generated_at"`
+	Files       []exportManifestRef `json:"files"`
+	Encryption  string              `json:"encryption"`
+}
+
+// exportManifestRef 导出文件中的单个文件
+type exportManifestRef struct {
+	Name    string `json:"name"`
+	Format  string `json:"format"` // json 或 ndjson
+	Cid     string `json:"cid,omitempty"`
+	Records int    `json:"records,omitempty"` // ndjson 文件的行数
+}
+
+// exportEncryptionNote 写入清单的加密说明
+const exportEncryptionNote = "message data and private keys are end-to-end encrypted; " +
+	"decrypt the private key in keys.json with your password, then the chat and group keys, then the events"
+
+// WithStorage 设置导出文件使用的对象存储
+func (h *Handler) WithStorage(r2 *storage.R2Storage) *Handler {
+	h.r2 = r2
+	return h
+}
+
+// RunDataExport 定时打包待导出的个人数据并清理过期的导出文件
+func (h *Handler) RunDataExport(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		h.processDueExports()
+		h.removeExpiredExports()
+	}
+}
+
+// processDueExports 打包一批待导出的任务
+func (h *Handler) processDueExports() {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	exports, err := h.seakingClient.ClaimDueExports(ctx, exportBatchSize)
+	cancel()
+	if err != nil {
+		log.Error().Err(err).Msg("failed to claim data exports")
+		return
+	}
+
+	for i := range exports {
+		ctx, cancel := context.WithTimeout(context.Background(), exportTimeout)
+		h.processExport(ctx, &exports[i])
+		cancel()
+	}
+}
+
+// processExport 收集数据、打包上传并通知用户
+// 压缩包写入临时文件再流式上传，大量历史消息不会占用Gateway内存
+func (h *Handler) processExport(ctx context.Context, export *client.DataExportInfo) {
+	f, err := os.CreateTemp("", "export-*.zip")
+	if err != nil {
+		log.Error().Err(err).Uint("id", export.ID).Msg("failed to create data export file")
+		h.failExport(ctx, export, "failed to collect data")
+		return
+	}
+	defer os.Remove(f.Name())
+	defer f.Close()
+
+	if err := h.buildExportArchive(ctx, f, export); err != nil {
+		log.Error().Err(err).Uint("id", export.ID).Str("uid", export.Uid).Msg("failed to build data export")
+		h.failExport(ctx, export, "failed to collect data")
+		return
+	}
+
+	size, err := f.Seek(0, io.SeekCurrent)
+	if err == nil {
+		_, err = f.Seek(0, io.SeekStart)
+	}
+	if err != nil {
+		log.Error().Err(err).Uint("id", export.ID).Msg("failed to read data export file")
+		h.failExport(ctx, export, "failed to collect data")
+		return
+	}
+
+	key, err := h.r2.UploadStream(ctx, f, size, fmt.Sprintf("export-%d.zip", export.ID))
+	if err != nil {
+		log.Error().Err(err).Uint("id", export.ID).Msg("failed to upload data export")
+		h.failExport(ctx, export, "failed to store archive")
+		return
+	}
+
+	done, err := h.seakingClient.CompleteDataExport(ctx, export.ID, key, size, "")
+	if err != nil {
+		// 任务保持领取状态，超时后重新打包
+		log.Error().Err(err).Uint("id", export.ID).Str("key", key).Msg("failed to complete data export")
+		return
+	}
+
+	body := &protocol.ExportUpdateBody{ID: done.ID, Status: done.Status}
+	body.URL, body.ExpiresAt, err = ExportDownloadLink(ctx, h.r2, done)
+	if err != nil {
+		log.Error().Err(err).Uint("id", export.ID).Msg("failed to sign data export link")
+	}
+	h.sendExportUpdate(export.Uid, body)
+
+	log.Info().Uint("id", export.ID).Str("uid", export.Uid).Int64("size", size).Msg("data export completed")
+}
+
+// failExport 标记导出失败并通知用户，用户可重新申请
+func (h *Handler) failExport(ctx context.Context, export *client.DataExportInfo, reason string) {
+	if _, err := h.seakingClient.CompleteDataExport(ctx, export.ID, "", 0, reason); err != nil {
+		log.Error().Err(err).Uint("id", export.ID).Msg("failed to complete data export")
+		return
+	}
+
+	h.sendExportUpdate(export.Uid, &protocol.ExportUpdateBody{
+		ID:     export.ID,
+		Status: protocol.ExportStatusFailed,
+		Reason: reason,
+	})
+}
+
+// buildExportArchive 从SeaKing和Relay收集用户数据并打包为zip写入w
+// 消息内容和密钥保持加密状态，服务端不接触明文
+func (h *Handler) buildExportArchive(ctx context.Context, w io.Writer, export *client.DataExportInfo) error {
+	userData, err := h.seakingClient.ExportUserData(ctx, export.Uid)
+	if err != nil {
+		return err
+	}
+
+	zw := zip.NewWriter(w)
+	manifest := exportManifest{
+		Version:     ExportFormatVersion,
+		ExportID:    export.ID,
+		Uid:         export.Uid,
+		GeneratedAt: time.Now().Unix(),
+		Encryption:  exportEncryptionNote,
+	}
+
+	for _, f := range []struct {
+		name string
+		data json.RawMessage
+	}{
+		{"profile.json", userData.Profile},
+		{"security_logs.json", userData.SecurityLogs},
+		{"relations.json", userData.Relations},
+		{"groups.json", userData.Groups},
+		{"conversations.json", userData.Conversations},
+		{"keys.json", userData.Keys},
+	} {
+		if err := writeZipJSON(zw, f.name, f.data); err != nil {
+			return err
+		}
+		manifest.Files = append(manifest.Files, exportManifestRef{Name: f.name, Format: "json"})
+	}
+
+	for _, cid := range userData.Cids {
+		name := "events/" + strings.ReplaceAll(cid, ":", "_") + ".ndjson"
+		count, err := h.writeConversationEvents(ctx, zw, name, cid, export.Uid)
+		if err != nil {
+			return err
+		}
+		manifest.Files = append(manifest.Files, exportManifestRef{Name: name, Format: "ndjson", Cid: cid, Records: count})
+	}
+
+	data, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := writeZipJSON(zw, "manifest.json", data); err != nil {
+		return err
+	}
+
+	return zw.Close()
+}
+
+// writeConversationEvents 分页拉取用户在会话中可见的事件，每行一个事件写入zip
+func (h *Handler) writeConversationEvents(ctx context.Context, zw *zip.Writer, name, cid, uid string) (int, error) {
+	w, err := zw.Create(name)
+	if err != nil {
+		return 0, err
+	}
+	enc := json.NewEncoder(w)
+
+	count := 0
+	var lastMid int64
+	for {
+		resp, err := h.relayClient.QueryEvents(ctx, &client.QueryEventsRequest{
+			Cid:     cid,
+			Uid:     uid,
+			LastMid: lastMid,
+			Limit:   exportEventBatch,
+		})
+		if err != nil {
+			return 0, err
+		}
+		if len(resp.Events) == 0 {
+			return count, nil
+		}
+		for i := range resp.Events {
+			if err := enc.Encode(&resp.Events[i]); err != nil {
+				return 0, err
+			}
+		}
+		count += len(resp.Events)
+		lastMid = resp.Events[len(resp.Events)-1].Mid
+	}
+}
+
+// writeZipJSON 写入一个JSON文件，数据为空时写入 null
+func writeZipJSON(zw *zip.Writer, name string, data []byte) error {
+	w, err := zw.Create(name)
+	if err != nil {
+		return err
+	}
+	if len(data) == 0 {
+		data = []byte("null")
+	}
+	_, err = w.Write(data)
+	return err
+}
+
+// removeExpiredExports 删除已过期的导出文件
+func (h *Handler) removeExpiredExports() {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	exports, err := h.seakingClient.GetExpiredExports(ctx, 100)
+	if err != nil {
+		log.Error().Err(err).Msg("failed to get expired data exports")
+		return
+	}
+
+	for _, export := range exports {
+		if err := h.r2.DeleteFile(ctx, export.FileKey); err != nil {
+			log.Error().Err(err).Uint("id", export.ID).Msg("failed to delete expired data export")
+			continue
+		}
+		if err := h.seakingClient.ExpireDataExport(ctx, export.ID); err != nil {
+			log.Error().Err(err).Uint("id", export.ID).Msg("failed to expire data export")
+		}
+	}
+}
+
+// sendExportUpdate 推送导出状态变更给用户的所有设备
+func (h *Handler) sendExportUpdate(uid string, body *protocol.ExportUpdateBody) {
+	data, err := protocol.Encode(protocol.NewEnvelope(protocol.CmdExportUpdate, 0, body))
+	if err != nil {
+		log.Error().Err(err).Msg("failed to encode export update")
+		return
+	}
+
+	h.hub.SendToUser(uid, data)
+}
+
+// ExportDownloadLink 为已完成的导出生成限时下载链接，返回链接及其过期时间（Unix秒）
+func ExportDownloadLink(ctx context.Context, r2 *storage.R2Storage, export *client.DataExportInfo) (string, int64, error) {
+	if export.Status != protocol.ExportStatusCompleted || export.FileKey == "" {
+		return "", 0, nil
+	}
+
+	now := time.Now()
+	ttl := time.Unix(export.ExpiresAt, 0).Sub(now)
+	if ttl > exportLinkTTL {
+		ttl = exportLinkTTL
+	}
+	if ttl <= 0 {
+		return "", 0, nil
+	}
+
+	filename := fmt.Sprintf("mychat-export-%s.zip", time.Unix(export.CreatedAt, 0).Format("20060102"))
+	url, err := r2.PresignDownloadURL(ctx, export.FileKey, filename, ttl)
+	if err != nil {
+		return "", 0, err
+	}
+	return url, now.Add(ttl).Unix(), nil
+}
@@ -10,7 +10,9 @@ import (
 	"github.com/my-chat/common/pkg/errors"
 	"github.com/my-chat/common/pkg/log"
 	"github.com/my-chat/common/pkg/protocol"
+	"github.com/my-chat/common/pkg/storage"
 	"github.com/my-chat/gateway/internal/ws"
+	"github.com/redis/go-redis/v9"
 )
 
 // Handler 消息处理器（仅处理消息推送相关）
@@ -19,6 +21,9 @@ type Handler struct {
 	jwtManager    *auth.JWTManager
 	relayClient   *client.RelayClient
 	seakingClient *client.SeaKingClient
+	readStatus    *readStatusNotifier
+	r2            *storage.R2Storage
+	redis         *redis.Client
 }
 
 // NewHandler 创建处理器
@@ -28,6 +33,7 @@ func NewHandler(hub *ws.Hub, jwtManager *auth.JWTManager, relayAddr, seakingAddr
 		jwtManager:    jwtManager,
 		relayClient:   client.NewRelayClient(relayAddr),
 		seakingClient: client.NewSeaKingClient(seakingAddr),
+		readStatus:    newReadStatusNotifier(hub, readStatusDebounce),
 	}
 }
 
@@ -74,6 +80,12 @@ func (h *Handler) handleEvent(conn *ws.Conn, env *protocol.Envelope) {
 		return
 	}
 
+	// 系统事件只能由服务端生成
+	if protocol.IsSystemKind(event.Kind) {
+		h.sendError(conn, env.Seq, errors.ErrInvalidParam)
+		return
+	}
+
 	// 设置发送者
 	event.Sender = conn.UID()
 
@@ -94,11 +106,34 @@ func (h *Handler) handleEvent(conn *ws.Conn, env *protocol.Envelope) {
 	}
 
 	// 检查是否被禁言
-	if accessResp.Muted && event.Kind != protocol.KindReadReceipt {
+	isReceipt := event.Kind == protocol.KindReadReceipt || event.Kind == protocol.KindDelivered
+	if accessResp.Muted && !isReceipt {
 		h.sendError(conn, env.Seq, errors.New(errors.ErrCodeForbidden, "you are muted"))
 		return
 	}
 
+	// 全员禁言时仅群主和管理员可发言
+	if !accessResp.CanSend && !isReceipt {
+		h.sendError(conn, env.Seq, errors.ErrGroupMuted)
+		return
+	}
+
+	// 单聊双方存在拉黑关系时禁止发送（含正在输入），回执只记录水位不通知对方
+	if accessResp.Blocked && !isReceipt {
+		h.sendError(conn, env.Seq, errors.ErrBlocked)
+		return
+	}
+
+	// 慢速模式限制成员两次发送内容消息的间隔，发送前占用本次发言机会
+	slowMode := 0
+	if accessResp.SlowMode > 0 && isSlowModeKind(event.Kind) {
+		if wait := h.reserveSlowMode(ctx, event.Cid, conn.UID(), accessResp.SlowMode); wait > 0 {
+			h.sendRetryError(conn, env.Seq, errors.ErrSlowMode, wait)
+			return
+		}
+		slowMode = accessResp.SlowMode
+	}
+
 	// 根据消息类型处理
 	switch event.Kind {
 	case protocol.KindTyping:
@@ -108,19 +143,33 @@ func (h *Handler) handleEvent(conn *ws.Conn, env *protocol.Envelope) {
 
 	case protocol.KindRevoke:
 		// 撤销消息需要验证权限
-		h.handleRevokeEvent(ctx, conn, env, event, accessResp.Role >= 1)
+		h.handleRevokeEvent(ctx, conn, env, event, accessResp.Role >= 1, accessResp.MessageTTL)
 
 	case protocol.KindEdit:
 		// 编辑消息需要验证权限
-		h.handleEditEvent(ctx, conn, env, event)
+		h.handleEditEvent(ctx, conn, env, event, accessResp.MessageTTL)
 
 	case protocol.KindReadReceipt:
 		// 已读回执直接更新
-		h.handleReadReceiptEvent(ctx, conn, env, event)
+		h.handleReadReceiptEvent(ctx, conn, env, event, !accessResp.Blocked)
+
+	case protocol.KindDelivered:
+		// 送达回执只记录水位，不广播
+		h.handleDeliveredEvent(ctx, conn, env, event, !accessResp.Blocked)
+
+	case protocol.KindPin:
+		// 置顶消息需要验证权限
+		h.handlePinEvent(ctx, conn, env, event, accessResp.MessageTTL)
+
+	case protocol.KindMessageTTL:
+		// 修改消息过期时间需要验证权限
+		h.handleMessageTTLEvent(ctx, conn, env, event)
 
 	default:
-		// 其他消息需要持久化
-		h.handlePersistentEvent(ctx, conn, env, event)
+		// 其他消息需要持久化，存储失败时释放慢速模式发言机会
+		if !h.handlePersistentEvent(ctx, conn, env, event, accessResp.MessageTTL) {
+			h.releaseSlowMode(ctx, event.Cid, conn.UID(), slowMode)
+		}
 	}
 
 	log.Debug().
@@ -130,14 +179,14 @@ func (h *Handler) handleEvent(conn *ws.Conn, env *protocol.Envelope) {
 		Msg("event processed")
 }
 
-// handlePersistentEvent 处理需要持久化的事件
-func (h *Handler) handlePersistentEvent(ctx context.Context, conn *ws.Conn, env *protocol.Envelope, event *protocol.Event) {
+// handlePersistentEvent 处理需要持久化的事件，ttl为会话消息过期时间，返回是否存储成功
+func (h *Handler) handlePersistentEvent(ctx context.Context, conn *ws.Conn, env *protocol.Envelope, event *protocol.Event, ttl int64) bool {
 	// 存储到Relay
-	resp, err := h.relayClient.StoreEvent(ctx, event)
+	resp, err := h.relayClient.StoreEvent(ctx, event, ttl)
 	if err != nil {
 		log.Error().Err(err).Msg("failed to store event")
 		h.sendError(conn, env.Seq, errors.ErrInternal)
-		return
+		return false
 	}
 
 	// 更新事件的mid和时间戳
@@ -147,12 +196,58 @@ func (h *Handler) handlePersistentEvent(ctx context.Context, conn *ws.Conn, env
 	// 广播给会话中的其他用户
 	h.broadcastEvent(event)
 
+	// 回复消息通知线程订阅者
+	if resp.RootMid > 0 {
+		h.notifyThreadSubscribers(ctx, event, resp.RootMid)
+	}
+
 	// 发送确认
 	h.sendAck(conn, env.Seq, resp.Mid)
+	return true
+}
+
+// notifyThreadSubscribers 向仍是会话成员的线程订阅者（发送者除外）推送线程更新
+func (h *Handler) notifyThreadSubscribers(ctx context.Context, event *protocol.Event, rootMid int64) {
+	uids, err := h.relayClient.GetThreadSubscribers(ctx, event.Cid, rootMid)
+	if err != nil {
+		log.Error().Err(err).Msg("failed to get thread subscribers")
+		return
+	}
+	if len(uids) == 0 {
+		return
+	}
+
+	// 订阅记录可能残留已退出的成员，只推送给当前成员
+	members, err := h.seakingClient.GetConversationMembers(ctx, event.Cid)
+	if err != nil {
+		log.Error().Err(err).Str("cid", event.Cid).Msg("failed to get conversation members")
+		return
+	}
+	isMember := make(map[string]bool, len(members.Members))
+	for _, m := range members.Members {
+		isMember[m.Uid] = true
+	}
+
+	data, err := protocol.Encode(protocol.NewEnvelope(protocol.CmdThreadUpdate, 0, &protocol.ThreadUpdateBody{
+		Cid:     event.Cid,
+		RootMid: rootMid,
+		Mid:     event.Mid,
+		Sender:  event.Sender,
+	}))
+	if err != nil {
+		log.Error().Err(err).Msg("failed to encode thread update")
+		return
+	}
+
+	for _, uid := range uids {
+		if uid != event.Sender && isMember[uid] {
+			h.hub.SendToUser(uid, data)
+		}
+	}
 }
 
 // handleRevokeEvent 处理撤销事件
-func (h *Handler) handleRevokeEvent(ctx context.Context, conn *ws.Conn, env *protocol.Envelope, event *protocol.Event, isAdmin bool) {
+func (h *Handler) handleRevokeEvent(ctx context.Context, conn *ws.Conn, env *protocol.Envelope, event *protocol.Event, isAdmin bool, ttl int64) {
 	// 获取目标消息ID
 	targetMid, ok := protocol.GetTargetMid(event.Tags)
 	if !ok {
@@ -160,8 +255,28 @@ func (h *Handler) handleRevokeEvent(ctx context.Context, conn *ws.Conn, env *pro
 		return
 	}
 
+	// 仅自己删除：记录隐藏并同步到自己的其他设备，不广播给会话
+	if scope, _ := event.GetRevokeData(); scope == protocol.RevokeScopeSelf {
+		if err := h.relayClient.HideEvent(ctx, event.Cid, conn.UID(), targetMid); err != nil {
+			log.Error().Err(err).Msg("failed to hide event")
+			h.sendError(conn, env.Seq, errors.ErrCannotRevoke)
+			return
+		}
+		h.sendToUser(conn.UID(), event)
+		h.sendAck(conn, env.Seq, 0)
+		return
+	}
+
+	// 获取会话消息策略
+	policy, err := h.seakingClient.GetConversationPolicy(ctx, event.Cid)
+	if err != nil {
+		log.Error().Err(err).Msg("failed to get conversation policy")
+		h.sendError(conn, env.Seq, errors.ErrInternal)
+		return
+	}
+
 	// 验证撤销权限
-	validateResp, err := h.relayClient.ValidateRevoke(ctx, event.Cid, conn.UID(), targetMid, isAdmin)
+	validateResp, err := h.relayClient.ValidateRevoke(ctx, event.Cid, conn.UID(), targetMid, isAdmin, policy)
 	if err != nil {
 		log.Error().Err(err).Msg("failed to validate revoke")
 		h.sendError(conn, env.Seq, errors.ErrInternal)
@@ -174,11 +289,11 @@ func (h *Handler) handleRevokeEvent(ctx context.Context, conn *ws.Conn, env *pro
 	}
 
 	// 存储撤销事件
-	h.handlePersistentEvent(ctx, conn, env, event)
+	h.handlePersistentEvent(ctx, conn, env, event, ttl)
 }
 
 // handleEditEvent 处理编辑事件
-func (h *Handler) handleEditEvent(ctx context.Context, conn *ws.Conn, env *protocol.Envelope, event *protocol.Event) {
+func (h *Handler) handleEditEvent(ctx context.Context, conn *ws.Conn, env *protocol.Envelope, event *protocol.Event, ttl int64) {
 	// 获取目标消息ID
 	targetMid, ok := protocol.GetTargetMid(event.Tags)
 	if !ok {
@@ -186,8 +301,16 @@ func (h *Handler) handleEditEvent(ctx context.Context, conn *ws.Conn, env *proto
 		return
 	}
 
+	// 获取会话消息策略
+	policy, err := h.seakingClient.GetConversationPolicy(ctx, event.Cid)
+	if err != nil {
+		log.Error().Err(err).Msg("failed to get conversation policy")
+		h.sendError(conn, env.Seq, errors.ErrInternal)
+		return
+	}
+
 	// 验证编辑权限
-	validateResp, err := h.relayClient.ValidateEdit(ctx, event.Cid, conn.UID(), targetMid)
+	validateResp, err := h.relayClient.ValidateEdit(ctx, event.Cid, conn.UID(), targetMid, policy)
 	if err != nil {
 		log.Error().Err(err).Msg("failed to validate edit")
 		h.sendError(conn, env.Seq, errors.ErrInternal)
@@ -200,11 +323,69 @@ func (h *Handler) handleEditEvent(ctx context.Context, conn *ws.Conn, env *proto
 	}
 
 	// 存储编辑事件
-	h.handlePersistentEvent(ctx, conn, env, event)
+	h.handlePersistentEvent(ctx, conn, env, event, ttl)
+}
+
+// handlePinEvent 处理置顶/取消置顶事件
+func (h *Handler) handlePinEvent(ctx context.Context, conn *ws.Conn, env *protocol.Envelope, event *protocol.Event, ttl int64) {
+	// 获取目标消息ID
+	targetMid, ok := protocol.GetTargetMid(event.Tags)
+	if !ok {
+		h.sendError(conn, env.Seq, errors.ErrInvalidParam)
+		return
+	}
+
+	if event.GetPinAction() == protocol.PinActionUnpin {
+		err := h.seakingClient.UnpinMessage(ctx, event.Cid, conn.UID(), targetMid)
+		if err != nil {
+			log.Error().Err(err).Msg("failed to unpin message")
+			h.sendError(conn, env.Seq, errors.New(errors.ErrCodeCannotPin, err.Error()))
+			return
+		}
+	} else {
+		// 目标消息必须属于该会话且未被撤销
+		target, err := h.relayClient.GetEvent(ctx, targetMid)
+		if err != nil || target.Cid != event.Cid {
+			h.sendError(conn, env.Seq, errors.ErrMessageNotFound)
+			return
+		}
+		if target.Flags&protocol.FlagRevoked != 0 {
+			h.sendError(conn, env.Seq, errors.ErrCannotPin)
+			return
+		}
+
+		if _, err := h.seakingClient.PinMessage(ctx, event.Cid, conn.UID(), targetMid); err != nil {
+			log.Error().Err(err).Msg("failed to pin message")
+			h.sendError(conn, env.Seq, errors.New(errors.ErrCodeCannotPin, err.Error()))
+			return
+		}
+	}
+
+	// 存储并广播置顶事件，其他设备据此实时更新
+	h.handlePersistentEvent(ctx, conn, env, event, ttl)
+}
+
+// handleMessageTTLEvent 处理消息过期时间变更事件
+func (h *Handler) handleMessageTTLEvent(ctx context.Context, conn *ws.Conn, env *protocol.Envelope, event *protocol.Event) {
+	ttl, ok := event.GetMessageTTL()
+	if !ok {
+		h.sendError(conn, env.Seq, errors.ErrInvalidParam)
+		return
+	}
+
+	ttl, err := h.seakingClient.SetMessageTTL(ctx, event.Cid, conn.UID(), ttl)
+	if err != nil {
+		log.Error().Err(err).Msg("failed to set message ttl")
+		h.sendError(conn, env.Seq, errors.New(errors.ErrCodeForbidden, err.Error()))
+		return
+	}
+
+	// 存储并广播变更事件，客户端据此展示提示；该事件本身按新设置过期
+	h.handlePersistentEvent(ctx, conn, env, event, ttl)
 }
 
 // handleReadReceiptEvent 处理已读回执
-func (h *Handler) handleReadReceiptEvent(ctx context.Context, conn *ws.Conn, env *protocol.Envelope, event *protocol.Event) {
+func (h *Handler) handleReadReceiptEvent(ctx context.Context, conn *ws.Conn, env *protocol.Envelope, event *protocol.Event, notify bool) {
 	// 获取已读消息ID
 	lastReadMid, ok := event.Data[0].(int64)
 	if !ok {
@@ -218,16 +399,44 @@ func (h *Handler) handleReadReceiptEvent(ctx context.Context, conn *ws.Conn, env
 	}
 
 	// 更新已读回执
-	err := h.relayClient.UpdateReadReceipt(ctx, event.Cid, conn.UID(), lastReadMid)
+	senders, err := h.relayClient.UpdateReadReceipt(ctx, event.Cid, conn.UID(), lastReadMid)
 	if err != nil {
 		log.Error().Err(err).Msg("failed to update read receipt")
 		h.sendError(conn, env.Seq, errors.ErrInternal)
 		return
 	}
 
+	h.sendAck(conn, env.Seq, 0)
+	if !notify {
+		return
+	}
+
 	// 广播已读回执给其他用户
 	h.broadcastEvent(event)
+
+	// 通知被读消息的发送者
+	h.readStatus.notify(protocol.CmdReadStatus, event.Cid, conn.UID(), lastReadMid, senders)
+}
+
+// handleDeliveredEvent 处理送达回执：记录送达水位并通知被送达消息的发送者
+func (h *Handler) handleDeliveredEvent(ctx context.Context, conn *ws.Conn, env *protocol.Envelope, event *protocol.Event, notify bool) {
+	lastDeliveredMid, ok := protocol.ToInt64(event.Data[0])
+	if !ok || lastDeliveredMid <= 0 {
+		h.sendError(conn, env.Seq, errors.ErrInvalidParam)
+		return
+	}
+
+	senders, err := h.relayClient.UpdateDeliveryReceipt(ctx, event.Cid, conn.UID(), lastDeliveredMid)
+	if err != nil {
+		log.Error().Err(err).Msg("failed to update delivery receipt")
+		h.sendError(conn, env.Seq, errors.ErrInternal)
+		return
+	}
+
 	h.sendAck(conn, env.Seq, 0)
+	if notify {
+		h.readStatus.notify(protocol.CmdDeliveryStatus, event.Cid, conn.UID(), lastDeliveredMid, senders)
+	}
 }
 
 // handleSubscribe 处理订阅
@@ -306,6 +515,7 @@ func (h *Handler) handleSync(conn *ws.Conn, env *protocol.Envelope) {
 		// 增量同步
 		events, err = h.relayClient.QueryEvents(ctx, &client.QueryEventsRequest{
 			Cid:     syncBody.Cid,
+			Uid:     conn.UID(),
 			LastMid: syncBody.LastMid,
 			Before:  syncBody.Before,
 			After:   syncBody.After,
@@ -313,7 +523,7 @@ func (h *Handler) handleSync(conn *ws.Conn, env *protocol.Envelope) {
 		})
 	} else {
 		// 全量同步（获取最新消息）
-		events, err = h.relayClient.SyncEvents(ctx, syncBody.Cid, limit)
+		events, err = h.relayClient.SyncEvents(ctx, syncBody.Cid, conn.UID(), limit)
 	}
 
 	if err != nil {
@@ -324,8 +534,9 @@ func (h *Handler) handleSync(conn *ws.Conn, env *protocol.Envelope) {
 
 	// 发送同步结果
 	syncResult := protocol.NewEnvelope(protocol.CmdEvent, env.Seq, map[string]interface{}{
-		"cid":    syncBody.Cid,
-		"events": events.Events,
+		"cid":     syncBody.Cid,
+		"events":  events.Events,
+		"threads": events.Threads,
 	})
 	conn.SendEnvelope(syncResult)
 }
@@ -341,6 +552,17 @@ func (h *Handler) broadcastEvent(event *protocol.Event) {
 	h.hub.Broadcast(event.Cid, data)
 }
 
+// sendToUser 推送事件给用户的所有连接
+func (h *Handler) sendToUser(uid string, event *protocol.Event) {
+	data, err := protocol.Encode(protocol.NewEnvelope(protocol.CmdEvent, 0, event))
+	if err != nil {
+		log.Error().Err(err).Msg("failed to encode event")
+		return
+	}
+
+	h.hub.SendToUser(uid, data)
+}
+
 // sendAck 发送确认
 func (h *Handler) sendAck(conn *ws.Conn, seq int64, mid int64) {
 	ack := protocol.NewEnvelope(protocol.CmdAck, seq, &protocol.AckBody{
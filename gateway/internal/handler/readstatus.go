@@ -0,0 +1,104 @@
+package handler
+
+import (
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/my-chat/common/pkg/log"
+	"github.com/my-chat/common/pkg/protocol"
+	"github.com/my-chat/gateway/internal/ws"
+)
+
+// readStatusDebounce 群聊中合并已读/送达进度推送的时间窗口
+const readStatusDebounce = 2 * time.Second
+
+// readStatusKey 推送目标（推送命令 + 接收者 + 会话）
+type readStatusKey struct {
+	cmd string
+	uid string
+	cid string
+}
+
+// readStatusNotifier 向消息发送者推送已读/送达进度，群聊中按接收者合并推送，避免大群频繁推送
+type readStatusNotifier struct {
+	hub     *ws.Hub
+	delay   time.Duration
+	mu      sync.Mutex
+	pending map[readStatusKey]map[string]int64 // 待推送的成员已读水位
+}
+
+// newReadStatusNotifier 创建已读/送达进度推送器
+func newReadStatusNotifier(hub *ws.Hub, delay time.Duration) *readStatusNotifier {
+	return &readStatusNotifier{
+		hub:     hub,
+		delay:   delay,
+		pending: make(map[readStatusKey]map[string]int64),
+	}
+}
+
+// notify 成员已读/送达水位前进后通知相关消息的发送者，cmd为 CmdReadStatus 或 CmdDeliveryStatus
+func (n *readStatusNotifier) notify(cmd, cid, reader string, lastReadMid int64, senders []string) {
+	// 单聊直接推送
+	if !strings.HasPrefix(cid, "g:") || n.delay <= 0 {
+		for _, uid := range senders {
+			n.send(readStatusKey{cmd: cmd, uid: uid, cid: cid}, []protocol.ReadPosition{{Uid: reader, LastReadMid: lastReadMid}})
+		}
+		return
+	}
+
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	for _, uid := range senders {
+		key := readStatusKey{cmd: cmd, uid: uid, cid: cid}
+		readers, ok := n.pending[key]
+		if !ok {
+			readers = make(map[string]int64)
+			n.pending[key] = readers
+			time.AfterFunc(n.delay, func() { n.flush(key) })
+		}
+		if lastReadMid > readers[reader] {
+			readers[reader] = lastReadMid
+		}
+	}
+}
+
+// flush 推送合并后的进度
+func (n *readStatusNotifier) flush(key readStatusKey) {
+	n.mu.Lock()
+	readers := n.pending[key]
+	delete(n.pending, key)
+	n.mu.Unlock()
+
+	if len(readers) == 0 {
+		return
+	}
+	n.send(key, readPositions(readers))
+}
+
+// send 推送进度给指定用户
+func (n *readStatusNotifier) send(key readStatusKey, readers []protocol.ReadPosition) {
+	data, err := protocol.Encode(protocol.NewEnvelope(key.cmd, 0, &protocol.ReadStatusBody{
+		Cid:     key.cid,
+		Readers: readers,
+	}))
+	if err != nil {
+		log.Error().Err(err).Str("cmd", key.cmd).Msg("failed to encode read status")
+		return
+	}
+	n.hub.SendToUser(key.uid, data)
+}
+
+// readPositions 将成员水位转换为按成员ID排序的列表
+func readPositions(readers map[string]int64) []protocol.ReadPosition {
+	positions := make([]protocol.ReadPosition, 0, len(readers))
+	for uid, mid := range readers {
+		positions = append(positions, protocol.ReadPosition{Uid: uid, LastReadMid: mid})
+	}
+	sort.Slice(positions, func(i, j int) bool {
+		return positions[i].Uid < positions[j].Uid
+	})
+	return positions
+}
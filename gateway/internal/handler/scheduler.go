@@ -0,0 +1,146 @@
+package handler
+
+import (
+	"context"
+	"time"
+
+	"github.com/my-chat/common/pkg/client"
+	"github.com/my-chat/common/pkg/log"
+	"github.com/my-chat/common/pkg/protocol"
+)
+
+// scheduleBatchSize 每轮最多领取的定时消息数
+const scheduleBatchSize = 100
+
+// RunScheduler 定时领取到期的定时消息并发送
+func (h *Handler) RunScheduler(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		h.dispatchDueScheduled()
+	}
+}
+
+// dispatchDueScheduled 发送一批到期的定时消息
+func (h *Handler) dispatchDueScheduled() {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	items, err := h.relayClient.ClaimDueScheduled(ctx, scheduleBatchSize)
+	if err != nil {
+		log.Error().Err(err).Msg("failed to claim scheduled messages")
+		return
+	}
+
+	for i := range items {
+		h.sendScheduled(ctx, &items[i])
+	}
+}
+
+// sendScheduled 发送单条定时消息，结果推送给作者的所有设备
+func (h *Handler) sendScheduled(ctx context.Context, item *client.ScheduledMessage) {
+	event := item.Event
+	if event == nil {
+		h.failScheduled(ctx, item, "invalid event")
+		return
+	}
+
+	// 成员关系可能已变化，重新检查权限
+	accessResp, err := h.seakingClient.CheckAccess(ctx, event.Sender, event.Cid)
+	if err != nil {
+		// 暂时性错误，保持领取状态等待超时后重试
+		log.Error().Err(err).Uint("id", item.ID).Msg("failed to check access for scheduled message")
+		return
+	}
+	if !accessResp.HasAccess {
+		h.failScheduled(ctx, item, "not in conversation")
+		return
+	}
+	if accessResp.Muted {
+		h.failScheduled(ctx, item, "you are muted")
+		return
+	}
+	if !accessResp.CanSend {
+		h.failScheduled(ctx, item, "only admins can send messages")
+		return
+	}
+	if accessResp.Blocked {
+		h.failScheduled(ctx, item, "user blocked")
+		return
+	}
+	slowMode := 0
+	if isSlowModeKind(event.Kind) {
+		slowMode = accessResp.SlowMode
+	}
+	if wait := h.reserveSlowMode(ctx, event.Cid, event.Sender, slowMode); wait > 0 {
+		// 慢速模式间隔未到，推迟到可以发言时再发送
+		h.deferScheduled(ctx, item, wait)
+		return
+	}
+
+	// 存储事件与标记已发送在Relay的同一事务中完成，重新领取后不会重复发送
+	resp, err := h.relayClient.SendScheduled(ctx, item.ID, accessResp.MessageTTL)
+	if err != nil {
+		// 暂时性错误（Relay 可能已提交），保持领取状态等待超时后重试，已存储时重试走 Duplicate 分支
+		log.Error().Err(err).Uint("id", item.ID).Msg("failed to store scheduled message")
+		h.releaseSlowMode(ctx, event.Cid, event.Sender, slowMode)
+		return
+	}
+
+	// 此前已存储（上次发送后worker异常），只补发状态通知
+	if !resp.Duplicate {
+		event.Mid = resp.Mid
+		event.Timestamp = resp.Timestamp
+
+		// 与即时发送一致：广播并通知线程订阅者
+		h.broadcastEvent(event)
+		if resp.RootMid > 0 {
+			h.notifyThreadSubscribers(ctx, event, resp.RootMid)
+		}
+	}
+
+	h.sendScheduleUpdate(event.Sender, &protocol.ScheduleUpdateBody{
+		ID:     item.ID,
+		Cid:    event.Cid,
+		Status: protocol.ScheduleStatusSent,
+		Mid:    resp.Mid,
+	})
+}
+
+// deferScheduled 将定时消息放回等待队列，wait 后再次发送
+func (h *Handler) deferScheduled(ctx context.Context, item *client.ScheduledMessage, wait time.Duration) {
+	scheduledAt := time.Now().Add(wait).Unix() + 1
+	if err := h.relayClient.DeferScheduled(ctx, item.ID, scheduledAt); err != nil {
+		// 保持领取状态，超时后重新领取
+		log.Error().Err(err).Uint("id", item.ID).Msg("failed to defer scheduled message")
+	}
+}
+
+// failScheduled 标记定时消息发送失败并通知作者
+func (h *Handler) failScheduled(ctx context.Context, item *client.ScheduledMessage, reason string) {
+	if err := h.relayClient.CompleteScheduled(ctx, item.ID, 0, reason); err != nil {
+		log.Error().Err(err).Uint("id", item.ID).Msg("failed to complete scheduled message")
+	}
+
+	if item.Event == nil {
+		return
+	}
+	h.sendScheduleUpdate(item.Event.Sender, &protocol.ScheduleUpdateBody{
+		ID:     item.ID,
+		Cid:    item.Event.Cid,
+		Status: protocol.ScheduleStatusFailed,
+		Reason: reason,
+	})
+}
+
+// sendScheduleUpdate 推送定时消息状态变更给用户的所有设备
+func (h *Handler) sendScheduleUpdate(uid string, body *protocol.ScheduleUpdateBody) {
+	data, err := protocol.Encode(protocol.NewEnvelope(protocol.CmdScheduleUpdate, 0, body))
+	if err != nil {
+		log.Error().Err(err).Msg("failed to encode schedule update")
+		return
+	}
+
+	h.hub.SendToUser(uid, data)
+}
@@ -0,0 +1,91 @@
+package handler
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/my-chat/common/pkg/errors"
+	"github.com/my-chat/common/pkg/log"
+	"github.com/my-chat/common/pkg/protocol"
+	"github.com/my-chat/gateway/internal/ws"
+	"github.com/redis/go-redis/v9"
+)
+
+// WithRedis 设置慢速模式使用的Redis（记录成员最后发言时间），未设置时不限制发言频率
+func (h *Handler) WithRedis(rdb *redis.Client) *Handler {
+	h.redis = rdb
+	return h
+}
+
+// isSlowModeKind 判断消息类型是否受慢速模式限制（仅内容类消息）
+func isSlowModeKind(kind int) bool {
+	switch kind {
+	case protocol.KindText, protocol.KindFile, protocol.KindForward:
+		return true
+	default:
+		return false
+	}
+}
+
+// slowModeKey 成员在会话中最后发言时间的Redis键，随慢速模式间隔过期
+func slowModeKey(cid, uid string) string {
+	return fmt.Sprintf("slow_mode:%s:%s", cid, uid)
+}
+
+// reserveSlowMode 原子地占用成员在慢速模式下的发言机会（SET NX），同一成员多个连接并发发送时只有一条能通过
+// 返回需要等待的时间，0 表示已占用可以发送；Redis 异常时不限制
+func (h *Handler) reserveSlowMode(ctx context.Context, cid, uid string, window int) time.Duration {
+	if window <= 0 || h.redis == nil {
+		return 0
+	}
+
+	key := slowModeKey(cid, uid)
+	limit := time.Duration(window) * time.Second
+	ok, err := h.redis.SetNX(ctx, key, time.Now().Unix(), limit).Result()
+	if err != nil {
+		log.Error().Err(err).Str("cid", cid).Msg("failed to reserve slow mode")
+		return 0
+	}
+	if ok {
+		return 0
+	}
+
+	ttl, err := h.redis.PTTL(ctx, key).Result()
+	if err != nil {
+		log.Error().Err(err).Str("cid", cid).Msg("failed to check slow mode")
+		return limit
+	}
+	// 管理员缩短间隔后，旧记录的剩余时间不超过新间隔
+	if ttl > limit {
+		return limit
+	}
+	if ttl <= 0 {
+		// 键恰好过期，稍后重试即可
+		return time.Second
+	}
+	return ttl
+}
+
+// releaseSlowMode 消息存储失败时释放占用的发言机会，发送失败的消息不占用慢速模式间隔
+func (h *Handler) releaseSlowMode(ctx context.Context, cid, uid string, window int) {
+	if window <= 0 || h.redis == nil {
+		return
+	}
+
+	if err := h.redis.Del(ctx, slowModeKey(cid, uid)).Err(); err != nil {
+		log.Error().Err(err).Str("cid", cid).Msg("failed to release slow mode")
+	}
+}
+
+// sendRetryError 发送带重试等待时间的错误
+func (h *Handler) sendRetryError(conn *ws.Conn, seq int64, err *errors.Error, wait time.Duration) {
+	errEnv := protocol.NewEnvelope(protocol.CmdError, seq, &protocol.ErrorBody{
+		Code:       err.Code,
+		Message:    err.Message,
+		Seq:        seq,
+		RetryAfter: int64(math.Ceil(wait.Seconds())),
+	})
+	conn.SendEnvelope(errEnv)
+}
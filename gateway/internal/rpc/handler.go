@@ -1,21 +1,33 @@
 package rpc
 
 import (
+	"context"
 	"encoding/json"
+	"fmt"
 	"io"
 	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/my-chat/common/pkg/auth"
 	"github.com/my-chat/common/pkg/client"
 	"github.com/my-chat/common/pkg/log"
+	"github.com/my-chat/common/pkg/protocol"
+	"github.com/my-chat/common/pkg/storage"
+	"github.com/my-chat/gateway/internal/handler"
+	"github.com/my-chat/gateway/internal/ws"
 )
 
 // Handler Gateway RPC处理器
 type Handler struct {
+	hub           *ws.Hub
 	jwtManager    *auth.JWTManager
 	seakingClient *client.SeaKingClient
 	relayClient   *client.RelayClient
+	r2            *storage.R2Storage
 	methods       map[string]MethodHandler
 }
 
@@ -46,8 +58,9 @@ type RPCError struct {
 }
 
 // NewHandler 创建Handler
-func NewHandler(jwtManager *auth.JWTManager, seakingAddr, relayAddr string) *Handler {
+func NewHandler(hub *ws.Hub, jwtManager *auth.JWTManager, seakingAddr, relayAddr string) *Handler {
 	h := &Handler{
+		hub:           hub,
 		jwtManager:    jwtManager,
 		seakingClient: client.NewSeaKingClient(seakingAddr),
 		relayClient:   client.NewRelayClient(relayAddr),
@@ -60,14 +73,34 @@ func NewHandler(jwtManager *auth.JWTManager, seakingAddr, relayAddr string) *Han
 	return h
 }
 
+// WithStorage 设置对象存储，用于生成数据导出的下载链接
+func (h *Handler) WithStorage(r2 *storage.R2Storage) *Handler {
+	h.r2 = r2
+	return h
+}
+
 // registerMethods 注册所有RPC方法
 func (h *Handler) registerMethods() {
 	// 认证相关（无需token）
 	h.methods["register"] = h.register
 	h.methods["login"] = h.login
+	h.methods["verifyLogin"] = h.verifyLogin
 
 	// 用户相关（需要token）
 	h.methods["getUserInfo"] = h.withAuth(h.getUserInfo)
+	h.methods["searchUsers"] = h.withAuth(h.searchUsers)
+	h.methods["setDiscoverable"] = h.withAuth(h.setDiscoverable)
+	h.methods["updateProfile"] = h.withAuth(h.updateProfile)
+	h.methods["changePassword"] = h.withAuth(h.changePassword)
+	h.methods["getSecurityLogs"] = h.withAuth(h.getSecurityLogs)
+	h.methods["getTwoFactorStatus"] = h.withAuth(h.getTwoFactorStatus)
+	h.methods["enrollTOTP"] = h.withAuth(h.enrollTOTP)
+	h.methods["confirmTOTP"] = h.withAuth(h.confirmTOTP)
+	h.methods["disableTOTP"] = h.withAuth(h.disableTOTP)
+	h.methods["regenerateRecoveryCodes"] = h.withAuth(h.regenerateRecoveryCodes)
+	h.methods["deleteAccount"] = h.withAuth(h.deleteAccount)
+	h.methods["requestDataExport"] = h.withAuth(h.requestDataExport)
+	h.methods["getDataExports"] = h.withAuth(h.getDataExports)
 
 	// 好友相关（需要token）
 	h.methods["getFriends"] = h.withAuth(h.getFriends)
@@ -76,17 +109,75 @@ func (h *Handler) registerMethods() {
 	h.methods["acceptFriendRequest"] = h.withAuth(h.acceptFriendRequest)
 	h.methods["rejectFriendRequest"] = h.withAuth(h.rejectFriendRequest)
 	h.methods["deleteFriend"] = h.withAuth(h.deleteFriend)
+	h.methods["blockUser"] = h.withAuth(h.blockUser)
+	h.methods["unblockUser"] = h.withAuth(h.unblockUser)
+	h.methods["getBlockedUsers"] = h.withAuth(h.getBlockedUsers)
 
 	// 会话相关（需要token）
 	h.methods["getConversations"] = h.withAuth(h.getConversations)
+	h.methods["getInbox"] = h.withAuth(h.getInbox)
 	h.methods["createConversation"] = h.withAuth(h.createConversation)
 	h.methods["getConversationMembers"] = h.withAuth(h.getConversationMembers)
+	h.methods["getConversationPolicy"] = h.withAuth(h.getConversationPolicy)
+	h.methods["setConversationPolicy"] = h.withAuth(h.setConversationPolicy)
+	h.methods["getPinnedMessages"] = h.withAuth(h.getPinnedMessages)
+	h.methods["getReadStatus"] = h.withAuth(h.getReadStatus)
+
+	// 消息线程
+	h.methods["getThread"] = h.withAuth(h.getThread)
+	h.methods["subscribeThread"] = h.withAuth(h.subscribeThread)
+	h.methods["unsubscribeThread"] = h.withAuth(h.unsubscribeThread)
+
+	// 定时消息
+	h.methods["scheduleMessage"] = h.withAuth(h.scheduleMessage)
+	h.methods["getScheduledMessages"] = h.withAuth(h.getScheduledMessages)
+	h.methods["updateScheduledMessage"] = h.withAuth(h.updateScheduledMessage)
+	h.methods["cancelScheduledMessage"] = h.withAuth(h.cancelScheduledMessage)
+
+	// 草稿同步
+	h.methods["saveDraft"] = h.withAuth(h.saveDraft)
+	h.methods["getDrafts"] = h.withAuth(h.getDrafts)
+	h.methods["clearDraft"] = h.withAuth(h.clearDraft)
 
 	// 群组相关（需要token）
 	h.methods["getGroups"] = h.withAuth(h.getGroups)
 	h.methods["createGroup"] = h.withAuth(h.createGroup)
 	h.methods["getGroupInfo"] = h.withAuth(h.getGroupInfo)
 	h.methods["getGroupMembers"] = h.withAuth(h.getGroupMembers)
+	h.methods["updateGroup"] = h.withAuth(h.updateGroup)
+	h.methods["dismissGroup"] = h.withAuth(h.dismissGroup)
+	h.methods["addGroupMember"] = h.withAuth(h.addGroupMember)
+	h.methods["removeGroupMember"] = h.withAuth(h.removeGroupMember)
+	h.methods["leaveGroup"] = h.withAuth(h.leaveGroup)
+	h.methods["setGroupAdmin"] = h.withAuth(h.setGroupAdmin)
+	h.methods["transferGroupOwner"] = h.withAuth(h.transferGroupOwner)
+	h.methods["muteGroupMember"] = h.withAuth(h.muteGroupMember)
+	h.methods["unmuteGroupMember"] = h.withAuth(h.unmuteGroupMember)
+	h.methods["setGroupMuteAll"] = h.withAuth(h.setGroupMuteAll)
+	h.methods["setGroupJoinRequests"] = h.withAuth(h.setGroupJoinRequests)
+	h.methods["setGroupSlowMode"] = h.withAuth(h.setGroupSlowMode)
+	h.methods["createGroupInvite"] = h.withAuth(h.createGroupInvite)
+	h.methods["getGroupInvites"] = h.withAuth(h.getGroupInvites)
+	h.methods["revokeGroupInvite"] = h.withAuth(h.revokeGroupInvite)
+	h.methods["previewGroupInvite"] = h.withAuth(h.previewGroupInvite)
+	h.methods["joinGroupByInvite"] = h.withAuth(h.joinGroupByInvite)
+	h.methods["requestGroupJoin"] = h.withAuth(h.requestGroupJoin)
+	h.methods["getGroupJoinRequests"] = h.withAuth(h.getGroupJoinRequests)
+	h.methods["approveGroupJoinRequest"] = h.withAuth(h.approveGroupJoinRequest)
+	h.methods["rejectGroupJoinRequest"] = h.withAuth(h.rejectGroupJoinRequest)
+	h.methods["setGroupAnnouncement"] = h.withAuth(h.setGroupAnnouncement)
+	h.methods["clearGroupAnnouncement"] = h.withAuth(h.clearGroupAnnouncement)
+	h.methods["getGroupAnnouncement"] = h.withAuth(h.getGroupAnnouncement)
+	h.methods["ackGroupAnnouncement"] = h.withAuth(h.ackGroupAnnouncement)
+	h.methods["getGroupAnnouncementHistory"] = h.withAuth(h.getGroupAnnouncementHistory)
+	h.methods["setGroupAnnouncementEncryption"] = h.withAuth(h.setGroupAnnouncementEncryption)
+
+	// 加密相关（需要token）
+	h.methods["getUserPublicKey"] = h.withAuth(h.getUserPublicKey)
+	h.methods["getChatKey"] = h.withAuth(h.getChatKey)
+	h.methods["createChatKey"] = h.withAuth(h.createChatKey)
+	h.methods["getGroupKey"] = h.withAuth(h.getGroupKey)
+	h.methods["createGroupKey"] = h.withAuth(h.createGroupKey)
 }
 
 // Handle 处理RPC请求
@@ -160,10 +251,11 @@ func (h *Handler) withAuth(fn func(ctx *gin.Context, uid string, id any, params
 			token = token[7:]
 		}
 
-		claims, err := h.jwtManager.ParseToken(token)
+		claims, err := h.jwtManager.ValidateToken(ctx.Request.Context(), token)
 		if err != nil {
 			return &RPCError{Code: -32002, Message: "Invalid token"}
 		}
+		ctx.Set("claims", claims)
 
 		return fn(ctx, claims.Uid, id, params)
 	}
@@ -200,6 +292,7 @@ func (h *Handler) login(ctx *gin.Context, id any, params json.RawMessage) any {
 	resp, err := h.seakingClient.Login(ctx.Request.Context(), &client.LoginRequest{
 		Username: req.Username,
 		Password: req.Password,
+		IP:       ctx.ClientIP(),
 	}, req.DeviceId, req.Platform)
 	if err != nil {
 		log.Error().Err(err).Msg("login failed")
@@ -209,6 +302,24 @@ func (h *Handler) login(ctx *gin.Context, id any, params json.RawMessage) any {
 	return resp
 }
 
+func (h *Handler) verifyLogin(ctx *gin.Context, id any, params json.RawMessage) any {
+	var req struct {
+		ChallengeToken string `json:"challenge_token"`
+		Code           string `json:"code"`
+	}
+	if err := json.Unmarshal(params, &req); err != nil || req.ChallengeToken == "" || req.Code == "" {
+		return &RPCError{Code: -32602, Message: "Invalid params"}
+	}
+
+	resp, err := h.seakingClient.VerifyLogin(ctx.Request.Context(), req.ChallengeToken, req.Code, ctx.ClientIP())
+	if err != nil {
+		log.Error().Err(err).Msg("verifyLogin failed")
+		return &RPCError{Code: -32000, Message: err.Error()}
+	}
+
+	return resp
+}
+
 // ============== 用户相关 ==============
 
 func (h *Handler) getUserInfo(ctx *gin.Context, uid string, id any, params json.RawMessage) any {
@@ -233,6 +344,274 @@ func (h *Handler) getUserInfo(ctx *gin.Context, uid string, id any, params json.
 	return resp
 }
 
+func (h *Handler) updateProfile(ctx *gin.Context, uid string, id any, params json.RawMessage) any {
+	var req struct {
+		Nickname string `json:"nickname"`
+		Avatar   string `json:"avatar"`
+	}
+	if err := json.Unmarshal(params, &req); err != nil {
+		return &RPCError{Code: -32602, Message: "Invalid params"}
+	}
+
+	resp, err := h.seakingClient.UpdateProfile(ctx.Request.Context(), uid, req.Nickname, req.Avatar)
+	if err != nil {
+		log.Error().Err(err).Msg("updateProfile failed")
+		return &RPCError{Code: -32000, Message: err.Error()}
+	}
+
+	return resp
+}
+
+func (h *Handler) changePassword(ctx *gin.Context, uid string, id any, params json.RawMessage) any {
+	var req struct {
+		OldPassword         string `json:"old_password"`
+		NewPassword         string `json:"new_password"`
+		EncryptedPrivateKey string `json:"encrypted_private_key"`
+		KeySalt             string `json:"key_salt"`
+	}
+	if err := json.Unmarshal(params, &req); err != nil || req.OldPassword == "" || req.NewPassword == "" {
+		return &RPCError{Code: -32602, Message: "Invalid params"}
+	}
+
+	claims := ctx.MustGet("claims").(*auth.Claims)
+	token, err := h.seakingClient.ChangePassword(ctx.Request.Context(), &client.ChangePasswordRequest{
+		Uid:                 uid,
+		DeviceId:            claims.DeviceId,
+		Platform:            claims.Platform,
+		OldPassword:         req.OldPassword,
+		NewPassword:         req.NewPassword,
+		EncryptedPrivateKey: req.EncryptedPrivateKey,
+		KeySalt:             req.KeySalt,
+	})
+	if err != nil {
+		log.Error().Err(err).Msg("changePassword failed")
+		return &RPCError{Code: -32000, Message: err.Error()}
+	}
+
+	// 旧Token已失效，断开其他设备的长连接，迫使其重新登录
+	for _, conn := range h.hub.GetUserConns(uid) {
+		if conn.DeviceId() != claims.DeviceId {
+			conn.Close()
+		}
+	}
+
+	return map[string]any{"token": token}
+}
+
+func (h *Handler) deleteAccount(ctx *gin.Context, uid string, id any, params json.RawMessage) any {
+	var req struct {
+		Password string `json:"password"`
+	}
+	if err := json.Unmarshal(params, &req); err != nil || req.Password == "" {
+		return &RPCError{Code: -32602, Message: "Invalid params"}
+	}
+
+	claims := ctx.MustGet("claims").(*auth.Claims)
+	purgeAt, err := h.seakingClient.DeleteAccount(ctx.Request.Context(), uid, req.Password, ctx.ClientIP(), claims.DeviceId)
+	if err != nil {
+		log.Error().Err(err).Msg("deleteAccount failed")
+		return &RPCError{Code: -32000, Message: err.Error()}
+	}
+
+	// 所有Token已失效，断开该用户的全部长连接
+	for _, conn := range h.hub.GetUserConns(uid) {
+		conn.Close()
+	}
+
+	// 账号已禁用，冷静期内不再发送其定时消息
+	if _, err := h.relayClient.CancelUserScheduled(ctx.Request.Context(), uid); err != nil {
+		log.Error().Err(err).Str("uid", uid).Msg("failed to cancel scheduled messages")
+	}
+
+	return map[string]any{"purge_at": purgeAt}
+}
+
+func (h *Handler) requestDataExport(ctx *gin.Context, uid string, id any, params json.RawMessage) any {
+	if h.r2 == nil {
+		return &RPCError{Code: -32000, Message: "storage service unavailable"}
+	}
+
+	claims := ctx.MustGet("claims").(*auth.Claims)
+	export, err := h.seakingClient.RequestDataExport(ctx.Request.Context(), uid, ctx.ClientIP(), claims.DeviceId)
+	if err != nil {
+		log.Error().Err(err).Msg("requestDataExport failed")
+		return &RPCError{Code: -32000, Message: err.Error()}
+	}
+
+	return map[string]any{
+		"id":         export.ID,
+		"status":     export.Status,
+		"created_at": export.CreatedAt,
+	}
+}
+
+func (h *Handler) getDataExports(ctx *gin.Context, uid string, id any, params json.RawMessage) any {
+	exports, err := h.seakingClient.GetDataExports(ctx.Request.Context(), uid)
+	if err != nil {
+		log.Error().Err(err).Msg("getDataExports failed")
+		return &RPCError{Code: -32000, Message: err.Error()}
+	}
+
+	result := make([]map[string]any, 0, len(exports))
+	for i := range exports {
+		e := &exports[i]
+		item := map[string]any{
+			"id":         e.ID,
+			"status":     e.Status,
+			"size":       e.Size,
+			"error":      e.Error,
+			"expires_at": e.ExpiresAt,
+			"created_at": e.CreatedAt,
+		}
+		// 每次查询生成新的限时下载链接，不返回存储Key
+		if h.r2 != nil {
+			url, urlExpiresAt, err := handler.ExportDownloadLink(ctx.Request.Context(), h.r2, e)
+			if err != nil {
+				log.Error().Err(err).Uint("id", e.ID).Msg("failed to sign data export link")
+			} else if url != "" {
+				item["url"] = url
+				item["url_expires_at"] = urlExpiresAt
+			}
+		}
+		result = append(result, item)
+	}
+
+	return map[string]any{"exports": result}
+}
+
+func (h *Handler) searchUsers(ctx *gin.Context, uid string, id any, params json.RawMessage) any {
+	var req struct {
+		Query  string `json:"query"`
+		Cursor string `json:"cursor"`
+		Limit  int    `json:"limit"`
+	}
+	if err := json.Unmarshal(params, &req); err != nil || strings.TrimSpace(req.Query) == "" {
+		return &RPCError{Code: -32602, Message: "Invalid params"}
+	}
+
+	resp, err := h.seakingClient.SearchUsers(ctx.Request.Context(), &client.SearchUsersRequest{
+		Uid:    uid,
+		Query:  req.Query,
+		Cursor: req.Cursor,
+		Limit:  req.Limit,
+	})
+	if err != nil {
+		log.Error().Err(err).Msg("searchUsers failed")
+		return &RPCError{Code: -32000, Message: err.Error()}
+	}
+
+	return resp
+}
+
+func (h *Handler) getSecurityLogs(ctx *gin.Context, uid string, id any, params json.RawMessage) any {
+	var req struct {
+		Cursor uint `json:"cursor"`
+		Limit  int  `json:"limit"`
+	}
+	if len(params) > 0 {
+		if err := json.Unmarshal(params, &req); err != nil {
+			return &RPCError{Code: -32602, Message: "Invalid params"}
+		}
+	}
+
+	resp, err := h.seakingClient.GetSecurityLogs(ctx.Request.Context(), uid, req.Cursor, req.Limit)
+	if err != nil {
+		log.Error().Err(err).Msg("getSecurityLogs failed")
+		return &RPCError{Code: -32000, Message: err.Error()}
+	}
+
+	return resp
+}
+
+func (h *Handler) getTwoFactorStatus(ctx *gin.Context, uid string, id any, params json.RawMessage) any {
+	resp, err := h.seakingClient.GetTwoFactorStatus(ctx.Request.Context(), uid)
+	if err != nil {
+		log.Error().Err(err).Msg("getTwoFactorStatus failed")
+		return &RPCError{Code: -32000, Message: err.Error()}
+	}
+
+	return resp
+}
+
+func (h *Handler) enrollTOTP(ctx *gin.Context, uid string, id any, params json.RawMessage) any {
+	resp, err := h.seakingClient.EnrollTOTP(ctx.Request.Context(), uid)
+	if err != nil {
+		log.Error().Err(err).Msg("enrollTOTP failed")
+		return &RPCError{Code: -32000, Message: err.Error()}
+	}
+
+	return resp
+}
+
+func (h *Handler) confirmTOTP(ctx *gin.Context, uid string, id any, params json.RawMessage) any {
+	var req struct {
+		Code string `json:"code"`
+	}
+	if err := json.Unmarshal(params, &req); err != nil || req.Code == "" {
+		return &RPCError{Code: -32602, Message: "Invalid params"}
+	}
+
+	codes, err := h.seakingClient.ConfirmTOTP(ctx.Request.Context(), uid, req.Code)
+	if err != nil {
+		log.Error().Err(err).Msg("confirmTOTP failed")
+		return &RPCError{Code: -32000, Message: err.Error()}
+	}
+
+	return map[string]any{"recovery_codes": codes}
+}
+
+func (h *Handler) disableTOTP(ctx *gin.Context, uid string, id any, params json.RawMessage) any {
+	var req struct {
+		Password string `json:"password"`
+		Code     string `json:"code"`
+	}
+	if err := json.Unmarshal(params, &req); err != nil || req.Password == "" || req.Code == "" {
+		return &RPCError{Code: -32602, Message: "Invalid params"}
+	}
+
+	err := h.seakingClient.DisableTOTP(ctx.Request.Context(), uid, req.Password, req.Code)
+	if err != nil {
+		log.Error().Err(err).Msg("disableTOTP failed")
+		return &RPCError{Code: -32000, Message: err.Error()}
+	}
+
+	return map[string]any{"success": true}
+}
+
+func (h *Handler) regenerateRecoveryCodes(ctx *gin.Context, uid string, id any, params json.RawMessage) any {
+	var req struct {
+		Code string `json:"code"`
+	}
+	if err := json.Unmarshal(params, &req); err != nil || req.Code == "" {
+		return &RPCError{Code: -32602, Message: "Invalid params"}
+	}
+
+	codes, err := h.seakingClient.RegenerateRecoveryCodes(ctx.Request.Context(), uid, req.Code)
+	if err != nil {
+		log.Error().Err(err).Msg("regenerateRecoveryCodes failed")
+		return &RPCError{Code: -32000, Message: err.Error()}
+	}
+
+	return map[string]any{"recovery_codes": codes}
+}
+
+func (h *Handler) setDiscoverable(ctx *gin.Context, uid string, id any, params json.RawMessage) any {
+	var req struct {
+		Discoverable *int `json:"discoverable"`
+	}
+	if err := json.Unmarshal(params, &req); err != nil || req.Discoverable == nil {
+		return &RPCError{Code: -32602, Message: "Invalid params"}
+	}
+
+	err := h.seakingClient.SetDiscoverable(ctx.Request.Context(), uid, *req.Discoverable)
+	if err != nil {
+		log.Error().Err(err).Msg("setDiscoverable failed")
+		return &RPCError{Code: -32000, Message: err.Error()}
+	}
+
+	return map[string]any{"success": true}
+}
+
 // ============== 好友相关 ==============
 
 func (h *Handler) getFriends(ctx *gin.Context, uid string, id any, params json.RawMessage) any {
@@ -324,6 +703,50 @@ func (h *Handler) deleteFriend(ctx *gin.Context, uid string, id any, params json
 	return map[string]any{"success": true}
 }
 
+func (h *Handler) blockUser(ctx *gin.Context, uid string, id any, params json.RawMessage) any {
+	var req struct {
+		Uid string `json:"uid"`
+	}
+	if err := json.Unmarshal(params, &req); err != nil || req.Uid == "" {
+		return &RPCError{Code: -32602, Message: "Invalid params"}
+	}
+
+	err := h.seakingClient.BlockUser(ctx.Request.Context(), uid, req.Uid)
+	if err != nil {
+		log.Error().Err(err).Msg("blockUser failed")
+		return &RPCError{Code: -32000, Message: err.Error()}
+	}
+
+	return map[string]any{"success": true}
+}
+
+func (h *Handler) unblockUser(ctx *gin.Context, uid string, id any, params json.RawMessage) any {
+	var req struct {
+		Uid string `json:"uid"`
+	}
+	if err := json.Unmarshal(params, &req); err != nil || req.Uid == "" {
+		return &RPCError{Code: -32602, Message: "Invalid params"}
+	}
+
+	err := h.seakingClient.UnblockUser(ctx.Request.Context(), uid, req.Uid)
+	if err != nil {
+		log.Error().Err(err).Msg("unblockUser failed")
+		return &RPCError{Code: -32000, Message: err.Error()}
+	}
+
+	return map[string]any{"success": true}
+}
+
+func (h *Handler) getBlockedUsers(ctx *gin.Context, uid string, id any, params json.RawMessage) any {
+	users, err := h.seakingClient.GetBlockedUsers(ctx.Request.Context(), uid)
+	if err != nil {
+		log.Error().Err(err).Msg("getBlockedUsers failed")
+		return &RPCError{Code: -32000, Message: err.Error()}
+	}
+
+	return map[string]any{"users": users}
+}
+
 // ============== 会话相关 ==============
 
 func (h *Handler) getConversations(ctx *gin.Context, uid string, id any, params json.RawMessage) any {
@@ -336,6 +759,150 @@ func (h *Handler) getConversations(ctx *gin.Context, uid string, id any, params
 	return map[string]any{"conversations": resp.Conversations}
 }
 
+// InboxItem 会话列表项（会话信息 + 最后消息 + 未读数）
+type InboxItem struct {
+	client.ConversationInfo
+	LastEvent    *client.EventData `json:"last_event,omitempty"`
+	LastReadMid  int64             `json:"last_read_mid"`
+	UnreadCount  int64             `json:"unread_count"`
+	LastActivity int64             `json:"last_activity"` // 最后消息时间，无消息时为0
+}
+
+const (
+	defaultInboxLimit = 20
+	maxInboxLimit     = 100
+)
+
+func (h *Handler) getInbox(ctx *gin.Context, uid string, id any, params json.RawMessage) any {
+	var req struct {
+		Cursor string `json:"cursor"`
+		Limit  int    `json:"limit"`
+	}
+	if len(params) > 0 {
+		if err := json.Unmarshal(params, &req); err != nil {
+			return &RPCError{Code: -32602, Message: "Invalid params"}
+		}
+	}
+	if req.Limit <= 0 {
+		req.Limit = defaultInboxLimit
+	}
+	if req.Limit > maxInboxLimit {
+		req.Limit = maxInboxLimit
+	}
+
+	convs, err := h.seakingClient.GetUserConversations(ctx.Request.Context(), uid)
+	if err != nil {
+		log.Error().Err(err).Msg("getInbox failed")
+		return &RPCError{Code: -32000, Message: err.Error()}
+	}
+
+	cids := make([]string, len(convs.Conversations))
+	for i, c := range convs.Conversations {
+		cids[i] = c.Cid
+	}
+
+	summaries, err := h.relayClient.GetConversationSummaries(ctx.Request.Context(), uid, cids)
+	if err != nil {
+		log.Error().Err(err).Msg("getInbox failed")
+		return &RPCError{Code: -32000, Message: err.Error()}
+	}
+
+	bySummary := make(map[string]client.ConversationSummary, len(summaries))
+	for _, summary := range summaries {
+		bySummary[summary.Cid] = summary
+	}
+
+	items := make([]InboxItem, 0, len(convs.Conversations))
+	for _, c := range convs.Conversations {
+		item := InboxItem{ConversationInfo: c}
+		if summary, ok := bySummary[c.Cid]; ok {
+			item.LastEvent = summary.LastEvent
+			item.LastReadMid = summary.LastReadMid
+			item.UnreadCount = summary.UnreadCount
+			if summary.LastEvent != nil {
+				item.LastActivity = summary.LastEvent.Timestamp
+			}
+		}
+		items = append(items, item)
+	}
+
+	page, nextCursor, err := paginateInbox(items, req.Cursor, req.Limit)
+	if err != nil {
+		return &RPCError{Code: -32602, Message: "Invalid params"}
+	}
+
+	return map[string]any{
+		"conversations": page,
+		"next_cursor":   nextCursor,
+	}
+}
+
+// inboxKey 会话列表排序键：置顶优先，其次按最后活跃时间倒序，最后按会话ID
+type inboxKey struct {
+	pinned       bool
+	lastActivity int64
+	cid          string
+}
+
+func (k inboxKey) before(o inboxKey) bool {
+	if k.pinned != o.pinned {
+		return k.pinned
+	}
+	if k.lastActivity != o.lastActivity {
+		return k.lastActivity > o.lastActivity
+	}
+	return k.cid < o.cid
+}
+
+func (k inboxKey) String() string {
+	pinned := 0
+	if k.pinned {
+		pinned = 1
+	}
+	return fmt.Sprintf("%d:%d:%s", pinned, k.lastActivity, k.cid)
+}
+
+// parseInboxCursor 解析分页游标，格式为 pinned:last_activity:cid
+func parseInboxCursor(cursor string) (inboxKey, error) {
+	parts := strings.SplitN(cursor, ":", 3)
+	if len(parts) != 3 {
+		return inboxKey{}, fmt.Errorf("invalid cursor")
+	}
+	lastActivity, err := strconv.ParseInt(parts[1], 10, 64)
+	if err != nil {
+		return inboxKey{}, fmt.Errorf("invalid cursor")
+	}
+	return inboxKey{pinned: parts[0] == "1", lastActivity: lastActivity, cid: parts[2]}, nil
+}
+
+func inboxKeyOf(item *InboxItem) inboxKey {
+	return inboxKey{pinned: item.Pinned, lastActivity: item.LastActivity, cid: item.Cid}
+}
+
+// paginateInbox 排序后返回游标之后的一页，以及下一页游标（没有更多时为空）
+func paginateInbox(items []InboxItem, cursor string, limit int) ([]InboxItem, string, error) {
+	sort.Slice(items, func(i, j int) bool {
+		return inboxKeyOf(&items[i]).before(inboxKeyOf(&items[j]))
+	})
+
+	start := 0
+	if cursor != "" {
+		after, err := parseInboxCursor(cursor)
+		if err != nil {
+			return nil, "", err
+		}
+		start = sort.Search(len(items), func(i int) bool {
+			return after.before(inboxKeyOf(&items[i]))
+		})
+	}
+
+	end := start + limit
+	if end >= len(items) {
+		return items[start:], "", nil
+	}
+	return items[start:end], inboxKeyOf(&items[end-1]).String(), nil
+}
+
 func (h *Handler) createConversation(ctx *gin.Context, uid string, id any, params json.RawMessage) any {
 	var req struct {
 		Type      int      `json:"type"`
@@ -382,7 +949,445 @@ func (h *Handler) getConversationMembers(ctx *gin.Context, uid string, id any, p
 	return map[string]any{"members": resp.Members}
 }
 
-// ============== 群组相关 ==============
+func (h *Handler) getConversationPolicy(ctx *gin.Context, uid string, id any, params json.RawMessage) any {
+	var req struct {
+		Cid string `json:"cid"`
+	}
+	if err := json.Unmarshal(params, &req); err != nil {
+		return &RPCError{Code: -32602, Message: "Invalid params"}
+	}
+
+	if rpcErr := h.checkAccess(ctx, uid, req.Cid); rpcErr != nil {
+		return rpcErr
+	}
+
+	policy, err := h.seakingClient.GetConversationPolicy(ctx.Request.Context(), req.Cid)
+	if err != nil {
+		log.Error().Err(err).Msg("getConversationPolicy failed")
+		return &RPCError{Code: -32000, Message: err.Error()}
+	}
+
+	return map[string]any{"policy": policy}
+}
+
+func (h *Handler) setConversationPolicy(ctx *gin.Context, uid string, id any, params json.RawMessage) any {
+	var req client.ConversationPolicy
+	if err := json.Unmarshal(params, &req); err != nil {
+		return &RPCError{Code: -32602, Message: "Invalid params"}
+	}
+
+	// 管理员权限由SeaKing校验
+	policy, err := h.seakingClient.SetConversationPolicy(ctx.Request.Context(), uid, &req)
+	if err != nil {
+		log.Error().Err(err).Msg("setConversationPolicy failed")
+		return &RPCError{Code: -32000, Message: err.Error()}
+	}
+
+	return map[string]any{"policy": policy}
+}
+
+func (h *Handler) getPinnedMessages(ctx *gin.Context, uid string, id any, params json.RawMessage) any {
+	var req struct {
+		Cid string `json:"cid"`
+	}
+	if err := json.Unmarshal(params, &req); err != nil {
+		return &RPCError{Code: -32602, Message: "Invalid params"}
+	}
+
+	if rpcErr := h.checkAccess(ctx, uid, req.Cid); rpcErr != nil {
+		return rpcErr
+	}
+
+	pins, err := h.seakingClient.GetPinnedMessages(ctx.Request.Context(), req.Cid)
+	if err != nil {
+		log.Error().Err(err).Msg("getPinnedMessages failed")
+		return &RPCError{Code: -32000, Message: err.Error()}
+	}
+
+	// 从Relay获取置顶消息本身
+	messages := make([]map[string]any, 0, len(pins))
+	for _, pin := range pins {
+		event, err := h.relayClient.GetEvent(ctx.Request.Context(), pin.Mid)
+		if err != nil {
+			log.Error().Err(err).Int64("mid", pin.Mid).Msg("getEvent failed")
+			continue
+		}
+		messages = append(messages, map[string]any{
+			"event":     event,
+			"pinned_by": pin.PinnedBy,
+			"position":  pin.Position,
+		})
+	}
+
+	return map[string]any{"pins": messages}
+}
+
+// ============== 消息线程 ==============
+
+func (h *Handler) getThread(ctx *gin.Context, uid string, id any, params json.RawMessage) any {
+	var req struct {
+		Cid     string `json:"cid"`
+		RootMid int64  `json:"root_mid"`
+		Cursor  int64  `json:"cursor"`
+		Limit   int    `json:"limit"`
+	}
+	if err := json.Unmarshal(params, &req); err != nil || req.RootMid <= 0 {
+		return &RPCError{Code: -32602, Message: "Invalid params"}
+	}
+
+	if rpcErr := h.checkAccess(ctx, uid, req.Cid); rpcErr != nil {
+		return rpcErr
+	}
+
+	resp, err := h.relayClient.GetThread(ctx.Request.Context(), &client.GetThreadRequest{
+		Cid:     req.Cid,
+		Uid:     uid,
+		RootMid: req.RootMid,
+		Cursor:  req.Cursor,
+		Limit:   req.Limit,
+	})
+	if err != nil {
+		log.Error().Err(err).Msg("getThread failed")
+		return &RPCError{Code: -32000, Message: err.Error()}
+	}
+
+	return map[string]any{
+		"events":      resp.Events,
+		"summary":     resp.Summary,
+		"next_cursor": resp.NextCursor,
+	}
+}
+
+func (h *Handler) subscribeThread(ctx *gin.Context, uid string, id any, params json.RawMessage) any {
+	var req struct {
+		Cid     string `json:"cid"`
+		RootMid int64  `json:"root_mid"`
+	}
+	if err := json.Unmarshal(params, &req); err != nil || req.RootMid <= 0 {
+		return &RPCError{Code: -32602, Message: "Invalid params"}
+	}
+
+	if rpcErr := h.checkAccess(ctx, uid, req.Cid); rpcErr != nil {
+		return rpcErr
+	}
+
+	if err := h.relayClient.SubscribeThread(ctx.Request.Context(), req.Cid, uid, req.RootMid); err != nil {
+		log.Error().Err(err).Msg("subscribeThread failed")
+		return &RPCError{Code: -32000, Message: err.Error()}
+	}
+
+	return map[string]any{"success": true}
+}
+
+func (h *Handler) unsubscribeThread(ctx *gin.Context, uid string, id any, params json.RawMessage) any {
+	var req struct {
+		Cid     string `json:"cid"`
+		RootMid int64  `json:"root_mid"`
+	}
+	if err := json.Unmarshal(params, &req); err != nil || req.RootMid <= 0 {
+		return &RPCError{Code: -32602, Message: "Invalid params"}
+	}
+
+	if rpcErr := h.checkAccess(ctx, uid, req.Cid); rpcErr != nil {
+		return rpcErr
+	}
+
+	if err := h.relayClient.UnsubscribeThread(ctx.Request.Context(), req.Cid, uid, req.RootMid); err != nil {
+		log.Error().Err(err).Msg("unsubscribeThread failed")
+		return &RPCError{Code: -32000, Message: err.Error()}
+	}
+
+	return map[string]any{"success": true}
+}
+
+func (h *Handler) getReadStatus(ctx *gin.Context, uid string, id any, params json.RawMessage) any {
+	var req struct {
+		Cid string `json:"cid"`
+		Mid int64  `json:"mid"`
+	}
+	if err := json.Unmarshal(params, &req); err != nil || req.Cid == "" || req.Mid <= 0 {
+		return &RPCError{Code: -32602, Message: "Invalid params"}
+	}
+
+	if rpcErr := h.checkAccess(ctx, uid, req.Cid); rpcErr != nil {
+		return rpcErr
+	}
+
+	event, err := h.relayClient.GetEvent(ctx.Request.Context(), req.Mid)
+	if err != nil || event.Cid != req.Cid {
+		return &RPCError{Code: -32000, Message: "Message not found"}
+	}
+
+	conv, err := h.seakingClient.GetConversation(ctx.Request.Context(), req.Cid)
+	if err != nil {
+		log.Error().Err(err).Msg("getReadStatus failed")
+		return &RPCError{Code: -32000, Message: err.Error()}
+	}
+
+	readUids, err := h.relayClient.GetReadStatus(ctx.Request.Context(), req.Cid, req.Mid)
+	if err != nil {
+		log.Error().Err(err).Msg("getReadStatus failed")
+		return &RPCError{Code: -32000, Message: err.Error()}
+	}
+
+	deliveredUids, err := h.relayClient.GetDeliveryStatus(ctx.Request.Context(), req.Cid, req.Mid)
+	if err != nil {
+		log.Error().Err(err).Msg("getReadStatus failed")
+		return &RPCError{Code: -32000, Message: err.Error()}
+	}
+
+	read, unread := splitReaders(conv.MemberIds, readUids, event.Sender)
+	delivered, _ := splitReaders(conv.MemberIds, deliveredUids, event.Sender)
+	return map[string]any{
+		"mid":       req.Mid,
+		"read":      read,
+		"unread":    unread,
+		"delivered": delivered,
+		"total":     len(read) + len(unread),
+	}
+}
+
+// splitReaders 将会话成员（发送者除外）划分为已读和未读
+func splitReaders(memberIds, readUids []string, sender string) ([]string, []string) {
+	readSet := make(map[string]bool, len(readUids))
+	for _, uid := range readUids {
+		readSet[uid] = true
+	}
+
+	read := make([]string, 0, len(memberIds))
+	unread := make([]string, 0, len(memberIds))
+	for _, uid := range memberIds {
+		if uid == sender {
+			continue
+		}
+		if readSet[uid] {
+			read = append(read, uid)
+		} else {
+			unread = append(unread, uid)
+		}
+	}
+	return read, unread
+}
+
+// ============== 定时消息 ==============
+
+func (h *Handler) scheduleMessage(ctx *gin.Context, uid string, id any, params json.RawMessage) any {
+	var req struct {
+		Event       *protocol.Event `json:"event"`
+		ScheduledAt int64           `json:"scheduled_at"`
+	}
+	if err := json.Unmarshal(params, &req); err != nil || req.Event == nil || !isSchedulableKind(req.Event.Kind) {
+		return &RPCError{Code: -32602, Message: "Invalid params"}
+	}
+
+	if rpcErr := h.checkAccess(ctx, uid, req.Event.Cid); rpcErr != nil {
+		return rpcErr
+	}
+
+	req.Event.Sender = uid
+	req.Event.Mid = 0
+	item, err := h.relayClient.ScheduleEvent(ctx.Request.Context(), req.Event, req.ScheduledAt)
+	if err != nil {
+		log.Error().Err(err).Msg("scheduleMessage failed")
+		return &RPCError{Code: -32000, Message: err.Error()}
+	}
+
+	h.pushScheduleUpdate(uid, item)
+	return map[string]any{"scheduled": item}
+}
+
+func (h *Handler) getScheduledMessages(ctx *gin.Context, uid string, id any, params json.RawMessage) any {
+	var req struct {
+		Cid         string `json:"cid"`
+		IncludeDone bool   `json:"include_done"`
+	}
+	if len(params) > 0 {
+		if err := json.Unmarshal(params, &req); err != nil {
+			return &RPCError{Code: -32602, Message: "Invalid params"}
+		}
+	}
+
+	items, err := h.relayClient.ListScheduled(ctx.Request.Context(), &client.ListScheduledRequest{
+		Sender:      uid,
+		Cid:         req.Cid,
+		IncludeDone: req.IncludeDone,
+	})
+	if err != nil {
+		log.Error().Err(err).Msg("getScheduledMessages failed")
+		return &RPCError{Code: -32000, Message: err.Error()}
+	}
+
+	return map[string]any{"items": items}
+}
+
+func (h *Handler) updateScheduledMessage(ctx *gin.Context, uid string, id any, params json.RawMessage) any {
+	var req struct {
+		ID          uint            `json:"id"`
+		Event       *protocol.Event `json:"event"`
+		ScheduledAt int64           `json:"scheduled_at"`
+	}
+	if err := json.Unmarshal(params, &req); err != nil || req.ID == 0 {
+		return &RPCError{Code: -32602, Message: "Invalid params"}
+	}
+
+	if req.Event != nil {
+		if !isSchedulableKind(req.Event.Kind) {
+			return &RPCError{Code: -32602, Message: "Invalid params"}
+		}
+		req.Event.Sender = uid
+	}
+
+	item, err := h.relayClient.UpdateScheduled(ctx.Request.Context(), &client.UpdateScheduledRequest{
+		ID:          req.ID,
+		Sender:      uid,
+		Event:       req.Event,
+		ScheduledAt: req.ScheduledAt,
+	})
+	if err != nil {
+		log.Error().Err(err).Msg("updateScheduledMessage failed")
+		return &RPCError{Code: -32000, Message: err.Error()}
+	}
+
+	h.pushScheduleUpdate(uid, item)
+	return map[string]any{"scheduled": item}
+}
+
+func (h *Handler) cancelScheduledMessage(ctx *gin.Context, uid string, id any, params json.RawMessage) any {
+	var req struct {
+		ID uint `json:"id"`
+	}
+	if err := json.Unmarshal(params, &req); err != nil || req.ID == 0 {
+		return &RPCError{Code: -32602, Message: "Invalid params"}
+	}
+
+	item, err := h.relayClient.CancelScheduled(ctx.Request.Context(), req.ID, uid)
+	if err != nil {
+		log.Error().Err(err).Msg("cancelScheduledMessage failed")
+		return &RPCError{Code: -32000, Message: err.Error()}
+	}
+
+	h.pushScheduleUpdate(uid, item)
+	return map[string]any{"success": true}
+}
+
+// pushScheduleUpdate 将定时消息状态变更同步到作者的所有设备
+func (h *Handler) pushScheduleUpdate(uid string, item *client.ScheduledMessage) {
+	body := &protocol.ScheduleUpdateBody{
+		ID:     item.ID,
+		Status: item.Status,
+		Mid:    item.Mid,
+		Reason: item.FailReason,
+	}
+	if item.Event != nil {
+		body.Cid = item.Event.Cid
+	}
+
+	data, err := protocol.Encode(protocol.NewEnvelope(protocol.CmdScheduleUpdate, 0, body))
+	if err != nil {
+		log.Error().Err(err).Msg("failed to encode schedule update")
+		return
+	}
+	h.hub.SendToUser(uid, data)
+}
+
+// isSchedulableKind 判断消息类型是否支持定时发送（仅内容类消息）
+func isSchedulableKind(kind int) bool {
+	switch kind {
+	case protocol.KindText, protocol.KindFile, protocol.KindForward:
+		return true
+	default:
+		return false
+	}
+}
+
+// ============== 草稿同步 ==============
+
+func (h *Handler) saveDraft(ctx *gin.Context, uid string, id any, params json.RawMessage) any {
+	var req struct {
+		Cid  string `json:"cid"`
+		Data string `json:"data"`
+		Ts   int64  `json:"ts"`
+	}
+	if err := json.Unmarshal(params, &req); err != nil || req.Cid == "" || req.Data == "" {
+		return &RPCError{Code: -32602, Message: "Invalid params"}
+	}
+
+	return h.writeDraft(ctx, uid, req.Cid, req.Data, req.Ts)
+}
+
+func (h *Handler) getDrafts(ctx *gin.Context, uid string, id any, params json.RawMessage) any {
+	var req struct {
+		Cid string `json:"cid"`
+	}
+	if len(params) > 0 {
+		if err := json.Unmarshal(params, &req); err != nil {
+			return &RPCError{Code: -32602, Message: "Invalid params"}
+		}
+	}
+
+	drafts, err := h.seakingClient.GetDrafts(ctx.Request.Context(), uid, req.Cid)
+	if err != nil {
+		log.Error().Err(err).Msg("getDrafts failed")
+		return &RPCError{Code: -32000, Message: err.Error()}
+	}
+
+	return map[string]any{"drafts": drafts}
+}
+
+func (h *Handler) clearDraft(ctx *gin.Context, uid string, id any, params json.RawMessage) any {
+	var req struct {
+		Cid string `json:"cid"`
+		Ts  int64  `json:"ts"`
+	}
+	if err := json.Unmarshal(params, &req); err != nil || req.Cid == "" {
+		return &RPCError{Code: -32602, Message: "Invalid params"}
+	}
+
+	return h.writeDraft(ctx, uid, req.Cid, "", req.Ts)
+}
+
+// writeDraft 写入草稿，生效时同步到用户的所有设备
+func (h *Handler) writeDraft(ctx *gin.Context, uid, cid, data string, ts int64) any {
+	resp, err := h.seakingClient.SaveDraft(ctx.Request.Context(), &client.SaveDraftRequest{
+		Uid:  uid,
+		Cid:  cid,
+		Data: data,
+		Ts:   ts,
+	})
+	if err != nil {
+		log.Error().Err(err).Msg("saveDraft failed")
+		return &RPCError{Code: -32000, Message: err.Error()}
+	}
+
+	if resp.Applied {
+		body := &protocol.DraftUpdateBody{Cid: cid, Data: data, Ts: ts}
+		if encoded, err := protocol.Encode(protocol.NewEnvelope(protocol.CmdDraftUpdate, 0, body)); err != nil {
+			log.Error().Err(err).Msg("failed to encode draft update")
+		} else {
+			h.hub.SendToUser(uid, encoded)
+		}
+	}
+
+	return map[string]any{
+		"draft":   resp.Draft,
+		"applied": resp.Applied,
+	}
+}
+
+// checkAccess 检查用户是否有权访问会话
+func (h *Handler) checkAccess(ctx *gin.Context, uid, cid string) *RPCError {
+	accessResp, err := h.seakingClient.CheckAccess(ctx.Request.Context(), uid, cid)
+	if err != nil {
+		log.Error().Err(err).Msg("checkAccess failed")
+		return &RPCError{Code: -32000, Message: err.Error()}
+	}
+	if !accessResp.HasAccess {
+		return &RPCError{Code: -32003, Message: "Access denied"}
+	}
+	return nil
+}
+
+// ============== 群组相关 ==============
 
 func (h *Handler) getGroups(ctx *gin.Context, uid string, id any, params json.RawMessage) any {
 	groups, err := h.seakingClient.GetUserGroups(ctx.Request.Context(), uid)
@@ -410,6 +1415,18 @@ func (h *Handler) createGroup(ctx *gin.Context, uid string, id any, params json.
 		return &RPCError{Code: -32000, Message: err.Error()}
 	}
 
+	// 新群聊尚无订阅者，逐个推送给群主和初始成员
+	members := make([]string, 0, len(req.MemberIds))
+	seen := map[string]bool{uid: true}
+	for _, memberId := range req.MemberIds {
+		if !seen[memberId] {
+			seen[memberId] = true
+			members = append(members, memberId)
+		}
+	}
+	h.emitSystemEvent(ctx.Request.Context(), "g:"+group.Id, protocol.KindGroupCreated, uid, members,
+		map[string]any{"name": group.Name}, append(members, uid)...)
+
 	return map[string]any{"group": group}
 }
 
@@ -446,3 +1463,761 @@ func (h *Handler) getGroupMembers(ctx *gin.Context, uid string, id any, params j
 
 	return map[string]any{"members": members}
 }
+
+// groupMemberParams 群成员管理操作的公共参数
+type groupMemberParams struct {
+	GroupId string `json:"group_id"`
+	Uid     string `json:"uid"`
+}
+
+// parseGroupMemberParams 解析并校验群成员管理参数
+func parseGroupMemberParams(params json.RawMessage) (*groupMemberParams, *RPCError) {
+	var req groupMemberParams
+	if err := json.Unmarshal(params, &req); err != nil || req.GroupId == "" || req.Uid == "" {
+		return nil, &RPCError{Code: -32602, Message: "Invalid params"}
+	}
+	return &req, nil
+}
+
+func (h *Handler) updateGroup(ctx *gin.Context, uid string, id any, params json.RawMessage) any {
+	var req struct {
+		GroupId     string `json:"group_id"`
+		Name        string `json:"name"`
+		Description string `json:"description"`
+		Avatar      string `json:"avatar"`
+	}
+	if err := json.Unmarshal(params, &req); err != nil || req.GroupId == "" {
+		return &RPCError{Code: -32602, Message: "Invalid params"}
+	}
+	if req.Name == "" && req.Description == "" && req.Avatar == "" {
+		return &RPCError{Code: -32602, Message: "Nothing to update"}
+	}
+
+	group, err := h.seakingClient.UpdateGroup(ctx.Request.Context(), req.GroupId, uid, req.Name, req.Description, req.Avatar)
+	if err != nil {
+		log.Error().Err(err).Msg("updateGroup failed")
+		return &RPCError{Code: -32000, Message: err.Error()}
+	}
+
+	attrs := map[string]any{}
+	if req.Name != "" {
+		attrs["name"] = req.Name
+	}
+	if req.Description != "" {
+		attrs["description"] = req.Description
+	}
+	if req.Avatar != "" {
+		attrs["avatar"] = req.Avatar
+	}
+	h.emitSystemEvent(ctx.Request.Context(), "g:"+req.GroupId, protocol.KindGroupUpdated, uid, nil, attrs)
+	return map[string]any{"group": group}
+}
+
+func (h *Handler) dismissGroup(ctx *gin.Context, uid string, id any, params json.RawMessage) any {
+	var req struct {
+		GroupId string `json:"group_id"`
+	}
+	if err := json.Unmarshal(params, &req); err != nil || req.GroupId == "" {
+		return &RPCError{Code: -32602, Message: "Invalid params"}
+	}
+
+	// 解散后成员列表即被清空，需提前获取以便通知
+	members, err := h.seakingClient.GetGroupMembers(ctx.Request.Context(), req.GroupId)
+	if err != nil {
+		log.Error().Err(err).Msg("dismissGroup failed")
+		return &RPCError{Code: -32000, Message: err.Error()}
+	}
+
+	if err := h.seakingClient.DismissGroup(ctx.Request.Context(), req.GroupId, uid); err != nil {
+		log.Error().Err(err).Msg("dismissGroup failed")
+		return &RPCError{Code: -32000, Message: err.Error()}
+	}
+
+	// 清除所有订阅后逐个推送给原成员
+	h.hub.UnsubscribeAll("g:" + req.GroupId)
+	notify := make([]string, 0, len(members))
+	for _, m := range members {
+		notify = append(notify, m.Uid)
+	}
+	h.emitSystemEvent(ctx.Request.Context(), "g:"+req.GroupId, protocol.KindGroupDismissed, uid, nil, nil, notify...)
+	return map[string]any{"success": true}
+}
+
+func (h *Handler) addGroupMember(ctx *gin.Context, uid string, id any, params json.RawMessage) any {
+	req, rpcErr := parseGroupMemberParams(params)
+	if rpcErr != nil {
+		return rpcErr
+	}
+
+	if err := h.seakingClient.AddGroupMember(ctx.Request.Context(), req.GroupId, uid, req.Uid); err != nil {
+		log.Error().Err(err).Msg("addGroupMember failed")
+		return &RPCError{Code: -32000, Message: err.Error()}
+	}
+
+	// 新成员尚未订阅会话，单独推送给其所有设备
+	h.emitSystemEvent(ctx.Request.Context(), "g:"+req.GroupId, protocol.KindMemberJoined, uid, []string{req.Uid}, nil, req.Uid)
+	return map[string]any{"success": true}
+}
+
+func (h *Handler) removeGroupMember(ctx *gin.Context, uid string, id any, params json.RawMessage) any {
+	req, rpcErr := parseGroupMemberParams(params)
+	if rpcErr != nil {
+		return rpcErr
+	}
+
+	if err := h.seakingClient.RemoveGroupMember(ctx.Request.Context(), req.GroupId, uid, req.Uid); err != nil {
+		log.Error().Err(err).Msg("removeGroupMember failed")
+		return &RPCError{Code: -32000, Message: err.Error()}
+	}
+
+	// 先踢掉被移除成员的实时订阅，再单独通知其所有设备
+	h.hub.UnsubscribeUser(req.Uid, "g:"+req.GroupId)
+	h.clearThreadSubscriptions(ctx.Request.Context(), "g:"+req.GroupId, req.Uid)
+	h.emitSystemEvent(ctx.Request.Context(), "g:"+req.GroupId, protocol.KindMemberLeft, uid, []string{req.Uid},
+		map[string]any{"reason": "removed"}, req.Uid)
+	return map[string]any{"success": true}
+}
+
+func (h *Handler) leaveGroup(ctx *gin.Context, uid string, id any, params json.RawMessage) any {
+	var req struct {
+		GroupId string `json:"group_id"`
+	}
+	if err := json.Unmarshal(params, &req); err != nil || req.GroupId == "" {
+		return &RPCError{Code: -32602, Message: "Invalid params"}
+	}
+
+	if err := h.seakingClient.LeaveGroup(ctx.Request.Context(), req.GroupId, uid); err != nil {
+		log.Error().Err(err).Msg("leaveGroup failed")
+		return &RPCError{Code: -32000, Message: err.Error()}
+	}
+
+	// 退出者的其他设备同样取消订阅并收到通知
+	h.hub.UnsubscribeUser(uid, "g:"+req.GroupId)
+	h.clearThreadSubscriptions(ctx.Request.Context(), "g:"+req.GroupId, uid)
+	h.emitSystemEvent(ctx.Request.Context(), "g:"+req.GroupId, protocol.KindMemberLeft, uid, []string{uid},
+		map[string]any{"reason": "left"}, uid)
+	return map[string]any{"success": true}
+}
+
+func (h *Handler) setGroupAdmin(ctx *gin.Context, uid string, id any, params json.RawMessage) any {
+	var req struct {
+		GroupId string `json:"group_id"`
+		Uid     string `json:"uid"`
+		IsAdmin bool   `json:"is_admin"`
+	}
+	if err := json.Unmarshal(params, &req); err != nil || req.GroupId == "" || req.Uid == "" {
+		return &RPCError{Code: -32602, Message: "Invalid params"}
+	}
+
+	if err := h.seakingClient.SetGroupAdmin(ctx.Request.Context(), req.GroupId, uid, req.Uid, req.IsAdmin); err != nil {
+		log.Error().Err(err).Msg("setGroupAdmin failed")
+		return &RPCError{Code: -32000, Message: err.Error()}
+	}
+
+	role := groupRoleMember
+	if req.IsAdmin {
+		role = groupRoleAdmin
+	}
+	h.emitSystemEvent(ctx.Request.Context(), "g:"+req.GroupId, protocol.KindMemberRoleChanged, uid, []string{req.Uid},
+		map[string]any{"role": role})
+	return map[string]any{"success": true}
+}
+
+func (h *Handler) transferGroupOwner(ctx *gin.Context, uid string, id any, params json.RawMessage) any {
+	req, rpcErr := parseGroupMemberParams(params)
+	if rpcErr != nil {
+		return rpcErr
+	}
+
+	if err := h.seakingClient.TransferGroupOwner(ctx.Request.Context(), req.GroupId, uid, req.Uid); err != nil {
+		log.Error().Err(err).Msg("transferGroupOwner failed")
+		return &RPCError{Code: -32000, Message: err.Error()}
+	}
+
+	// 新群主成为群主，原群主降为普通成员
+	h.emitSystemEvent(ctx.Request.Context(), "g:"+req.GroupId, protocol.KindMemberRoleChanged, uid, []string{req.Uid, uid},
+		map[string]any{"role": groupRoleOwner, "previous_role": groupRoleMember})
+	return map[string]any{"success": true}
+}
+
+func (h *Handler) muteGroupMember(ctx *gin.Context, uid string, id any, params json.RawMessage) any {
+	var req struct {
+		GroupId  string `json:"group_id"`
+		Uid      string `json:"uid"`
+		Duration int64  `json:"duration"` // 禁言时长（秒），0 表示永久
+	}
+	if err := json.Unmarshal(params, &req); err != nil || req.GroupId == "" || req.Uid == "" || req.Duration < 0 {
+		return &RPCError{Code: -32602, Message: "Invalid params"}
+	}
+
+	if err := h.seakingClient.MuteGroupMember(ctx.Request.Context(), req.GroupId, uid, req.Uid, req.Duration); err != nil {
+		log.Error().Err(err).Msg("muteGroupMember failed")
+		return &RPCError{Code: -32000, Message: err.Error()}
+	}
+
+	h.emitSystemEvent(ctx.Request.Context(), "g:"+req.GroupId, protocol.KindMemberMuted, uid, []string{req.Uid},
+		map[string]any{"muted": true, "duration": req.Duration})
+	return map[string]any{"success": true}
+}
+
+func (h *Handler) unmuteGroupMember(ctx *gin.Context, uid string, id any, params json.RawMessage) any {
+	req, rpcErr := parseGroupMemberParams(params)
+	if rpcErr != nil {
+		return rpcErr
+	}
+
+	if err := h.seakingClient.UnmuteGroupMember(ctx.Request.Context(), req.GroupId, uid, req.Uid); err != nil {
+		log.Error().Err(err).Msg("unmuteGroupMember failed")
+		return &RPCError{Code: -32000, Message: err.Error()}
+	}
+
+	h.emitSystemEvent(ctx.Request.Context(), "g:"+req.GroupId, protocol.KindMemberMuted, uid, []string{req.Uid},
+		map[string]any{"muted": false})
+	return map[string]any{"success": true}
+}
+
+func (h *Handler) setGroupMuteAll(ctx *gin.Context, uid string, id any, params json.RawMessage) any {
+	var req struct {
+		GroupId string `json:"group_id"`
+		Enabled bool   `json:"enabled"`
+	}
+	if err := json.Unmarshal(params, &req); err != nil || req.GroupId == "" {
+		return &RPCError{Code: -32602, Message: "Invalid params"}
+	}
+
+	if err := h.seakingClient.SetGroupMuteAll(ctx.Request.Context(), req.GroupId, uid, req.Enabled); err != nil {
+		log.Error().Err(err).Msg("setGroupMuteAll failed")
+		return &RPCError{Code: -32000, Message: err.Error()}
+	}
+
+	h.emitSystemEvent(ctx.Request.Context(), "g:"+req.GroupId, protocol.KindGroupUpdated, uid, nil,
+		map[string]any{"mute_all": req.Enabled})
+	return map[string]any{"success": true}
+}
+
+func (h *Handler) setGroupJoinRequests(ctx *gin.Context, uid string, id any, params json.RawMessage) any {
+	var req struct {
+		GroupId string `json:"group_id"`
+		Enabled bool   `json:"enabled"`
+	}
+	if err := json.Unmarshal(params, &req); err != nil || req.GroupId == "" {
+		return &RPCError{Code: -32602, Message: "Invalid params"}
+	}
+
+	if err := h.seakingClient.SetGroupJoinRequests(ctx.Request.Context(), req.GroupId, uid, req.Enabled); err != nil {
+		log.Error().Err(err).Msg("setGroupJoinRequests failed")
+		return &RPCError{Code: -32000, Message: err.Error()}
+	}
+
+	h.emitSystemEvent(ctx.Request.Context(), "g:"+req.GroupId, protocol.KindGroupUpdated, uid, nil,
+		map[string]any{"join_requests_enabled": req.Enabled})
+	return map[string]any{"success": true}
+}
+
+func (h *Handler) setGroupSlowMode(ctx *gin.Context, uid string, id any, params json.RawMessage) any {
+	var req struct {
+		GroupId string `json:"group_id"`
+		Seconds int    `json:"seconds"`
+	}
+	if err := json.Unmarshal(params, &req); err != nil || req.GroupId == "" || req.Seconds < 0 {
+		return &RPCError{Code: -32602, Message: "Invalid params"}
+	}
+
+	if err := h.seakingClient.SetGroupSlowMode(ctx.Request.Context(), req.GroupId, uid, req.Seconds); err != nil {
+		log.Error().Err(err).Msg("setGroupSlowMode failed")
+		return &RPCError{Code: -32000, Message: err.Error()}
+	}
+
+	h.emitSystemEvent(ctx.Request.Context(), "g:"+req.GroupId, protocol.KindGroupUpdated, uid, nil,
+		map[string]any{"slow_mode": req.Seconds})
+	return map[string]any{"success": true}
+}
+
+func (h *Handler) createGroupInvite(ctx *gin.Context, uid string, id any, params json.RawMessage) any {
+	var req client.CreateGroupInviteRequest
+	if err := json.Unmarshal(params, &req); err != nil || req.GroupId == "" {
+		return &RPCError{Code: -32602, Message: "Invalid params"}
+	}
+	req.OperatorId = uid
+
+	invite, err := h.seakingClient.CreateGroupInvite(ctx.Request.Context(), &req)
+	if err != nil {
+		log.Error().Err(err).Msg("createGroupInvite failed")
+		return &RPCError{Code: -32000, Message: err.Error()}
+	}
+
+	return map[string]any{"invite": invite}
+}
+
+func (h *Handler) getGroupInvites(ctx *gin.Context, uid string, id any, params json.RawMessage) any {
+	var req struct {
+		GroupId string `json:"group_id"`
+	}
+	if err := json.Unmarshal(params, &req); err != nil || req.GroupId == "" {
+		return &RPCError{Code: -32602, Message: "Invalid params"}
+	}
+
+	invites, err := h.seakingClient.GetGroupInvites(ctx.Request.Context(), req.GroupId, uid)
+	if err != nil {
+		log.Error().Err(err).Msg("getGroupInvites failed")
+		return &RPCError{Code: -32000, Message: err.Error()}
+	}
+
+	return map[string]any{"invites": invites}
+}
+
+func (h *Handler) revokeGroupInvite(ctx *gin.Context, uid string, id any, params json.RawMessage) any {
+	var req struct {
+		GroupId  string `json:"group_id"`
+		InviteId uint   `json:"invite_id"`
+	}
+	if err := json.Unmarshal(params, &req); err != nil || req.GroupId == "" || req.InviteId == 0 {
+		return &RPCError{Code: -32602, Message: "Invalid params"}
+	}
+
+	if err := h.seakingClient.RevokeGroupInvite(ctx.Request.Context(), req.GroupId, uid, req.InviteId); err != nil {
+		log.Error().Err(err).Msg("revokeGroupInvite failed")
+		return &RPCError{Code: -32000, Message: err.Error()}
+	}
+
+	return map[string]any{"success": true}
+}
+
+func (h *Handler) previewGroupInvite(ctx *gin.Context, uid string, id any, params json.RawMessage) any {
+	var req struct {
+		Token string `json:"token"`
+	}
+	if err := json.Unmarshal(params, &req); err != nil || req.Token == "" {
+		return &RPCError{Code: -32602, Message: "Invalid params"}
+	}
+
+	preview, err := h.seakingClient.PreviewGroupInvite(ctx.Request.Context(), req.Token)
+	if err != nil {
+		log.Error().Err(err).Msg("previewGroupInvite failed")
+		return &RPCError{Code: -32000, Message: err.Error()}
+	}
+
+	return map[string]any{"group": preview}
+}
+
+func (h *Handler) joinGroupByInvite(ctx *gin.Context, uid string, id any, params json.RawMessage) any {
+	var req struct {
+		Token string `json:"token"`
+	}
+	if err := json.Unmarshal(params, &req); err != nil || req.Token == "" {
+		return &RPCError{Code: -32602, Message: "Invalid params"}
+	}
+
+	res, err := h.seakingClient.JoinGroupByInvite(ctx.Request.Context(), req.Token, uid)
+	if err != nil {
+		log.Error().Err(err).Msg("joinGroupByInvite failed")
+		return &RPCError{Code: -32000, Message: err.Error()}
+	}
+
+	// 链接需要审批，已创建入群申请
+	if res.Request != nil {
+		h.pushJoinRequestUpdate(res.Request, res.Admins)
+		return map[string]any{"group_id": res.GroupId, "pending": true, "request": res.Request}
+	}
+
+	// 新成员尚未订阅会话，单独推送给其所有设备
+	h.emitSystemEvent(ctx.Request.Context(), "g:"+res.GroupId, protocol.KindMemberJoined, uid, []string{uid},
+		map[string]any{"via": "invite"}, uid)
+	return map[string]any{"group_id": res.GroupId}
+}
+
+func (h *Handler) requestGroupJoin(ctx *gin.Context, uid string, id any, params json.RawMessage) any {
+	var req struct {
+		GroupId string `json:"group_id"`
+		Message string `json:"message"`
+	}
+	if err := json.Unmarshal(params, &req); err != nil || req.GroupId == "" {
+		return &RPCError{Code: -32602, Message: "Invalid params"}
+	}
+
+	res, err := h.seakingClient.RequestGroupJoin(ctx.Request.Context(), req.GroupId, uid, req.Message)
+	if err != nil {
+		log.Error().Err(err).Msg("requestGroupJoin failed")
+		return &RPCError{Code: -32000, Message: err.Error()}
+	}
+
+	h.pushJoinRequestUpdate(&res.Request, res.Admins)
+	return map[string]any{"request": res.Request}
+}
+
+func (h *Handler) getGroupJoinRequests(ctx *gin.Context, uid string, id any, params json.RawMessage) any {
+	var req struct {
+		GroupId string `json:"group_id"`
+	}
+	if err := json.Unmarshal(params, &req); err != nil || req.GroupId == "" {
+		return &RPCError{Code: -32602, Message: "Invalid params"}
+	}
+
+	requests, err := h.seakingClient.GetGroupJoinRequests(ctx.Request.Context(), req.GroupId, uid)
+	if err != nil {
+		log.Error().Err(err).Msg("getGroupJoinRequests failed")
+		return &RPCError{Code: -32000, Message: err.Error()}
+	}
+
+	return map[string]any{"requests": requests}
+}
+
+func (h *Handler) approveGroupJoinRequest(ctx *gin.Context, uid string, id any, params json.RawMessage) any {
+	var req struct {
+		GroupId   string `json:"group_id"`
+		RequestId uint   `json:"request_id"`
+	}
+	if err := json.Unmarshal(params, &req); err != nil || req.GroupId == "" || req.RequestId == 0 {
+		return &RPCError{Code: -32602, Message: "Invalid params"}
+	}
+
+	res, err := h.seakingClient.ApproveGroupJoinRequest(ctx.Request.Context(), req.GroupId, uid, req.RequestId)
+	if err != nil {
+		log.Error().Err(err).Msg("approveGroupJoinRequest failed")
+		return &RPCError{Code: -32000, Message: err.Error()}
+	}
+
+	applicant := res.Request.Uid
+	h.emitSystemEvent(ctx.Request.Context(), "g:"+req.GroupId, protocol.KindMemberJoined, uid, []string{applicant},
+		map[string]any{"via": "request"}, applicant)
+	h.pushJoinRequestUpdate(&res.Request, append(res.Admins, applicant))
+	return map[string]any{"success": true}
+}
+
+func (h *Handler) rejectGroupJoinRequest(ctx *gin.Context, uid string, id any, params json.RawMessage) any {
+	var req struct {
+		GroupId   string `json:"group_id"`
+		RequestId uint   `json:"request_id"`
+	}
+	if err := json.Unmarshal(params, &req); err != nil || req.GroupId == "" || req.RequestId == 0 {
+		return &RPCError{Code: -32602, Message: "Invalid params"}
+	}
+
+	res, err := h.seakingClient.RejectGroupJoinRequest(ctx.Request.Context(), req.GroupId, uid, req.RequestId)
+	if err != nil {
+		log.Error().Err(err).Msg("rejectGroupJoinRequest failed")
+		return &RPCError{Code: -32000, Message: err.Error()}
+	}
+
+	h.pushJoinRequestUpdate(&res.Request, append(res.Admins, res.Request.Uid))
+	return map[string]any{"success": true}
+}
+
+func (h *Handler) setGroupAnnouncement(ctx *gin.Context, uid string, id any, params json.RawMessage) any {
+	var req struct {
+		GroupId    string `json:"group_id"`
+		Content    string `json:"content"`
+		KeyVersion int    `json:"key_version"`
+	}
+	if err := json.Unmarshal(params, &req); err != nil || req.GroupId == "" || req.Content == "" {
+		return &RPCError{Code: -32602, Message: "Invalid params"}
+	}
+
+	ann, err := h.seakingClient.SetGroupAnnouncement(ctx.Request.Context(), req.GroupId, uid, req.Content, req.KeyVersion)
+	if err != nil {
+		log.Error().Err(err).Msg("setGroupAnnouncement failed")
+		return &RPCError{Code: -32000, Message: err.Error()}
+	}
+
+	h.emitSystemEvent(ctx.Request.Context(), "g:"+req.GroupId, protocol.KindAnnouncementUpdated, uid, nil,
+		map[string]any{"revision": ann.Revision})
+	return map[string]any{"announcement": ann}
+}
+
+func (h *Handler) clearGroupAnnouncement(ctx *gin.Context, uid string, id any, params json.RawMessage) any {
+	var req struct {
+		GroupId string `json:"group_id"`
+	}
+	if err := json.Unmarshal(params, &req); err != nil || req.GroupId == "" {
+		return &RPCError{Code: -32602, Message: "Invalid params"}
+	}
+
+	ann, err := h.seakingClient.ClearGroupAnnouncement(ctx.Request.Context(), req.GroupId, uid)
+	if err != nil {
+		log.Error().Err(err).Msg("clearGroupAnnouncement failed")
+		return &RPCError{Code: -32000, Message: err.Error()}
+	}
+
+	h.emitSystemEvent(ctx.Request.Context(), "g:"+req.GroupId, protocol.KindAnnouncementUpdated, uid, nil,
+		map[string]any{"revision": ann.Revision, "cleared": true})
+	return map[string]any{"announcement": ann}
+}
+
+func (h *Handler) getGroupAnnouncement(ctx *gin.Context, uid string, id any, params json.RawMessage) any {
+	var req struct {
+		GroupId string `json:"group_id"`
+	}
+	if err := json.Unmarshal(params, &req); err != nil || req.GroupId == "" {
+		return &RPCError{Code: -32602, Message: "Invalid params"}
+	}
+
+	view, err := h.seakingClient.GetGroupAnnouncement(ctx.Request.Context(), req.GroupId, uid)
+	if err != nil {
+		log.Error().Err(err).Msg("getGroupAnnouncement failed")
+		return &RPCError{Code: -32000, Message: err.Error()}
+	}
+
+	return map[string]any{"announcement": view}
+}
+
+func (h *Handler) ackGroupAnnouncement(ctx *gin.Context, uid string, id any, params json.RawMessage) any {
+	var req struct {
+		GroupId  string `json:"group_id"`
+		Revision int    `json:"revision"`
+	}
+	if err := json.Unmarshal(params, &req); err != nil || req.GroupId == "" || req.Revision <= 0 {
+		return &RPCError{Code: -32602, Message: "Invalid params"}
+	}
+
+	if err := h.seakingClient.AckGroupAnnouncement(ctx.Request.Context(), req.GroupId, uid, req.Revision); err != nil {
+		log.Error().Err(err).Msg("ackGroupAnnouncement failed")
+		return &RPCError{Code: -32000, Message: err.Error()}
+	}
+
+	return map[string]any{"success": true}
+}
+
+func (h *Handler) getGroupAnnouncementHistory(ctx *gin.Context, uid string, id any, params json.RawMessage) any {
+	var req struct {
+		GroupId string `json:"group_id"`
+		Limit   int    `json:"limit"`
+	}
+	if err := json.Unmarshal(params, &req); err != nil || req.GroupId == "" {
+		return &RPCError{Code: -32602, Message: "Invalid params"}
+	}
+
+	history, err := h.seakingClient.GetGroupAnnouncementHistory(ctx.Request.Context(), req.GroupId, uid, req.Limit)
+	if err != nil {
+		log.Error().Err(err).Msg("getGroupAnnouncementHistory failed")
+		return &RPCError{Code: -32000, Message: err.Error()}
+	}
+
+	return map[string]any{"history": history}
+}
+
+func (h *Handler) setGroupAnnouncementEncryption(ctx *gin.Context, uid string, id any, params json.RawMessage) any {
+	var req struct {
+		GroupId string `json:"group_id"`
+		Enabled bool   `json:"enabled"`
+	}
+	if err := json.Unmarshal(params, &req); err != nil || req.GroupId == "" {
+		return &RPCError{Code: -32602, Message: "Invalid params"}
+	}
+
+	if err := h.seakingClient.SetGroupAnnouncementEncryption(ctx.Request.Context(), req.GroupId, uid, req.Enabled); err != nil {
+		log.Error().Err(err).Msg("setGroupAnnouncementEncryption failed")
+		return &RPCError{Code: -32000, Message: err.Error()}
+	}
+
+	h.emitSystemEvent(ctx.Request.Context(), "g:"+req.GroupId, protocol.KindGroupUpdated, uid, nil,
+		map[string]any{"announcement_encrypted": req.Enabled})
+	return map[string]any{"success": true}
+}
+
+// pushJoinRequestUpdate 推送入群申请变更（群主和管理员据此刷新待审批列表）
+func (h *Handler) pushJoinRequestUpdate(r *client.GroupJoinRequestInfo, uids []string) {
+	body := &protocol.JoinRequestUpdateBody{
+		ID:        r.ID,
+		GroupId:   r.GroupId,
+		Uid:       r.Uid,
+		Status:    r.Status,
+		Message:   r.Message,
+		HandledBy: r.HandledBy,
+	}
+
+	data, err := protocol.Encode(protocol.NewEnvelope(protocol.CmdJoinRequestUpdate, 0, body))
+	if err != nil {
+		log.Error().Err(err).Msg("failed to encode join request update")
+		return
+	}
+	for _, uid := range uids {
+		h.hub.SendToUser(uid, data)
+	}
+}
+
+// 系统事件中的成员角色，与 SeaKing 群成员角色一致
+const (
+	groupRoleMember = 0
+	groupRoleAdmin  = 1
+	groupRoleOwner  = 2
+)
+
+// clearThreadSubscriptions 删除离开群组的用户在该会话中的线程订阅，失败时只记录日志（推送时仍会按成员过滤）
+func (h *Handler) clearThreadSubscriptions(ctx context.Context, cid, uid string) {
+	if err := h.relayClient.ClearThreadSubscriptions(ctx, cid, uid); err != nil {
+		log.Error().Err(err).Str("cid", cid).Str("uid", uid).Msg("failed to clear thread subscriptions")
+	}
+}
+
+// emitSystemEvent 存储系统事件并广播给会话的在线订阅者，同时推送给 notify 中的用户（如新加入或已离开的成员）
+func (h *Handler) emitSystemEvent(ctx context.Context, cid string, kind int, operator string, targets []string, attrs map[string]any, notify ...string) {
+	event := protocol.NewEvent(kind, cid, operator).SetSystemData(targets, attrs)
+
+	// 系统事件不受会话消息过期时间影响
+	resp, err := h.relayClient.StoreEvent(ctx, event, 0)
+	if err != nil {
+		// 存储失败时仍推送，保证在线成员及时感知变更
+		log.Error().Err(err).Str("cid", cid).Str("kind", protocol.KindName(kind)).Msg("failed to store system event")
+		event.Timestamp = time.Now().Unix()
+	} else {
+		event.Mid = resp.Mid
+		event.Timestamp = resp.Timestamp
+	}
+
+	data, err := protocol.Encode(protocol.NewEnvelope(protocol.CmdEvent, 0, event))
+	if err != nil {
+		log.Error().Err(err).Str("kind", protocol.KindName(kind)).Msg("failed to encode system event")
+		return
+	}
+
+	h.hub.Broadcast(cid, data)
+	for _, uid := range notify {
+		h.hub.SendToUser(uid, data)
+	}
+}
+
+// ============== 加密相关 ==============
+
+func (h *Handler) getUserPublicKey(ctx *gin.Context, uid string, id any, params json.RawMessage) any {
+	var req struct {
+		Uid string `json:"uid"`
+	}
+	if err := json.Unmarshal(params, &req); err != nil || req.Uid == "" {
+		return &RPCError{Code: -32602, Message: "Invalid params"}
+	}
+
+	publicKey, err := h.seakingClient.GetUserPublicKey(ctx.Request.Context(), req.Uid)
+	if err != nil {
+		log.Error().Err(err).Msg("getUserPublicKey failed")
+		return &RPCError{Code: -32000, Message: err.Error()}
+	}
+
+	return map[string]any{"uid": req.Uid, "public_key": publicKey}
+}
+
+func (h *Handler) getChatKey(ctx *gin.Context, uid string, id any, params json.RawMessage) any {
+	var req struct {
+		Cid string `json:"cid"`
+	}
+	if err := json.Unmarshal(params, &req); err != nil || req.Cid == "" {
+		return &RPCError{Code: -32602, Message: "Invalid params"}
+	}
+
+	if rpcErr := h.checkAccess(ctx, uid, req.Cid); rpcErr != nil {
+		return rpcErr
+	}
+
+	chatKey, err := h.seakingClient.GetChatKey(ctx.Request.Context(), req.Cid, uid)
+	if err != nil {
+		log.Error().Err(err).Msg("getChatKey failed")
+		return &RPCError{Code: -32000, Message: err.Error()}
+	}
+
+	return map[string]any{"key": chatKey}
+}
+
+func (h *Handler) createChatKey(ctx *gin.Context, uid string, id any, params json.RawMessage) any {
+	var req struct {
+		Cid  string            `json:"cid"`
+		Keys []client.KeyEntry `json:"keys"`
+	}
+	if err := json.Unmarshal(params, &req); err != nil || req.Cid == "" {
+		return &RPCError{Code: -32602, Message: "Invalid params"}
+	}
+
+	if rpcErr := h.checkKeyRecipients(ctx, uid, req.Cid, req.Keys); rpcErr != nil {
+		return rpcErr
+	}
+
+	if err := h.seakingClient.CreateChatKey(ctx.Request.Context(), req.Cid, req.Keys); err != nil {
+		log.Error().Err(err).Msg("createChatKey failed")
+		return &RPCError{Code: -32000, Message: err.Error()}
+	}
+
+	return map[string]any{"success": true}
+}
+
+func (h *Handler) getGroupKey(ctx *gin.Context, uid string, id any, params json.RawMessage) any {
+	var req struct {
+		GroupId string `json:"group_id"`
+		Version int    `json:"version"` // 0 表示最新版本
+	}
+	if err := json.Unmarshal(params, &req); err != nil || req.GroupId == "" || req.Version < 0 {
+		return &RPCError{Code: -32602, Message: "Invalid params"}
+	}
+
+	if rpcErr := h.checkAccess(ctx, uid, "g:"+req.GroupId); rpcErr != nil {
+		return rpcErr
+	}
+
+	groupKey, err := h.seakingClient.GetGroupKey(ctx.Request.Context(), req.GroupId, uid, req.Version)
+	if err != nil {
+		log.Error().Err(err).Msg("getGroupKey failed")
+		return &RPCError{Code: -32000, Message: err.Error()}
+	}
+
+	return map[string]any{"key": groupKey}
+}
+
+func (h *Handler) createGroupKey(ctx *gin.Context, uid string, id any, params json.RawMessage) any {
+	var req struct {
+		GroupId string            `json:"group_id"`
+		Keys    []client.KeyEntry `json:"keys"`
+		Version int               `json:"version"` // 必须是最新版本 + 1
+	}
+	if err := json.Unmarshal(params, &req); err != nil || req.GroupId == "" || req.Version <= 0 {
+		return &RPCError{Code: -32602, Message: "Invalid params"}
+	}
+
+	if rpcErr := h.checkKeyRecipients(ctx, uid, "g:"+req.GroupId, req.Keys); rpcErr != nil {
+		return rpcErr
+	}
+
+	version, err := h.seakingClient.CreateGroupKey(ctx.Request.Context(), req.GroupId, req.Keys, req.Version)
+	if err != nil {
+		log.Error().Err(err).Msg("createGroupKey failed")
+		return &RPCError{Code: -32000, Message: err.Error()}
+	}
+
+	h.emitSystemEvent(ctx.Request.Context(), "g:"+req.GroupId, protocol.KindKeyRotated, uid, nil,
+		map[string]any{"version": version})
+
+	return map[string]any{"success": true, "version": version}
+}
+
+// checkKeyRecipients 检查上传者是会话成员，且密钥恰好覆盖当前所有成员
+func (h *Handler) checkKeyRecipients(ctx *gin.Context, uid, cid string, keys []client.KeyEntry) *RPCError {
+	if rpcErr := h.checkAccess(ctx, uid, cid); rpcErr != nil {
+		return rpcErr
+	}
+
+	conv, err := h.seakingClient.GetConversation(ctx.Request.Context(), cid)
+	if err != nil {
+		log.Error().Err(err).Msg("checkKeyRecipients failed")
+		return &RPCError{Code: -32000, Message: err.Error()}
+	}
+
+	if !coversMembers(conv.MemberIds, keys) {
+		return &RPCError{Code: -32602, Message: "Keys must cover exactly the current members"}
+	}
+	return nil
+}
+
+// coversMembers 判断密钥条目是否与成员一一对应（无遗漏、无重复、无非成员）
+func coversMembers(memberIds []string, keys []client.KeyEntry) bool {
+	if len(keys) != len(memberIds) {
+		return false
+	}
+
+	pending := make(map[string]bool, len(memberIds))
+	for _, uid := range memberIds {
+		pending[uid] = true
+	}
+	for _, k := range keys {
+		if k.EncryptedKey == "" || !pending[k.Uid] {
+			return false
+		}
+		delete(pending, k.Uid)
+	}
+	return len(pending) == 0
+}
@@ -9,6 +9,9 @@ import (
 
 	"github.com/gin-gonic/gin"
 	"github.com/my-chat/common/pkg/auth"
+	"github.com/my-chat/common/pkg/client"
+	"github.com/my-chat/gateway/internal/conf"
+	"github.com/my-chat/gateway/internal/ws"
 )
 
 func init() {
@@ -17,7 +20,7 @@ func init() {
 
 func TestNewHandler(t *testing.T) {
 	jwtManager := auth.NewJWTManager("test-secret", 24)
-	h := NewHandler(jwtManager, "http://localhost:8081", "http://localhost:8082")
+	h := NewHandler(ws.NewHub(conf.GatewayConfiguration{}), jwtManager, "http://localhost:8081", "http://localhost:8082")
 
 	if h == nil {
 		t.Fatal("NewHandler returned nil")
@@ -42,7 +45,7 @@ func TestNewHandler(t *testing.T) {
 
 func TestHandler_InvalidJSON(t *testing.T) {
 	jwtManager := auth.NewJWTManager("test-secret", 24)
-	h := NewHandler(jwtManager, "http://localhost:8081", "http://localhost:8082")
+	h := NewHandler(ws.NewHub(conf.GatewayConfiguration{}), jwtManager, "http://localhost:8081", "http://localhost:8082")
 
 	router := gin.New()
 	router.POST("/api/rpc", h.Handle)
@@ -73,7 +76,7 @@ func TestHandler_InvalidJSON(t *testing.T) {
 
 func TestHandler_InvalidJSONRPCVersion(t *testing.T) {
 	jwtManager := auth.NewJWTManager("test-secret", 24)
-	h := NewHandler(jwtManager, "http://localhost:8081", "http://localhost:8082")
+	h := NewHandler(ws.NewHub(conf.GatewayConfiguration{}), jwtManager, "http://localhost:8081", "http://localhost:8082")
 
 	router := gin.New()
 	router.POST("/api/rpc", h.Handle)
@@ -107,7 +110,7 @@ func TestHandler_InvalidJSONRPCVersion(t *testing.T) {
 
 func TestHandler_MethodNotFound(t *testing.T) {
 	jwtManager := auth.NewJWTManager("test-secret", 24)
-	h := NewHandler(jwtManager, "http://localhost:8081", "http://localhost:8082")
+	h := NewHandler(ws.NewHub(conf.GatewayConfiguration{}), jwtManager, "http://localhost:8081", "http://localhost:8082")
 
 	router := gin.New()
 	router.POST("/api/rpc", h.Handle)
@@ -141,7 +144,7 @@ func TestHandler_MethodNotFound(t *testing.T) {
 
 func TestHandler_AuthRequired(t *testing.T) {
 	jwtManager := auth.NewJWTManager("test-secret", 24)
-	h := NewHandler(jwtManager, "http://localhost:8081", "http://localhost:8082")
+	h := NewHandler(ws.NewHub(conf.GatewayConfiguration{}), jwtManager, "http://localhost:8081", "http://localhost:8082")
 
 	router := gin.New()
 	router.POST("/api/rpc", h.Handle)
@@ -178,7 +181,7 @@ func TestHandler_AuthRequired(t *testing.T) {
 
 func TestHandler_InvalidToken(t *testing.T) {
 	jwtManager := auth.NewJWTManager("test-secret", 24)
-	h := NewHandler(jwtManager, "http://localhost:8081", "http://localhost:8082")
+	h := NewHandler(ws.NewHub(conf.GatewayConfiguration{}), jwtManager, "http://localhost:8081", "http://localhost:8082")
 
 	router := gin.New()
 	router.POST("/api/rpc", h.Handle)
@@ -214,25 +217,89 @@ func TestHandler_InvalidToken(t *testing.T) {
 
 func TestHandler_RegisteredMethods(t *testing.T) {
 	jwtManager := auth.NewJWTManager("test-secret", 24)
-	h := NewHandler(jwtManager, "http://localhost:8081", "http://localhost:8082")
+	h := NewHandler(ws.NewHub(conf.GatewayConfiguration{}), jwtManager, "http://localhost:8081", "http://localhost:8082")
 
 	expectedMethods := []string{
 		"register",
 		"login",
+		"verifyLogin",
 		"getUserInfo",
+		"searchUsers",
+		"setDiscoverable",
+		"updateProfile",
+		"changePassword",
+		"getSecurityLogs",
+		"getTwoFactorStatus",
+		"enrollTOTP",
+		"confirmTOTP",
+		"disableTOTP",
+		"regenerateRecoveryCodes",
+		"deleteAccount",
+		"requestDataExport",
+		"getDataExports",
 		"getFriends",
 		"sendFriendRequest",
 		"getPendingFriendRequests",
 		"acceptFriendRequest",
 		"rejectFriendRequest",
 		"deleteFriend",
+		"blockUser",
+		"unblockUser",
+		"getBlockedUsers",
 		"getConversations",
+		"getInbox",
 		"createConversation",
 		"getConversationMembers",
+		"getConversationPolicy",
+		"setConversationPolicy",
+		"getPinnedMessages",
+		"getReadStatus",
+		"getThread",
+		"subscribeThread",
+		"unsubscribeThread",
+		"scheduleMessage",
+		"getScheduledMessages",
+		"updateScheduledMessage",
+		"cancelScheduledMessage",
+		"saveDraft",
+		"getDrafts",
+		"clearDraft",
 		"getGroups",
 		"createGroup",
 		"getGroupInfo",
 		"getGroupMembers",
+		"updateGroup",
+		"dismissGroup",
+		"addGroupMember",
+		"removeGroupMember",
+		"leaveGroup",
+		"setGroupAdmin",
+		"transferGroupOwner",
+		"muteGroupMember",
+		"unmuteGroupMember",
+		"setGroupMuteAll",
+		"setGroupJoinRequests",
+		"setGroupSlowMode",
+		"createGroupInvite",
+		"getGroupInvites",
+		"revokeGroupInvite",
+		"previewGroupInvite",
+		"joinGroupByInvite",
+		"requestGroupJoin",
+		"getGroupJoinRequests",
+		"approveGroupJoinRequest",
+		"rejectGroupJoinRequest",
+		"setGroupAnnouncement",
+		"clearGroupAnnouncement",
+		"getGroupAnnouncement",
+		"ackGroupAnnouncement",
+		"getGroupAnnouncementHistory",
+		"setGroupAnnouncementEncryption",
+		"getUserPublicKey",
+		"getChatKey",
+		"createChatKey",
+		"getGroupKey",
+		"createGroupKey",
 	}
 
 	for _, method := range expectedMethods {
@@ -330,7 +397,7 @@ func TestRPCResponse_WithError(t *testing.T) {
 
 func TestHandler_RegisterInvalidParams(t *testing.T) {
 	jwtManager := auth.NewJWTManager("test-secret", 24)
-	h := NewHandler(jwtManager, "http://localhost:8081", "http://localhost:8082")
+	h := NewHandler(ws.NewHub(conf.GatewayConfiguration{}), jwtManager, "http://localhost:8081", "http://localhost:8082")
 
 	router := gin.New()
 	router.POST("/api/rpc", h.Handle)
@@ -365,7 +432,7 @@ func TestHandler_RegisterInvalidParams(t *testing.T) {
 
 func TestHandler_LoginInvalidParams(t *testing.T) {
 	jwtManager := auth.NewJWTManager("test-secret", 24)
-	h := NewHandler(jwtManager, "http://localhost:8081", "http://localhost:8082")
+	h := NewHandler(ws.NewHub(conf.GatewayConfiguration{}), jwtManager, "http://localhost:8081", "http://localhost:8082")
 
 	router := gin.New()
 	router.POST("/api/rpc", h.Handle)
@@ -397,7 +464,7 @@ func TestHandler_LoginInvalidParams(t *testing.T) {
 
 func TestHandler_WithValidToken(t *testing.T) {
 	jwtManager := auth.NewJWTManager("test-secret", 24)
-	h := NewHandler(jwtManager, "http://localhost:8081", "http://localhost:8082")
+	h := NewHandler(ws.NewHub(conf.GatewayConfiguration{}), jwtManager, "http://localhost:8081", "http://localhost:8082")
 
 	// Generate a valid token
 	token, err := jwtManager.GenerateToken("test-uid", "device-1", "ios")
@@ -440,7 +507,7 @@ func TestHandler_WithValidToken(t *testing.T) {
 
 func TestHandler_BearerPrefix(t *testing.T) {
 	jwtManager := auth.NewJWTManager("test-secret", 24)
-	h := NewHandler(jwtManager, "http://localhost:8081", "http://localhost:8082")
+	h := NewHandler(ws.NewHub(conf.GatewayConfiguration{}), jwtManager, "http://localhost:8081", "http://localhost:8082")
 
 	token, _ := jwtManager.GenerateToken("test-uid", "device-1", "ios")
 
@@ -474,7 +541,7 @@ func TestHandler_BearerPrefix(t *testing.T) {
 
 func TestHandler_NullID(t *testing.T) {
 	jwtManager := auth.NewJWTManager("test-secret", 24)
-	h := NewHandler(jwtManager, "http://localhost:8081", "http://localhost:8082")
+	h := NewHandler(ws.NewHub(conf.GatewayConfiguration{}), jwtManager, "http://localhost:8081", "http://localhost:8082")
 
 	router := gin.New()
 	router.POST("/api/rpc", h.Handle)
@@ -498,3 +565,91 @@ func TestHandler_NullID(t *testing.T) {
 		t.Errorf("expected jsonrpc 2.0, got %s", resp.JSONRPC)
 	}
 }
+
+func TestPaginateInbox(t *testing.T) {
+	newItem := func(cid string, pinned bool, lastActivity int64) InboxItem {
+		return InboxItem{
+			ConversationInfo: client.ConversationInfo{Cid: cid, Pinned: pinned},
+			LastActivity:     lastActivity,
+		}
+	}
+	items := []InboxItem{
+		newItem("d:a:b", false, 100),
+		newItem("g:old", false, 10),
+		newItem("g:pinned", true, 5),
+		newItem("g:new", false, 200),
+		newItem("g:empty", false, 0),
+	}
+	want := []string{"g:pinned", "g:new", "d:a:b", "g:old", "g:empty"}
+
+	var got []string
+	cursor := ""
+	for i := 0; i < len(want); i++ {
+		page, next, err := paginateInbox(items, cursor, 2)
+		if err != nil {
+			t.Fatalf("paginateInbox() error = %v", err)
+		}
+		for _, item := range page {
+			got = append(got, item.Cid)
+		}
+		if next == "" {
+			break
+		}
+		cursor = next
+	}
+
+	if len(got) != len(want) {
+		t.Fatalf("paginated cids = %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("paginated cids = %v, want %v", got, want)
+			break
+		}
+	}
+
+	if _, _, err := paginateInbox(items, "bad", 2); err == nil {
+		t.Error("expected error for malformed cursor")
+	}
+}
+
+func TestSplitReaders(t *testing.T) {
+	members := []string{"alice", "bob", "carol", "dave"}
+	// 已离开会话的成员不计入
+	readUids := []string{"bob", "eve", "alice"}
+
+	read, unread := splitReaders(members, readUids, "alice")
+	if len(read) != 1 || read[0] != "bob" {
+		t.Errorf("read = %v, want [bob]", read)
+	}
+	if len(unread) != 2 || unread[0] != "carol" || unread[1] != "dave" {
+		t.Errorf("unread = %v, want [carol dave]", unread)
+	}
+}
+
+func TestCoversMembers(t *testing.T) {
+	members := []string{"alice", "bob", "carol"}
+	entry := func(uid string) client.KeyEntry {
+		return client.KeyEntry{Uid: uid, EncryptedKey: "wrapped-" + uid}
+	}
+
+	tests := []struct {
+		name string
+		keys []client.KeyEntry
+		want bool
+	}{
+		{"exact members", []client.KeyEntry{entry("carol"), entry("alice"), entry("bob")}, true},
+		{"missing member", []client.KeyEntry{entry("alice"), entry("bob")}, false},
+		{"non member", []client.KeyEntry{entry("alice"), entry("bob"), entry("eve")}, false},
+		{"duplicate member", []client.KeyEntry{entry("alice"), entry("alice"), entry("bob")}, false},
+		{"empty key", []client.KeyEntry{entry("alice"), entry("bob"), {Uid: "carol"}}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := coversMembers(members, tt.keys); got != tt.want {
+				t.Errorf("coversMembers() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
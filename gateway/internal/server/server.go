@@ -3,6 +3,7 @@ package server
 import (
 	"fmt"
 	"net/http"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/gorilla/websocket"
@@ -34,10 +35,10 @@ type Server struct {
 
 // NewServer 创建服务器
 func NewServer(config conf.Config, redisClient *redis.Client, r2 *storage.R2Storage) *Server {
-	jwtManager := auth.NewJWTManager(config.JWT.Secret, config.JWT.ExpireHour)
+	jwtManager := auth.NewJWTManager(config.JWT.Secret, config.JWT.ExpireHour).WithRevocation(redisClient)
 	hub := ws.NewHub(config.Gateway)
-	h := handler.NewHandler(hub, jwtManager, config.Gateway.RelayAddr, config.Gateway.SeaKingAddr)
-	rpcHandler := rpc.NewHandler(jwtManager, config.Gateway.SeaKingAddr, config.Gateway.RelayAddr)
+	h := handler.NewHandler(hub, jwtManager, config.Gateway.RelayAddr, config.Gateway.SeaKingAddr).WithStorage(r2).WithRedis(redisClient)
+	rpcHandler := rpc.NewHandler(hub, jwtManager, config.Gateway.SeaKingAddr, config.Gateway.RelayAddr).WithStorage(r2)
 	uploadHandler := handler.NewUploadHandler(r2, redisClient, config.Gateway.UploadRateLimit)
 
 	return &Server{
@@ -64,6 +65,21 @@ func (s *Server) Run() error {
 	// 启动Hub
 	go s.hub.Run()
 
+	// 启动定时消息发送
+	interval := time.Duration(s.config.Gateway.ScheduleInterval) * time.Second
+	if interval <= 0 {
+		interval = 5 * time.Second
+	}
+	go s.handler.RunScheduler(interval)
+
+	// 启动注销账号清理
+	go s.handler.RunAccountPurge(time.Minute)
+
+	// 启动个人数据导出（需要对象存储）
+	if s.r2 != nil {
+		go s.handler.RunDataExport(time.Minute)
+	}
+
 	// 设置Gin模式
 	if !s.config.Service.Debug {
 		gin.SetMode(gin.ReleaseMode)
@@ -71,6 +87,10 @@ func (s *Server) Run() error {
 
 	// 创建Gin引擎
 	s.engine = gin.New()
+	// 客户端IP用于登录限流和安全日志，只接受受信任代理设置的转发头
+	if err := s.engine.SetTrustedProxies(s.config.Gateway.TrustedProxies); err != nil {
+		return fmt.Errorf("invalid trusted proxies: %w", err)
+	}
 	s.engine.Use(middleware.Recover())
 	s.engine.Use(gin.Logger())
 	s.engine.Use(middleware.Cors())
@@ -126,7 +146,7 @@ func (s *Server) authMiddleware() gin.HandlerFunc {
 			token = token[7:]
 		}
 
-		claims, err := s.jwtManager.ParseToken(token)
+		claims, err := s.jwtManager.ValidateToken(c.Request.Context(), token)
 		if err != nil {
 			c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid token"})
 			c.Abort()
@@ -160,7 +180,7 @@ func (s *Server) handleWebSocket(c *gin.Context) {
 	}
 
 	// 验证token
-	claims, err := s.jwtManager.ParseToken(token)
+	claims, err := s.jwtManager.ValidateToken(c.Request.Context(), token)
 	if err != nil {
 		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid token"})
 		return
@@ -162,6 +162,18 @@ func (h *Hub) Unsubscribe(conn *Conn, cid string) {
 	}
 }
 
+// UnsubscribeUser 取消用户所有连接对会话的订阅（成员被移出会话时调用）
+func (h *Hub) UnsubscribeUser(uid, cid string) {
+	for _, conn := range h.GetUserConns(uid) {
+		h.Unsubscribe(conn, cid)
+	}
+}
+
+// UnsubscribeAll 清除会话的所有订阅（会话解散时调用）
+func (h *Hub) UnsubscribeAll(cid string) {
+	h.subscriptions.Delete(cid)
+}
+
 // Broadcast 广播消息到会话
 func (h *Hub) Broadcast(cid string, data []byte) {
 	h.broadcast <- &BroadcastMessage{Cid: cid, Data: data}
@@ -1,6 +1,7 @@
 package ws
 
 import (
+	"sync"
 	"testing"
 
 	"github.com/my-chat/gateway/internal/conf"
@@ -44,3 +45,43 @@ func TestHubConfig(t *testing.T) {
 	// Hub should be properly configured
 	// We can't directly access config, but hub should not be nil
 }
+
+// subscribed 判断连接是否订阅了会话
+func subscribed(h *Hub, conn *Conn, cid string) bool {
+	subsI, ok := h.subscriptions.Load(cid)
+	if !ok {
+		return false
+	}
+	_, ok = subsI.(*sync.Map).Load(conn.id)
+	return ok
+}
+
+func TestHub_UnsubscribeUser(t *testing.T) {
+	hub := NewHub(conf.GatewayConfiguration{})
+
+	phone := NewConn("c1", "alice", "phone", "ios", nil, hub)
+	laptop := NewConn("c2", "alice", "laptop", "web", nil, hub)
+	other := NewConn("c3", "bob", "phone", "android", nil, hub)
+	for _, conn := range []*Conn{phone, laptop, other} {
+		hub.handleRegister(conn)
+		hub.Subscribe(conn, "g:group1")
+	}
+	hub.Subscribe(phone, "g:group2")
+
+	hub.UnsubscribeUser("alice", "g:group1")
+
+	if subscribed(hub, phone, "g:group1") || subscribed(hub, laptop, "g:group1") {
+		t.Error("alice should be unsubscribed from g:group1 on all devices")
+	}
+	if !subscribed(hub, other, "g:group1") {
+		t.Error("bob should stay subscribed to g:group1")
+	}
+	if !subscribed(hub, phone, "g:group2") {
+		t.Error("other subscriptions of alice should be kept")
+	}
+
+	hub.UnsubscribeAll("g:group1")
+	if subscribed(hub, other, "g:group1") {
+		t.Error("UnsubscribeAll should remove every subscriber")
+	}
+}
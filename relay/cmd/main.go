@@ -45,7 +45,11 @@ func main() {
 		if err := db.AutoMigrate(
 			&model.Event{},
 			&model.ReadReceipt{},
+			&model.DeliveryReceipt{},
 			&model.Reaction{},
+			&model.HiddenEvent{},
+			&model.ThreadSubscription{},
+			&model.ScheduledEvent{},
 		); err != nil {
 			log.Fatal().Err(err).Msg("failed to migrate database")
 		}
@@ -17,4 +17,6 @@ type RelayConfiguration struct {
 	RetentionDays int `mapstructure:"RetentionDays"`
 	// 单次查询最大数量
 	MaxQueryLimit int `mapstructure:"MaxQueryLimit"`
+	// 清理过期消息的间隔（秒，默认60）
+	PurgeInterval int `mapstructure:"PurgeInterval"`
 }
@@ -20,6 +20,18 @@ type Event struct {
 	Timestamp int64          `gorm:"index;not null" json:"timestamp"`              // 时间戳
 	CreatedAt time.Time      `json:"created_at"`
 	DeletedAt gorm.DeletedAt `gorm:"index" json:"-"`
+
+	// 撤销信息（全体撤销后原内容被清除，仅保留撤销记录）
+	RevokedBy    string     `gorm:"size:32" json:"revoked_by,omitempty"`     // 撤销者UID
+	RevokeReason string     `gorm:"size:256" json:"revoke_reason,omitempty"` // 撤销原因
+	RevokedAt    *time.Time `json:"revoked_at,omitempty"`                    // 撤销时间
+
+	// 回复线程索引（从TagReply提取）
+	ReplyMid int64 `gorm:"index;default:0" json:"reply_mid,omitempty"` // 直接回复的消息ID
+	RootMid  int64 `gorm:"index;default:0" json:"root_mid,omitempty"`  // 线程根消息ID
+
+	// 阅后即焚：到期后由清理任务物理删除，0表示不过期
+	ExpiresAt int64 `gorm:"index;default:0" json:"expires_at,omitempty"`
 }
 
 // TableName 表名
@@ -27,6 +39,36 @@ func (Event) TableName() string {
 	return "events"
 }
 
+// HiddenEvent 用户隐藏的消息（仅自己删除）
+type HiddenEvent struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	Uid       string    `gorm:"uniqueIndex:idx_hidden_uid_mid;size:32;not null" json:"uid"` // 用户ID
+	Mid       int64     `gorm:"uniqueIndex:idx_hidden_uid_mid;not null" json:"mid"`         // 消息ID
+	Cid       string    `gorm:"index;size:64;not null" json:"cid"`                          // 会话ID
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// TableName 表名
+func (HiddenEvent) TableName() string {
+	return "hidden_events"
+}
+
+// ThreadSubscription 线程订阅（回复者自动订阅，可手动退订）
+type ThreadSubscription struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	Cid       string    `gorm:"uniqueIndex:idx_thread_sub;size:64;not null" json:"cid"` // 会话ID
+	RootMid   int64     `gorm:"uniqueIndex:idx_thread_sub;not null" json:"root_mid"`    // 线程根消息ID
+	Uid       string    `gorm:"uniqueIndex:idx_thread_sub;size:32;not null" json:"uid"` // 用户ID
+	Muted     bool      `gorm:"default:false" json:"muted"`                             // 已退订
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// TableName 表名
+func (ThreadSubscription) TableName() string {
+	return "thread_subscriptions"
+}
+
 // ReadReceipt 已读回执存储模型
 type ReadReceipt struct {
 	ID          uint      `gorm:"primaryKey" json:"id"`
@@ -41,6 +83,20 @@ func (ReadReceipt) TableName() string {
 	return "read_receipts"
 }
 
+// DeliveryReceipt 送达回执存储模型（消息已送达用户的至少一台设备）
+type DeliveryReceipt struct {
+	ID               uint      `gorm:"primaryKey" json:"id"`
+	Cid              string    `gorm:"uniqueIndex:idx_delivery_cid_uid;size:64;not null" json:"cid"` // 会话ID
+	Uid              string    `gorm:"uniqueIndex:idx_delivery_cid_uid;size:32;not null" json:"uid"` // 用户ID
+	LastDeliveredMid int64     `gorm:"not null" json:"last_delivered_mid"`                           // 最后送达消息ID
+	UpdatedAt        time.Time `json:"updated_at"`
+}
+
+// TableName 表名
+func (DeliveryReceipt) TableName() string {
+	return "delivery_receipts"
+}
+
 // Reaction 消息反应存储模型
 type Reaction struct {
 	ID        uint           `gorm:"primaryKey" json:"id"`
@@ -0,0 +1,39 @@
+package model
+
+import (
+	"time"
+
+	"github.com/my-chat/common/pkg/protocol"
+)
+
+// ScheduledEvent 定时发送的事件
+type ScheduledEvent struct {
+	ID          uint      `gorm:"primaryKey" json:"id"`
+	Cid         string    `gorm:"index;size:64;not null" json:"cid"`     // 会话ID
+	Sender      string    `gorm:"index;size:32;not null" json:"sender"`  // 发送者UID
+	Kind        int       `gorm:"not null" json:"kind"`                  // 消息类型
+	Tags        string    `gorm:"type:jsonb" json:"tags"`                // 标签JSON
+	Data        string    `gorm:"type:jsonb" json:"data"`                // 消息体JSON（可为密文）
+	Flags       int       `gorm:"default:0" json:"flags"`                // 标志位
+	Sig         string    `gorm:"size:256" json:"sig"`                   // 签名
+	ScheduledAt int64     `gorm:"index;not null" json:"scheduled_at"`    // 计划发送时间（秒）
+	Status      int       `gorm:"index;default:0" json:"status"`         // 状态
+	Mid         int64     `gorm:"default:0" json:"mid,omitempty"`        // 发送后的消息ID
+	FailReason  string    `gorm:"size:256" json:"fail_reason,omitempty"` // 失败原因
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+// TableName 表名
+func (ScheduledEvent) TableName() string {
+	return "scheduled_events"
+}
+
+// 定时事件状态
+const (
+	ScheduledStatusPending    = protocol.ScheduleStatusPending    // 等待发送
+	ScheduledStatusProcessing = protocol.ScheduleStatusProcessing // 发送中（已被worker领取）
+	ScheduledStatusSent       = protocol.ScheduleStatusSent       // 已发送
+	ScheduledStatusCancelled  = protocol.ScheduleStatusCancelled  // 已取消
+	ScheduledStatusFailed     = protocol.ScheduleStatusFailed     // 发送失败
+)
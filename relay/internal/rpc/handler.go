@@ -6,16 +6,21 @@ import (
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/my-chat/common/pkg/errors"
 	"github.com/my-chat/common/pkg/protocol"
 	"github.com/my-chat/relay/internal/conf"
+	"github.com/my-chat/relay/internal/model"
 	"github.com/my-chat/relay/internal/service/event"
+	"github.com/my-chat/relay/internal/service/schedule"
+	"gorm.io/gorm"
 )
 
 // Handler RPC处理器
 type Handler struct {
-	eventService *event.Service
-	config       conf.RelayConfiguration
-	methods      map[string]MethodHandler
+	eventService    *event.Service
+	scheduleService *schedule.Service
+	config          conf.RelayConfiguration
+	methods         map[string]MethodHandler
 }
 
 // MethodHandler 方法处理函数
@@ -45,11 +50,12 @@ type Error struct {
 }
 
 // NewHandler 创建RPC处理器
-func NewHandler(eventService *event.Service, config conf.RelayConfiguration) *Handler {
+func NewHandler(eventService *event.Service, scheduleService *schedule.Service, config conf.RelayConfiguration) *Handler {
 	h := &Handler{
-		eventService: eventService,
-		config:       config,
-		methods:      make(map[string]MethodHandler),
+		eventService:    eventService,
+		scheduleService: scheduleService,
+		config:          config,
+		methods:         make(map[string]MethodHandler),
 	}
 	h.registerMethods()
 	return h
@@ -61,9 +67,34 @@ func (h *Handler) registerMethods() {
 	h.methods["relay.getEvent"] = h.getEvent
 	h.methods["relay.queryEvents"] = h.queryEvents
 	h.methods["relay.syncEvents"] = h.syncEvents
+	h.methods["relay.hideEvent"] = h.hideEvent
+	h.methods["relay.getThread"] = h.getThread
+	h.methods["relay.getThreadSummaries"] = h.getThreadSummaries
+	h.methods["relay.subscribeThread"] = h.subscribeThread
+	h.methods["relay.unsubscribeThread"] = h.unsubscribeThread
+	h.methods["relay.getThreadSubscribers"] = h.getThreadSubscribers
+	h.methods["relay.clearThreadSubscriptions"] = h.clearThreadSubscriptions
 	h.methods["relay.updateReadReceipt"] = h.updateReadReceipt
+	h.methods["relay.getReadStatus"] = h.getReadStatus
+	h.methods["relay.updateDeliveryReceipt"] = h.updateDeliveryReceipt
+	h.methods["relay.getDeliveryStatus"] = h.getDeliveryStatus
+	h.methods["relay.getConversationSummaries"] = h.getConversationSummaries
 	h.methods["relay.validateRevoke"] = h.validateRevoke
 	h.methods["relay.validateEdit"] = h.validateEdit
+
+	// 定时消息
+	h.methods["relay.scheduleEvent"] = h.scheduleEvent
+	h.methods["relay.listScheduled"] = h.listScheduled
+	h.methods["relay.updateScheduled"] = h.updateScheduled
+	h.methods["relay.cancelScheduled"] = h.cancelScheduled
+	h.methods["relay.cancelUserScheduled"] = h.cancelUserScheduled
+	h.methods["relay.claimDueScheduled"] = h.claimDueScheduled
+	h.methods["relay.completeScheduled"] = h.completeScheduled
+	h.methods["relay.sendScheduled"] = h.sendScheduled
+	h.methods["relay.deferScheduled"] = h.deferScheduled
+
+	// 账号注销
+	h.methods["relay.purgeUser"] = h.purgeUser
 }
 
 // Handle 处理RPC请求
@@ -118,19 +149,42 @@ func (h *Handler) Handle(c *gin.Context) {
 func (h *Handler) storeEvent(ctx context.Context, params json.RawMessage) (interface{}, error) {
 	var req struct {
 		Event *protocol.Event `json:"event"`
+		TTL   int64           `json:"ttl"`
 	}
 	if err := json.Unmarshal(params, &req); err != nil {
 		return nil, err
 	}
 
-	stored, err := h.eventService.StoreEvent(ctx, req.Event)
+	stored, err := h.eventService.StoreEvent(ctx, req.Event, req.TTL)
 	if err != nil {
 		return nil, err
 	}
 
 	return map[string]interface{}{
-		"mid":       stored.Mid,
-		"timestamp": stored.Timestamp,
+		"mid":        stored.Mid,
+		"timestamp":  stored.Timestamp,
+		"root_mid":   stored.RootMid,
+		"expires_at": stored.ExpiresAt,
+	}, nil
+}
+
+// getConversationSummaries 批量获取会话最后消息和未读数
+func (h *Handler) getConversationSummaries(ctx context.Context, params json.RawMessage) (interface{}, error) {
+	var req struct {
+		Uid  string   `json:"uid"`
+		Cids []string `json:"cids"`
+	}
+	if err := json.Unmarshal(params, &req); err != nil {
+		return nil, err
+	}
+
+	summaries, err := h.eventService.GetConversationSummaries(ctx, req.Uid, req.Cids)
+	if err != nil {
+		return nil, err
+	}
+
+	return map[string]interface{}{
+		"summaries": summaries,
 	}, nil
 }
 
@@ -163,8 +217,14 @@ func (h *Handler) queryEvents(ctx context.Context, params json.RawMessage) (inte
 		return nil, err
 	}
 
+	threads, err := h.eventService.GetThreadSummariesForEvents(ctx, req.Cid, events)
+	if err != nil {
+		return nil, err
+	}
+
 	return map[string]interface{}{
-		"events": events,
+		"events":  events,
+		"threads": threads,
 	}, nil
 }
 
@@ -172,6 +232,7 @@ func (h *Handler) queryEvents(ctx context.Context, params json.RawMessage) (inte
 func (h *Handler) syncEvents(ctx context.Context, params json.RawMessage) (interface{}, error) {
 	var req struct {
 		Cid   string `json:"cid"`
+		Uid   string `json:"uid"`
 		Limit int    `json:"limit"`
 	}
 	if err := json.Unmarshal(params, &req); err != nil {
@@ -182,13 +243,180 @@ func (h *Handler) syncEvents(ctx context.Context, params json.RawMessage) (inter
 		req.Limit = 50
 	}
 
-	events, err := h.eventService.QueryEventsDesc(ctx, req.Cid, req.Limit)
+	events, err := h.eventService.QueryEventsDesc(ctx, req.Cid, req.Uid, req.Limit)
 	if err != nil {
 		return nil, err
 	}
 
+	threads, err := h.eventService.GetThreadSummariesForEvents(ctx, req.Cid, events)
+	if err != nil {
+		return nil, err
+	}
+
+	return map[string]interface{}{
+		"events":  events,
+		"threads": threads,
+	}, nil
+}
+
+// hideEvent 为用户隐藏消息（仅自己删除）
+func (h *Handler) hideEvent(ctx context.Context, params json.RawMessage) (interface{}, error) {
+	var req struct {
+		Cid string `json:"cid"`
+		Uid string `json:"uid"`
+		Mid int64  `json:"mid"`
+	}
+	if err := json.Unmarshal(params, &req); err != nil {
+		return nil, err
+	}
+
+	if err := h.eventService.HideEvent(ctx, req.Cid, req.Uid, req.Mid); err != nil {
+		return nil, err
+	}
+
 	return map[string]interface{}{
-		"events": events,
+		"success": true,
+	}, nil
+}
+
+// getThread 分页获取线程回复
+func (h *Handler) getThread(ctx context.Context, params json.RawMessage) (interface{}, error) {
+	var req struct {
+		Cid     string `json:"cid"`
+		Uid     string `json:"uid"`
+		RootMid int64  `json:"root_mid"`
+		Cursor  int64  `json:"cursor"`
+		Limit   int    `json:"limit"`
+	}
+	if err := json.Unmarshal(params, &req); err != nil {
+		return nil, err
+	}
+
+	if req.Limit <= 0 {
+		req.Limit = 50
+	}
+
+	events, err := h.eventService.GetThread(ctx, req.Cid, req.Uid, req.RootMid, req.Cursor, req.Limit)
+	if err != nil {
+		return nil, err
+	}
+
+	summaries, err := h.eventService.GetThreadSummaries(ctx, req.Cid, []int64{req.RootMid})
+	if err != nil {
+		return nil, err
+	}
+
+	var nextCursor int64
+	if len(events) == req.Limit {
+		nextCursor = events[len(events)-1].Mid
+	}
+
+	result := map[string]interface{}{
+		"events":      events,
+		"next_cursor": nextCursor,
+	}
+	if len(summaries) > 0 {
+		result["summary"] = summaries[0]
+	}
+	return result, nil
+}
+
+// getThreadSummaries 批量获取线程摘要
+func (h *Handler) getThreadSummaries(ctx context.Context, params json.RawMessage) (interface{}, error) {
+	var req struct {
+		Cid      string  `json:"cid"`
+		RootMids []int64 `json:"root_mids"`
+	}
+	if err := json.Unmarshal(params, &req); err != nil {
+		return nil, err
+	}
+
+	threads, err := h.eventService.GetThreadSummaries(ctx, req.Cid, req.RootMids)
+	if err != nil {
+		return nil, err
+	}
+
+	return map[string]interface{}{
+		"threads": threads,
+	}, nil
+}
+
+// subscribeThread 订阅线程
+func (h *Handler) subscribeThread(ctx context.Context, params json.RawMessage) (interface{}, error) {
+	var req struct {
+		Cid     string `json:"cid"`
+		Uid     string `json:"uid"`
+		RootMid int64  `json:"root_mid"`
+	}
+	if err := json.Unmarshal(params, &req); err != nil {
+		return nil, err
+	}
+
+	if err := h.eventService.SubscribeThread(ctx, req.Cid, req.Uid, req.RootMid); err != nil {
+		return nil, err
+	}
+
+	return map[string]interface{}{
+		"success": true,
+	}, nil
+}
+
+// unsubscribeThread 退订线程
+func (h *Handler) unsubscribeThread(ctx context.Context, params json.RawMessage) (interface{}, error) {
+	var req struct {
+		Cid     string `json:"cid"`
+		Uid     string `json:"uid"`
+		RootMid int64  `json:"root_mid"`
+	}
+	if err := json.Unmarshal(params, &req); err != nil {
+		return nil, err
+	}
+
+	if err := h.eventService.UnsubscribeThread(ctx, req.Cid, req.Uid, req.RootMid); err != nil {
+		return nil, err
+	}
+
+	return map[string]interface{}{
+		"success": true,
+	}, nil
+}
+
+// getThreadSubscribers 获取线程订阅者
+func (h *Handler) getThreadSubscribers(ctx context.Context, params json.RawMessage) (interface{}, error) {
+	var req struct {
+		Cid     string `json:"cid"`
+		RootMid int64  `json:"root_mid"`
+	}
+	if err := json.Unmarshal(params, &req); err != nil {
+		return nil, err
+	}
+
+	uids, err := h.eventService.GetThreadSubscribers(ctx, req.Cid, req.RootMid)
+	if err != nil {
+		return nil, err
+	}
+
+	return map[string]interface{}{
+		"uids": uids,
+	}, nil
+}
+
+// clearThreadSubscriptions 删除用户在会话中的全部线程订阅
+func (h *Handler) clearThreadSubscriptions(ctx context.Context, params json.RawMessage) (interface{}, error) {
+	var req struct {
+		Cid string `json:"cid"`
+		Uid string `json:"uid"`
+	}
+	if err := json.Unmarshal(params, &req); err != nil {
+		return nil, err
+	}
+
+	if err := h.eventService.ClearThreadSubscriptions(ctx, req.Cid, req.Uid); err != nil {
+		return nil, err
+	}
+
+	return map[string]interface{}{
+		"success": true,
 	}, nil
 }
 
@@ -203,28 +431,98 @@ func (h *Handler) updateReadReceipt(ctx context.Context, params json.RawMessage)
 		return nil, err
 	}
 
-	err := h.eventService.UpdateReadReceipt(ctx, req.Cid, req.Uid, req.LastReadMid)
+	senders, err := h.eventService.UpdateReadReceipt(ctx, req.Cid, req.Uid, req.LastReadMid)
+	if err != nil {
+		return nil, err
+	}
+
+	return map[string]interface{}{
+		"success": true,
+		"senders": senders,
+	}, nil
+}
+
+// getReadStatus 获取已读到指定消息的用户
+func (h *Handler) getReadStatus(ctx context.Context, params json.RawMessage) (interface{}, error) {
+	var req struct {
+		Cid string `json:"cid"`
+		Mid int64  `json:"mid"`
+	}
+	if err := json.Unmarshal(params, &req); err != nil {
+		return nil, err
+	}
+
+	uids, err := h.eventService.GetReadStatus(ctx, req.Cid, req.Mid)
+	if err != nil {
+		return nil, err
+	}
+
+	return map[string]interface{}{
+		"read_uids": uids,
+	}, nil
+}
+
+// updateDeliveryReceipt 更新送达回执
+func (h *Handler) updateDeliveryReceipt(ctx context.Context, params json.RawMessage) (interface{}, error) {
+	var req struct {
+		Cid              string `json:"cid"`
+		Uid              string `json:"uid"`
+		LastDeliveredMid int64  `json:"last_delivered_mid"`
+	}
+	if err := json.Unmarshal(params, &req); err != nil {
+		return nil, err
+	}
+
+	senders, err := h.eventService.UpdateDeliveryReceipt(ctx, req.Cid, req.Uid, req.LastDeliveredMid)
 	if err != nil {
 		return nil, err
 	}
 
 	return map[string]interface{}{
 		"success": true,
+		"senders": senders,
+	}, nil
+}
+
+// getDeliveryStatus 获取已送达指定消息的用户
+func (h *Handler) getDeliveryStatus(ctx context.Context, params json.RawMessage) (interface{}, error) {
+	var req struct {
+		Cid string `json:"cid"`
+		Mid int64  `json:"mid"`
+	}
+	if err := json.Unmarshal(params, &req); err != nil {
+		return nil, err
+	}
+
+	uids, err := h.eventService.GetDeliveryStatus(ctx, req.Cid, req.Mid)
+	if err != nil {
+		return nil, err
+	}
+
+	return map[string]interface{}{
+		"delivered_uids": uids,
 	}, nil
 }
 
 // validateRevoke 验证撤销权限
 func (h *Handler) validateRevoke(ctx context.Context, params json.RawMessage) (interface{}, error) {
 	var req struct {
-		Cid       string `json:"cid"`
-		Uid       string `json:"uid"`
-		TargetMid int64  `json:"target_mid"`
-		IsAdmin   bool   `json:"is_admin"`
+		Cid       string               `json:"cid"`
+		Uid       string               `json:"uid"`
+		TargetMid int64                `json:"target_mid"`
+		IsAdmin   bool                 `json:"is_admin"`
+		Policy    *event.MessagePolicy `json:"policy"`
 	}
 	if err := json.Unmarshal(params, &req); err != nil {
 		return nil, err
 	}
 
+	policy := req.Policy
+	if policy == nil {
+		policy = event.DefaultMessagePolicy()
+	}
+	adminOverride := req.IsAdmin && policy.AdminRevokeAny
+
 	// 获取目标消息
 	targetEvent, err := h.eventService.GetEvent(ctx, req.TargetMid)
 	if err != nil {
@@ -242,17 +540,32 @@ func (h *Handler) validateRevoke(ctx context.Context, params json.RawMessage) (i
 		}, nil
 	}
 
-	// 检查是否是自己发送的消息，或者是管理员
-	if targetEvent.Sender != req.Uid && !req.IsAdmin {
+	// 系统事件是会话变更的审计记录，不能撤销
+	if protocol.IsSystemKind(targetEvent.Kind) {
+		return map[string]interface{}{
+			"valid":  false,
+			"reason": "system event cannot be revoked",
+		}, nil
+	}
+
+	// 已撤销的消息不能重复撤销
+	if targetEvent.Flags&protocol.FlagRevoked != 0 {
+		return map[string]interface{}{
+			"valid":  false,
+			"reason": "message already revoked",
+		}, nil
+	}
+
+	// 检查是否是自己发送的消息，或者是可撤销任何人消息的管理员
+	if targetEvent.Sender != req.Uid && !adminOverride {
 		return map[string]interface{}{
 			"valid":  false,
 			"reason": "no permission to revoke",
 		}, nil
 	}
 
-	// 检查时间窗口（2分钟内可撤销）
-	revokeWindow := int64(2 * 60) // 2分钟
-	if time.Now().Unix()-targetEvent.Timestamp > revokeWindow && !req.IsAdmin {
+	// 检查撤销时间窗口
+	if policy.RevokeExpired(targetEvent.Timestamp, time.Now().Unix()) && !adminOverride {
 		return map[string]interface{}{
 			"valid":  false,
 			"reason": "revoke time exceeded",
@@ -267,14 +580,20 @@ func (h *Handler) validateRevoke(ctx context.Context, params json.RawMessage) (i
 // validateEdit 验证编辑权限
 func (h *Handler) validateEdit(ctx context.Context, params json.RawMessage) (interface{}, error) {
 	var req struct {
-		Cid       string `json:"cid"`
-		Uid       string `json:"uid"`
-		TargetMid int64  `json:"target_mid"`
+		Cid       string               `json:"cid"`
+		Uid       string               `json:"uid"`
+		TargetMid int64                `json:"target_mid"`
+		Policy    *event.MessagePolicy `json:"policy"`
 	}
 	if err := json.Unmarshal(params, &req); err != nil {
 		return nil, err
 	}
 
+	policy := req.Policy
+	if policy == nil {
+		policy = event.DefaultMessagePolicy()
+	}
+
 	// 获取目标消息
 	targetEvent, err := h.eventService.GetEvent(ctx, req.TargetMid)
 	if err != nil {
@@ -292,6 +611,14 @@ func (h *Handler) validateEdit(ctx context.Context, params json.RawMessage) (int
 		}, nil
 	}
 
+	// 已撤销的消息不能编辑
+	if targetEvent.Flags&protocol.FlagRevoked != 0 {
+		return map[string]interface{}{
+			"valid":  false,
+			"reason": "message already revoked",
+		}, nil
+	}
+
 	// 只能编辑自己发送的消息
 	if targetEvent.Sender != req.Uid {
 		return map[string]interface{}{
@@ -300,17 +627,16 @@ func (h *Handler) validateEdit(ctx context.Context, params json.RawMessage) (int
 		}, nil
 	}
 
-	// 只能编辑文本消息
-	if targetEvent.Kind != protocol.KindText {
+	// 检查消息类型是否允许编辑
+	if !policy.CanEditKind(targetEvent.Kind) {
 		return map[string]interface{}{
 			"valid":  false,
-			"reason": "can only edit text message",
+			"reason": "message kind not editable",
 		}, nil
 	}
 
-	// 检查时间窗口（24小时内可编辑）
-	editWindow := int64(24 * 60 * 60) // 24小时
-	if time.Now().Unix()-targetEvent.Timestamp > editWindow {
+	// 检查编辑时间窗口
+	if policy.EditExpired(targetEvent.Timestamp, time.Now().Unix()) {
 		return map[string]interface{}{
 			"valid":  false,
 			"reason": "edit time exceeded",
@@ -321,3 +647,203 @@ func (h *Handler) validateEdit(ctx context.Context, params json.RawMessage) (int
 		"valid": true,
 	}, nil
 }
+
+// scheduleEvent 创建定时消息
+func (h *Handler) scheduleEvent(ctx context.Context, params json.RawMessage) (interface{}, error) {
+	var req schedule.ScheduleRequest
+	if err := json.Unmarshal(params, &req); err != nil {
+		return nil, err
+	}
+
+	return h.scheduleService.Schedule(ctx, &req)
+}
+
+// listScheduled 获取用户的定时消息
+func (h *Handler) listScheduled(ctx context.Context, params json.RawMessage) (interface{}, error) {
+	var req struct {
+		Sender      string `json:"sender"`
+		Cid         string `json:"cid"`
+		IncludeDone bool   `json:"include_done"`
+	}
+	if err := json.Unmarshal(params, &req); err != nil {
+		return nil, err
+	}
+
+	items, err := h.scheduleService.List(ctx, req.Sender, req.Cid, req.IncludeDone)
+	if err != nil {
+		return nil, err
+	}
+
+	return map[string]interface{}{
+		"items": items,
+	}, nil
+}
+
+// updateScheduled 修改定时消息
+func (h *Handler) updateScheduled(ctx context.Context, params json.RawMessage) (interface{}, error) {
+	var req schedule.UpdateRequest
+	if err := json.Unmarshal(params, &req); err != nil {
+		return nil, err
+	}
+
+	return h.scheduleService.Update(ctx, &req)
+}
+
+// cancelScheduled 取消定时消息
+func (h *Handler) cancelScheduled(ctx context.Context, params json.RawMessage) (interface{}, error) {
+	var req struct {
+		ID     uint   `json:"id"`
+		Sender string `json:"sender"`
+	}
+	if err := json.Unmarshal(params, &req); err != nil {
+		return nil, err
+	}
+
+	return h.scheduleService.Cancel(ctx, req.ID, req.Sender)
+}
+
+// cancelUserScheduled 取消用户所有等待发送的定时消息（账号注销时调用）
+func (h *Handler) cancelUserScheduled(ctx context.Context, params json.RawMessage) (interface{}, error) {
+	var req struct {
+		Sender string `json:"sender"`
+	}
+	if err := json.Unmarshal(params, &req); err != nil || req.Sender == "" {
+		return nil, errors.ErrInvalidParam
+	}
+
+	cancelled, err := h.scheduleService.CancelBySender(ctx, req.Sender)
+	if err != nil {
+		return nil, err
+	}
+
+	return map[string]interface{}{
+		"cancelled": cancelled,
+	}, nil
+}
+
+// claimDueScheduled 领取到期的定时消息（供Gateway worker调用）
+func (h *Handler) claimDueScheduled(ctx context.Context, params json.RawMessage) (interface{}, error) {
+	var req struct {
+		Limit int `json:"limit"`
+	}
+	if err := json.Unmarshal(params, &req); err != nil {
+		return nil, err
+	}
+
+	items, err := h.scheduleService.ClaimDue(ctx, req.Limit)
+	if err != nil {
+		return nil, err
+	}
+
+	return map[string]interface{}{
+		"items": items,
+	}, nil
+}
+
+// sendScheduled 存储定时消息对应的事件，并在同一事务中标记为已发送
+// 已发送过的定时消息直接返回原消息ID（duplicate=true），worker 重试不会重复存储
+func (h *Handler) sendScheduled(ctx context.Context, params json.RawMessage) (interface{}, error) {
+	var req struct {
+		ID  uint  `json:"id"`
+		TTL int64 `json:"ttl"`
+	}
+	if err := json.Unmarshal(params, &req); err != nil {
+		return nil, err
+	}
+
+	item, err := h.scheduleService.GetForSend(ctx, req.ID)
+	if err != nil {
+		return nil, err
+	}
+	if item.Status == model.ScheduledStatusSent {
+		return duplicateScheduled(item.Mid), nil
+	}
+
+	stored, err := h.eventService.StoreEventWith(ctx, item.Event, req.TTL, func(tx *gorm.DB, e *model.Event) error {
+		return h.scheduleService.MarkSentTx(tx, req.ID, e.Mid)
+	})
+	if err != nil {
+		// 并发重试时另一个worker已先完成发送
+		if again, gerr := h.scheduleService.GetForSend(ctx, req.ID); gerr == nil && again.Status == model.ScheduledStatusSent {
+			return duplicateScheduled(again.Mid), nil
+		}
+		return nil, err
+	}
+
+	return map[string]interface{}{
+		"mid":        stored.Mid,
+		"timestamp":  stored.Timestamp,
+		"root_mid":   stored.RootMid,
+		"expires_at": stored.ExpiresAt,
+	}, nil
+}
+
+// duplicateScheduled 已发送过的定时消息的响应
+func duplicateScheduled(mid int64) map[string]interface{} {
+	return map[string]interface{}{
+		"mid":       mid,
+		"duplicate": true,
+	}
+}
+
+// completeScheduled 记录定时消息发送结果
+func (h *Handler) completeScheduled(ctx context.Context, params json.RawMessage) (interface{}, error) {
+	var req struct {
+		ID     uint   `json:"id"`
+		Mid    int64  `json:"mid"`
+		Reason string `json:"reason"`
+	}
+	if err := json.Unmarshal(params, &req); err != nil {
+		return nil, err
+	}
+
+	if err := h.scheduleService.Complete(ctx, req.ID, req.Mid, req.Reason); err != nil {
+		return nil, err
+	}
+
+	return map[string]interface{}{
+		"success": true,
+	}, nil
+}
+
+// deferScheduled 推迟已领取的定时消息（慢速模式等暂时无法发送时）
+func (h *Handler) deferScheduled(ctx context.Context, params json.RawMessage) (interface{}, error) {
+	var req struct {
+		ID          uint  `json:"id"`
+		ScheduledAt int64 `json:"scheduled_at"`
+	}
+	if err := json.Unmarshal(params, &req); err != nil {
+		return nil, err
+	}
+
+	if err := h.scheduleService.Defer(ctx, req.ID, req.ScheduledAt); err != nil {
+		return nil, err
+	}
+
+	return map[string]interface{}{
+		"success": true,
+	}, nil
+}
+
+// purgeUser 分批清理已注销用户的数据（供Gateway worker调用，重复调用直到 done）
+func (h *Handler) purgeUser(ctx context.Context, params json.RawMessage) (interface{}, error) {
+	var req struct {
+		Uid   string `json:"uid"`
+		Limit int    `json:"limit"`
+	}
+	if err := json.Unmarshal(params, &req); err != nil {
+		return nil, err
+	}
+	if req.Uid == "" || req.Uid == protocol.DeletedSender {
+		return nil, errors.ErrInvalidParam
+	}
+
+	done, err := h.eventService.PurgeUser(ctx, req.Uid, req.Limit)
+	if err != nil {
+		return nil, err
+	}
+
+	return map[string]interface{}{
+		"done": done,
+	}, nil
+}
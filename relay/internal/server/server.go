@@ -3,6 +3,7 @@ package server
 import (
 	"fmt"
 	"net/http"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/my-chat/common/pkg/log"
@@ -10,29 +11,33 @@ import (
 	"github.com/my-chat/relay/internal/conf"
 	"github.com/my-chat/relay/internal/rpc"
 	"github.com/my-chat/relay/internal/service/event"
+	"github.com/my-chat/relay/internal/service/schedule"
 	"github.com/my-chat/relay/internal/storage"
 )
 
 // Server Relay服务器
 type Server struct {
-	config     conf.Config
-	storage    *storage.Storage
-	rpcHandler *rpc.Handler
-	engine     *gin.Engine
+	config       conf.Config
+	storage      *storage.Storage
+	eventService *event.Service
+	rpcHandler   *rpc.Handler
+	engine       *gin.Engine
 }
 
 // NewServer 创建服务器
 func NewServer(config conf.Config, storage *storage.Storage) *Server {
 	// 创建服务
 	eventService := event.NewService(storage, config.Relay)
+	scheduleService := schedule.NewService(storage)
 
 	// 创建RPC处理器（内部服务通信）
-	rpcHandler := rpc.NewHandler(eventService, config.Relay)
+	rpcHandler := rpc.NewHandler(eventService, scheduleService, config.Relay)
 
 	return &Server{
-		config:     config,
-		storage:    storage,
-		rpcHandler: rpcHandler,
+		config:       config,
+		storage:      storage,
+		eventService: eventService,
+		rpcHandler:   rpcHandler,
 	}
 }
 
@@ -49,6 +54,13 @@ func (s *Server) Run() error {
 
 	s.registerRoutes()
 
+	// 清理过期消息
+	purgeInterval := time.Duration(s.config.Relay.PurgeInterval) * time.Second
+	if purgeInterval <= 0 {
+		purgeInterval = time.Minute
+	}
+	go s.eventService.RunExpiryPurge(purgeInterval)
+
 	addr := fmt.Sprintf(":%s", s.config.Service.Port)
 	log.Info().Str("addr", addr).Msg("relay server starting")
 	return s.engine.Run(addr)
@@ -0,0 +1,65 @@
+package event
+
+import (
+	"context"
+
+	"github.com/my-chat/common/pkg/protocol"
+	"github.com/my-chat/relay/internal/model"
+	"gorm.io/gorm"
+)
+
+// DefaultPurgeUserBatch 注销清理时每张表每次最多处理的记录数
+const DefaultPurgeUserBatch = 500
+
+// PurgeUser 清理已注销用户的数据：匿名化其发送的事件，删除回执、反应、隐藏记录、线程订阅和定时消息
+// 每张表每次最多处理 limit 条，返回是否已全部清理完成；可重复调用，中断后继续调用即可
+func (s *Service) PurgeUser(ctx context.Context, uid string, limit int) (bool, error) {
+	if limit <= 0 {
+		limit = DefaultPurgeUserBatch
+	}
+	db := s.storage.DB()
+
+	// 保留事件本身以维持消息顺序和回复线程，只清除内容和发送者
+	sub := db.Unscoped().Model(&model.Event{}).Select("id").Where("sender = ?", uid).Limit(limit)
+	res := db.Unscoped().Model(&model.Event{}).Where("id IN (?)", sub).Updates(map[string]interface{}{
+		"sender": protocol.DeletedSender,
+		"flags":  gorm.Expr("flags | ?", protocol.FlagSenderDeleted),
+		"data":   "{}",
+		"sig":    "",
+	})
+	if res.Error != nil {
+		return false, res.Error
+	}
+	if res.RowsAffected >= int64(limit) {
+		return false, nil
+	}
+
+	for _, m := range []interface{}{
+		&model.ReadReceipt{},
+		&model.DeliveryReceipt{},
+		&model.Reaction{},
+		&model.HiddenEvent{},
+		&model.ThreadSubscription{},
+	} {
+		n, err := deleteUserBatch(db, m, "uid = ?", uid, limit)
+		if err != nil {
+			return false, err
+		}
+		if n >= int64(limit) {
+			return false, nil
+		}
+	}
+
+	n, err := deleteUserBatch(db, &model.ScheduledEvent{}, "sender = ?", uid, limit)
+	if err != nil {
+		return false, err
+	}
+	return n < int64(limit), nil
+}
+
+// deleteUserBatch 物理删除一批属于该用户的记录，返回删除条数
+func deleteUserBatch(db *gorm.DB, m interface{}, where, uid string, limit int) (int64, error) {
+	sub := db.Unscoped().Model(m).Select("id").Where(where, uid).Limit(limit)
+	res := db.Unscoped().Where("id IN (?)", sub).Delete(m)
+	return res.RowsAffected, res.Error
+}
@@ -12,6 +12,7 @@ import (
 	"github.com/my-chat/relay/internal/model"
 	"github.com/my-chat/relay/internal/storage"
 	"github.com/redis/go-redis/v9"
+	"gorm.io/gorm"
 )
 
 // Service 事件服务
@@ -28,8 +29,14 @@ func NewService(storage *storage.Storage, config conf.RelayConfiguration) *Servi
 	}
 }
 
-// StoreEvent 存储事件
-func (s *Service) StoreEvent(ctx context.Context, event *protocol.Event) (*model.Event, error) {
+// StoreEvent 存储事件，ttl大于0时记录过期时间
+func (s *Service) StoreEvent(ctx context.Context, event *protocol.Event, ttl int64) (*model.Event, error) {
+	return s.StoreEventWith(ctx, event, ttl, nil)
+}
+
+// StoreEventWith 存储事件，并在同一事务中执行 after（如标记定时消息已发送）
+// after 返回错误时事件不会写入
+func (s *Service) StoreEventWith(ctx context.Context, event *protocol.Event, ttl int64, after func(tx *gorm.DB, e *model.Event) error) (*model.Event, error) {
 	// 生成消息ID
 	mid, err := s.generateMid(ctx, event.Cid)
 	if err != nil {
@@ -52,14 +59,112 @@ func (s *Service) StoreEvent(ctx context.Context, event *protocol.Event) (*model
 		Sig:       event.Sig,
 		Timestamp: time.Now().Unix(),
 	}
-
-	if err := s.storage.DB().Create(e).Error; err != nil {
+	e.ExpiresAt = expiresAt(e.Timestamp, ttl)
+
+	err = s.storage.DB().Transaction(func(tx *gorm.DB) error {
+		// 回复消息建立线程索引
+		if replyMid, ok := protocol.GetReplyMid(event.Tags); ok {
+			e.ReplyMid = replyMid
+			e.RootMid = s.resolveThreadRoot(tx, event.Cid, replyMid)
+		}
+
+		if err := tx.Create(e).Error; err != nil {
+			return err
+		}
+		if e.RootMid > 0 {
+			if err := s.subscribeThreadParticipants(tx, e); err != nil {
+				return err
+			}
+		}
+		// 撤销消息需要同时清除目标消息内容
+		if event.Kind == protocol.KindRevoke {
+			if err := s.tombstone(tx, event); err != nil {
+				return err
+			}
+		}
+		if after != nil {
+			return after(tx, e)
+		}
+		return nil
+	})
+	if err != nil {
 		return nil, err
 	}
 
 	return e, nil
 }
 
+// tombstone 将被撤销的消息标记为已撤销，并原地清除其内容
+// 仅处理全体撤销，仅自己删除通过 HideEvent 记录
+func (s *Service) tombstone(tx *gorm.DB, revoke *protocol.Event) error {
+	targetMid, ok := protocol.GetTargetMid(revoke.Tags)
+	if !ok {
+		return errors.ErrInvalidParam
+	}
+
+	scope, reason := revoke.GetRevokeData()
+	if scope != protocol.RevokeScopeAll {
+		return nil
+	}
+
+	updates := map[string]interface{}{
+		"flags":         gorm.Expr("flags | ?", protocol.FlagRevoked),
+		"data":          "{}",
+		"tags":          "[]",
+		"revoked_by":    revoke.Sender,
+		"revoke_reason": reason,
+		"revoked_at":    time.Now(),
+	}
+
+	if err := tx.Model(&model.Event{}).
+		Where("cid = ? AND mid = ?", revoke.Cid, targetMid).
+		Updates(updates).Error; err != nil {
+		return err
+	}
+
+	// 编辑记录中保存了修改后的内容，需要一并清除
+	targetTag, _ := json.Marshal([]protocol.Tag{protocol.NewTargetTag(targetMid)})
+	return tx.Model(&model.Event{}).
+		Where("cid = ? AND kind = ? AND tags @> ?::jsonb", revoke.Cid, protocol.KindEdit, string(targetTag)).
+		Updates(updates).Error
+}
+
+// HideEvent 为用户隐藏消息（仅自己删除）
+func (s *Service) HideEvent(ctx context.Context, cid, uid string, mid int64) error {
+	target, err := s.GetEvent(ctx, mid)
+	if err != nil {
+		return err
+	}
+	if target.Cid != cid {
+		return errors.ErrMessageNotFound
+	}
+
+	hidden := &model.HiddenEvent{
+		Uid: uid,
+		Mid: mid,
+		Cid: cid,
+	}
+	return s.storage.DB().
+		Where("uid = ? AND mid = ?", uid, mid).
+		FirstOrCreate(hidden).Error
+}
+
+// excludeHidden 排除用户自己删除的消息
+func (s *Service) excludeHidden(query *gorm.DB, cid, uid string) *gorm.DB {
+	if uid == "" {
+		return query
+	}
+	hidden := s.storage.DB().Model(&model.HiddenEvent{}).
+		Select("mid").
+		Where("uid = ? AND cid = ?", uid, cid)
+	return query.Where("mid NOT IN (?)", hidden)
+}
+
+// excludeExpired 排除已过期但尚未被清理的消息
+func excludeExpired(query *gorm.DB, now int64) *gorm.DB {
+	return query.Where("expires_at = 0 OR expires_at > ?", now)
+}
+
 // generateMid 生成消息ID（使用Redis自增）
 func (s *Service) generateMid(ctx context.Context, cid string) (int64, error) {
 	key := fmt.Sprintf("mid:%s", cid)
@@ -73,7 +178,8 @@ func (s *Service) generateMid(ctx context.Context, cid string) (int64, error) {
 // GetEvent 获取单条事件
 func (s *Service) GetEvent(ctx context.Context, mid int64) (*model.Event, error) {
 	var event model.Event
-	if err := s.storage.DB().Where("mid = ?", mid).First(&event).Error; err != nil {
+	query := excludeExpired(s.storage.DB().Where("mid = ?", mid), time.Now().Unix())
+	if err := query.First(&event).Error; err != nil {
 		return nil, errors.ErrMessageNotFound
 	}
 	return &event, nil
@@ -82,6 +188,7 @@ func (s *Service) GetEvent(ctx context.Context, mid int64) (*model.Event, error)
 // QueryRequest 查询请求
 type QueryRequest struct {
 	Cid     string `json:"cid"`
+	Uid     string `json:"uid"`      // 查询者UID，用于过滤其自己删除的消息
 	LastMid int64  `json:"last_mid"` // 从这条消息之后查询
 	Before  int64  `json:"before"`   // 时间戳上限
 	After   int64  `json:"after"`    // 时间戳下限
@@ -113,20 +220,25 @@ func (s *Service) QueryEvents(ctx context.Context, req *QueryRequest) ([]model.E
 		query = query.Where("kind IN ?", req.Kinds)
 	}
 
+	query = s.excludeHidden(query, req.Cid, req.Uid)
+	query = excludeExpired(query, time.Now().Unix())
+
 	var events []model.Event
 	err := query.Order("mid ASC").Limit(req.Limit).Find(&events).Error
 	return events, err
 }
 
 // QueryEventsDesc 逆序查询事件（获取最新消息）
-func (s *Service) QueryEventsDesc(ctx context.Context, cid string, limit int) ([]model.Event, error) {
+func (s *Service) QueryEventsDesc(ctx context.Context, cid, uid string, limit int) ([]model.Event, error) {
 	if limit <= 0 || limit > s.config.MaxQueryLimit {
 		limit = s.config.MaxQueryLimit
 	}
 
+	query := s.excludeHidden(s.storage.DB().Where("cid = ?", cid), cid, uid)
+	query = excludeExpired(query, time.Now().Unix())
+
 	var events []model.Event
-	err := s.storage.DB().
-		Where("cid = ?", cid).
+	err := query.
 		Order("mid DESC").
 		Limit(limit).
 		Find(&events).Error
@@ -139,21 +251,6 @@ func (s *Service) QueryEventsDesc(ctx context.Context, cid string, limit int) ([
 	return events, err
 }
 
-// UpdateReadReceipt 更新已读回执
-func (s *Service) UpdateReadReceipt(ctx context.Context, cid, uid string, lastReadMid int64) error {
-	receipt := &model.ReadReceipt{
-		Cid:         cid,
-		Uid:         uid,
-		LastReadMid: lastReadMid,
-		UpdatedAt:   time.Now(),
-	}
-
-	return s.storage.DB().
-		Where("cid = ? AND uid = ?", cid, uid).
-		Assign(receipt).
-		FirstOrCreate(receipt).Error
-}
-
 // GetReadReceipt 获取已读回执
 func (s *Service) GetReadReceipt(ctx context.Context, cid, uid string) (*model.ReadReceipt, error) {
 	var receipt model.ReadReceipt
@@ -1,7 +1,13 @@
 package event
 
 import (
+	"strings"
 	"testing"
+
+	"github.com/my-chat/common/pkg/protocol"
+	"github.com/my-chat/relay/internal/model"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
 )
 
 func TestQueryRequest_Validation(t *testing.T) {
@@ -123,3 +129,193 @@ func TestQueryRequest_TimeRange(t *testing.T) {
 		})
 	}
 }
+
+func TestMessagePolicy_Default(t *testing.T) {
+	p := DefaultMessagePolicy()
+
+	if !p.CanEditKind(protocol.KindText) {
+		t.Error("default policy should allow editing text")
+	}
+	if p.CanEditKind(protocol.KindFile) {
+		t.Error("default policy should not allow editing files")
+	}
+	if !p.AdminRevokeAny {
+		t.Error("default policy should allow admins to revoke any message")
+	}
+}
+
+func TestMessagePolicy_Windows(t *testing.T) {
+	now := int64(10000)
+
+	tests := []struct {
+		name          string
+		policy        MessagePolicy
+		timestamp     int64
+		revokeExpired bool
+		editExpired   bool
+	}{
+		{
+			name:          "within windows",
+			policy:        MessagePolicy{RevokeWindow: 120, EditWindow: 3600},
+			timestamp:     now - 60,
+			revokeExpired: false,
+			editExpired:   false,
+		},
+		{
+			name:          "revoke window exceeded",
+			policy:        MessagePolicy{RevokeWindow: 120, EditWindow: 3600},
+			timestamp:     now - 121,
+			revokeExpired: true,
+			editExpired:   false,
+		},
+		{
+			name:          "zero window means unlimited",
+			policy:        MessagePolicy{RevokeWindow: 0, EditWindow: 0},
+			timestamp:     0,
+			revokeExpired: false,
+			editExpired:   false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.policy.RevokeExpired(tt.timestamp, now); got != tt.revokeExpired {
+				t.Errorf("RevokeExpired() = %v, want %v", got, tt.revokeExpired)
+			}
+			if got := tt.policy.EditExpired(tt.timestamp, now); got != tt.editExpired {
+				t.Errorf("EditExpired() = %v, want %v", got, tt.editExpired)
+			}
+		})
+	}
+}
+
+func TestMessagePolicy_EditForbidden(t *testing.T) {
+	p := MessagePolicy{EditableKinds: []int{}}
+	if p.CanEditKind(protocol.KindText) {
+		t.Error("empty editable kinds should forbid edits")
+	}
+}
+
+func TestThreadRoots(t *testing.T) {
+	events := []model.Event{
+		{Mid: 1},
+		{Mid: 2, ReplyMid: 1, RootMid: 1},
+		{Mid: 3, ReplyMid: 2, RootMid: 1},
+		{Mid: 4},
+	}
+
+	roots := threadRoots(events)
+	want := []int64{1, 4}
+	if len(roots) != len(want) {
+		t.Fatalf("threadRoots() = %v, want %v", roots, want)
+	}
+	for i := range want {
+		if roots[i] != want[i] {
+			t.Errorf("threadRoots()[%d] = %d, want %d", i, roots[i], want[i])
+		}
+	}
+}
+
+func TestExpiresAt(t *testing.T) {
+	tests := []struct {
+		name      string
+		timestamp int64
+		ttl       int64
+		want      int64
+	}{
+		{"no ttl", 1700000000, 0, 0},
+		{"negative ttl", 1700000000, -10, 0},
+		{"one day", 1700000000, 86400, 1700086400},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := expiresAt(tt.timestamp, tt.ttl); got != tt.want {
+				t.Errorf("expiresAt(%d, %d) = %d, want %d", tt.timestamp, tt.ttl, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBuildSummaries(t *testing.T) {
+	cids := []string{"d:a:b", "g:1", "g:2"}
+	lastEvents := []model.Event{{Cid: "g:1", Mid: 9}, {Cid: "unknown", Mid: 3}}
+	receipts := []model.ReadReceipt{{Cid: "g:1", LastReadMid: 5}}
+	unread := []unreadCount{{Cid: "g:1", Count: 4}, {Cid: "d:a:b", Count: 1}}
+
+	summaries := buildSummaries(cids, lastEvents, receipts, unread)
+	if len(summaries) != len(cids) {
+		t.Fatalf("len(summaries) = %d, want %d", len(summaries), len(cids))
+	}
+	for i, cid := range cids {
+		if summaries[i].Cid != cid {
+			t.Errorf("summaries[%d].Cid = %s, want %s", i, summaries[i].Cid, cid)
+		}
+	}
+
+	g1 := summaries[1]
+	if g1.LastEvent == nil || g1.LastEvent.Mid != 9 {
+		t.Errorf("g:1 last event = %v, want mid 9", g1.LastEvent)
+	}
+	if g1.LastReadMid != 5 || g1.UnreadCount != 4 {
+		t.Errorf("g:1 last_read_mid = %d, unread = %d, want 5, 4", g1.LastReadMid, g1.UnreadCount)
+	}
+	if summaries[0].UnreadCount != 1 || summaries[0].LastEvent != nil {
+		t.Errorf("d:a:b summary = %+v", summaries[0])
+	}
+	if summaries[2].UnreadCount != 0 || summaries[2].LastEvent != nil {
+		t.Errorf("g:2 summary = %+v", summaries[2])
+	}
+}
+
+func TestSummaryQueriesExcludeHidden(t *testing.T) {
+	db, err := gorm.Open(postgres.New(postgres.Config{DSN: "host=localhost"}), &gorm.Config{
+		DryRun:               true,
+		DisableAutomaticPing: true,
+	})
+	if err != nil {
+		t.Fatalf("gorm.Open failed: %v", err)
+	}
+	cids := []string{"g:1", "g:2"}
+
+	last := db.ToSQL(func(tx *gorm.DB) *gorm.DB {
+		var events []model.Event
+		return lastEventsQuery(tx, "alice", cids, 1700000000).Find(&events)
+	})
+	unread := db.ToSQL(func(tx *gorm.DB) *gorm.DB {
+		var counts []unreadCount
+		return unreadQuery(tx, "alice", cids, 1700000000).Scan(&counts)
+	})
+
+	// 最后一条消息和未读数都排除用户隐藏的消息，且隐藏记录按会话关联（mid 只在会话内唯一）
+	tests := []struct {
+		name string
+		sql  string
+		want string
+	}{
+		{"last event", last, "h.uid = 'alice' AND h.cid = events.cid AND h.mid = events.mid"},
+		{"unread count", unread, "h.uid = 'alice' AND h.cid = e.cid AND h.mid = e.mid"},
+	}
+	for _, tt := range tests {
+		if !strings.Contains(tt.sql, tt.want) {
+			t.Errorf("%s query does not exclude hidden events: %s", tt.name, tt.sql)
+		}
+	}
+}
+
+func TestReadWindowStart(t *testing.T) {
+	tests := []struct {
+		prevMid, lastReadMid, want int64
+	}{
+		{0, 10, 0},
+		{5, 10, 5},
+		{0, 500, 500 - readNotifyWindow},
+		{450, 500, 450},
+	}
+
+	for _, tt := range tests {
+		if got := readWindowStart(tt.prevMid, tt.lastReadMid); got != tt.want {
+			t.Errorf("readWindowStart(%d, %d) = %d, want %d", tt.prevMid, tt.lastReadMid, got, tt.want)
+		}
+	}
+}
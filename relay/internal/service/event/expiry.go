@@ -0,0 +1,74 @@
+package event
+
+import (
+	"context"
+	"time"
+
+	"github.com/my-chat/common/pkg/log"
+	"github.com/my-chat/relay/internal/model"
+	"gorm.io/gorm"
+)
+
+// purgeBatchSize 每批物理删除的过期事件数
+const purgeBatchSize = 500
+
+// expiresAt 根据存储时间和ttl计算过期时间，ttl不大于0表示不过期
+func expiresAt(timestamp, ttl int64) int64 {
+	if ttl <= 0 {
+		return 0
+	}
+	return timestamp + ttl
+}
+
+// RunExpiryPurge 定时清理过期事件，阻塞运行
+func (s *Service) RunExpiryPurge(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		for {
+			n, err := s.PurgeExpired(context.Background(), purgeBatchSize)
+			if err != nil {
+				log.Error().Err(err).Msg("failed to purge expired events")
+				break
+			}
+			if n > 0 {
+				log.Debug().Int("count", n).Msg("purged expired events")
+			}
+			if n < purgeBatchSize {
+				break
+			}
+		}
+	}
+}
+
+// PurgeExpired 物理删除一批已过期的事件及其关联数据，返回删除的事件数
+func (s *Service) PurgeExpired(ctx context.Context, limit int) (int, error) {
+	var mids []int64
+	err := s.storage.DB().Unscoped().Model(&model.Event{}).
+		Where("expires_at > 0 AND expires_at <= ?", time.Now().Unix()).
+		Order("expires_at ASC").
+		Limit(limit).
+		Pluck("mid", &mids).Error
+	if err != nil || len(mids) == 0 {
+		return 0, err
+	}
+
+	err = s.storage.DB().Transaction(func(tx *gorm.DB) error {
+		if err := tx.Unscoped().Where("mid IN ?", mids).Delete(&model.Reaction{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("mid IN ?", mids).Delete(&model.HiddenEvent{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("root_mid IN ?", mids).Delete(&model.ThreadSubscription{}).Error; err != nil {
+			return err
+		}
+		return tx.Unscoped().Where("mid IN ?", mids).Delete(&model.Event{}).Error
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return len(mids), nil
+}
@@ -0,0 +1,111 @@
+package event
+
+import (
+	"context"
+	"time"
+
+	"github.com/my-chat/common/pkg/protocol"
+	"github.com/my-chat/relay/internal/model"
+	"gorm.io/gorm"
+)
+
+// nonInboxKinds 不作为会话列表最后一条消息、也不计入未读数的消息类型
+var nonInboxKinds = []int{
+	protocol.KindRevoke,
+	protocol.KindEdit,
+	protocol.KindReadReceipt,
+	protocol.KindTyping,
+	protocol.KindReaction,
+}
+
+// ConversationSummary 会话列表摘要
+type ConversationSummary struct {
+	Cid         string       `json:"cid"`
+	LastEvent   *model.Event `json:"last_event,omitempty"` // 最后一条可展示的消息
+	LastReadMid int64        `json:"last_read_mid"`        // 用户已读水位
+	UnreadCount int64        `json:"unread_count"`         // 已读水位之后他人发送的消息数
+}
+
+// unreadCount 会话未读数统计行
+type unreadCount struct {
+	Cid   string
+	Count int64
+}
+
+// GetConversationSummaries 批量获取用户在多个会话中的最后消息和未读数
+func (s *Service) GetConversationSummaries(ctx context.Context, uid string, cids []string) ([]ConversationSummary, error) {
+	if len(cids) == 0 {
+		return []ConversationSummary{}, nil
+	}
+	now := time.Now().Unix()
+
+	// 每个会话的最后一条消息
+	var lastEvents []model.Event
+	if err := lastEventsQuery(s.storage.DB(), uid, cids, now).Find(&lastEvents).Error; err != nil {
+		return nil, err
+	}
+
+	// 已读水位
+	var receipts []model.ReadReceipt
+	if err := s.storage.DB().Where("uid = ? AND cid IN ?", uid, cids).Find(&receipts).Error; err != nil {
+		return nil, err
+	}
+
+	// 未读数
+	var unread []unreadCount
+	if err := unreadQuery(s.storage.DB(), uid, cids, now).Scan(&unread).Error; err != nil {
+		return nil, err
+	}
+
+	return buildSummaries(cids, lastEvents, receipts, unread), nil
+}
+
+// excludeHiddenIn 排除用户自己删除的消息（多会话查询，按会话和消息ID关联），table 为事件表在查询中的名称
+func excludeHiddenIn(query *gorm.DB, table, uid string) *gorm.DB {
+	return query.Where("NOT EXISTS (SELECT 1 FROM hidden_events h WHERE h.uid = ? AND h.cid = "+table+".cid AND h.mid = "+table+".mid)", uid)
+}
+
+// lastEventsQuery 查询每个会话最后一条可展示的消息
+func lastEventsQuery(db *gorm.DB, uid string, cids []string, now int64) *gorm.DB {
+	query := excludeExpired(db.Model(&model.Event{}), now).
+		Select("DISTINCT ON (cid) *").
+		Where("cid IN ? AND kind NOT IN ?", cids, nonInboxKinds)
+	return excludeHiddenIn(query, "events", uid).Order("cid, mid DESC")
+}
+
+// unreadQuery 统计每个会话已读水位之后他人发送的消息数，与最后一条消息使用相同的过滤条件
+func unreadQuery(db *gorm.DB, uid string, cids []string, now int64) *gorm.DB {
+	query := excludeExpired(db.Table("events AS e"), now).
+		Select("e.cid, count(*) AS count").
+		Joins("LEFT JOIN read_receipts r ON r.cid = e.cid AND r.uid = ?", uid).
+		Where("e.deleted_at IS NULL AND e.cid IN ? AND e.sender <> ? AND e.kind NOT IN ?", cids, uid, nonInboxKinds).
+		Where("e.mid > COALESCE(r.last_read_mid, 0) AND e.flags & ? = 0", protocol.FlagRevoked)
+	return excludeHiddenIn(query, "e", uid).Group("e.cid")
+}
+
+// buildSummaries 按请求顺序组装会话摘要
+func buildSummaries(cids []string, lastEvents []model.Event, receipts []model.ReadReceipt, unread []unreadCount) []ConversationSummary {
+	summaries := make(map[string]*ConversationSummary, len(cids))
+	result := make([]ConversationSummary, len(cids))
+	for i, cid := range cids {
+		result[i].Cid = cid
+		summaries[cid] = &result[i]
+	}
+
+	for i := range lastEvents {
+		if summary, ok := summaries[lastEvents[i].Cid]; ok {
+			summary.LastEvent = &lastEvents[i]
+		}
+	}
+	for _, r := range receipts {
+		if summary, ok := summaries[r.Cid]; ok {
+			summary.LastReadMid = r.LastReadMid
+		}
+	}
+	for _, u := range unread {
+		if summary, ok := summaries[u.Cid]; ok {
+			summary.UnreadCount = u.Count
+		}
+	}
+	return result
+}
@@ -0,0 +1,41 @@
+package event
+
+import "github.com/my-chat/common/pkg/protocol"
+
+// MessagePolicy 会话消息策略（由Gateway从SeaKing获取后随校验请求传入）
+type MessagePolicy struct {
+	RevokeWindow   int64 `json:"revoke_window"`    // 撤销时间窗口（秒），0=不限制
+	EditWindow     int64 `json:"edit_window"`      // 编辑时间窗口（秒），0=不限制
+	EditableKinds  []int `json:"editable_kinds"`   // 可编辑的消息类型，为空则禁止编辑
+	AdminRevokeAny bool  `json:"admin_revoke_any"` // 管理员是否可撤销任何人的消息
+}
+
+// DefaultMessagePolicy 未传入策略时的默认规则：2分钟内可撤销，24小时内可编辑文本
+func DefaultMessagePolicy() *MessagePolicy {
+	return &MessagePolicy{
+		RevokeWindow:   2 * 60,
+		EditWindow:     24 * 60 * 60,
+		EditableKinds:  []int{protocol.KindText},
+		AdminRevokeAny: true,
+	}
+}
+
+// RevokeExpired 判断消息是否已超出撤销时间窗口
+func (p *MessagePolicy) RevokeExpired(timestamp, now int64) bool {
+	return p.RevokeWindow > 0 && now-timestamp > p.RevokeWindow
+}
+
+// EditExpired 判断消息是否已超出编辑时间窗口
+func (p *MessagePolicy) EditExpired(timestamp, now int64) bool {
+	return p.EditWindow > 0 && now-timestamp > p.EditWindow
+}
+
+// CanEditKind 判断消息类型是否可编辑
+func (p *MessagePolicy) CanEditKind(kind int) bool {
+	for _, k := range p.EditableKinds {
+		if k == kind {
+			return true
+		}
+	}
+	return false
+}
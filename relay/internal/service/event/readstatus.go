@@ -0,0 +1,125 @@
+package event
+
+import (
+	"context"
+	"time"
+
+	"github.com/my-chat/common/pkg/protocol"
+	"github.com/my-chat/relay/internal/model"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// readNotifyWindow 已读/送达水位前进时，仅通知最近这么多条消息的发送者
+const readNotifyWindow = 100
+
+// UpdateReadReceipt 更新已读回执（水位只前进不后退），返回因此新被读到的近期消息的发送者
+func (s *Service) UpdateReadReceipt(ctx context.Context, cid, uid string, lastReadMid int64) ([]string, error) {
+	var prevMid int64
+	err := s.storage.DB().Transaction(func(tx *gorm.DB) error {
+		var receipt model.ReadReceipt
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("cid = ? AND uid = ?", cid, uid).
+			First(&receipt).Error
+		if err == gorm.ErrRecordNotFound {
+			return tx.Create(&model.ReadReceipt{
+				Cid:         cid,
+				Uid:         uid,
+				LastReadMid: lastReadMid,
+				UpdatedAt:   time.Now(),
+			}).Error
+		}
+		if err != nil {
+			return err
+		}
+
+		prevMid = receipt.LastReadMid
+		if lastReadMid <= prevMid {
+			return nil
+		}
+		return tx.Model(&receipt).Updates(map[string]interface{}{
+			"last_read_mid": lastReadMid,
+			"updated_at":    time.Now(),
+		}).Error
+	})
+	if err != nil || lastReadMid <= prevMid {
+		return nil, err
+	}
+
+	return s.readSenders(cid, uid, readWindowStart(prevMid, lastReadMid), lastReadMid)
+}
+
+// readWindowStart 计算需要通知的消息区间起点（不含），只回溯最近 readNotifyWindow 条
+func readWindowStart(prevMid, lastReadMid int64) int64 {
+	if start := lastReadMid - readNotifyWindow; start > prevMid {
+		return start
+	}
+	return prevMid
+}
+
+// readSenders 获取 (fromMid, toMid] 区间内他人发送的消息的发送者
+func (s *Service) readSenders(cid, reader string, fromMid, toMid int64) ([]string, error) {
+	var senders []string
+	err := s.storage.DB().Model(&model.Event{}).
+		Distinct("sender").
+		Where("cid = ? AND mid > ? AND mid <= ? AND sender <> ?", cid, fromMid, toMid, reader).
+		Where("kind NOT IN ? AND flags & ? = 0", nonInboxKinds, protocol.FlagRevoked).
+		Pluck("sender", &senders).Error
+	return senders, err
+}
+
+// UpdateDeliveryReceipt 更新送达回执（水位只前进不后退），返回因此新送达的近期消息的发送者
+func (s *Service) UpdateDeliveryReceipt(ctx context.Context, cid, uid string, lastDeliveredMid int64) ([]string, error) {
+	var prevMid int64
+	err := s.storage.DB().Transaction(func(tx *gorm.DB) error {
+		var receipt model.DeliveryReceipt
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("cid = ? AND uid = ?", cid, uid).
+			First(&receipt).Error
+		if err == gorm.ErrRecordNotFound {
+			return tx.Create(&model.DeliveryReceipt{
+				Cid:              cid,
+				Uid:              uid,
+				LastDeliveredMid: lastDeliveredMid,
+				UpdatedAt:        time.Now(),
+			}).Error
+		}
+		if err != nil {
+			return err
+		}
+
+		prevMid = receipt.LastDeliveredMid
+		if lastDeliveredMid <= prevMid {
+			return nil
+		}
+		return tx.Model(&receipt).Updates(map[string]interface{}{
+			"last_delivered_mid": lastDeliveredMid,
+			"updated_at":         time.Now(),
+		}).Error
+	})
+	if err != nil || lastDeliveredMid <= prevMid {
+		return nil, err
+	}
+
+	return s.readSenders(cid, uid, readWindowStart(prevMid, lastDeliveredMid), lastDeliveredMid)
+}
+
+// GetDeliveryStatus 获取已送达指定消息的用户（已读也视为已送达）
+func (s *Service) GetDeliveryStatus(ctx context.Context, cid string, mid int64) ([]string, error) {
+	var uids []string
+	err := s.storage.DB().Raw(
+		"SELECT uid FROM delivery_receipts WHERE cid = ? AND last_delivered_mid >= ? "+
+			"UNION SELECT uid FROM read_receipts WHERE cid = ? AND last_read_mid >= ?",
+		cid, mid, cid, mid,
+	).Scan(&uids).Error
+	return uids, err
+}
+
+// GetReadStatus 获取已读到指定消息的用户
+func (s *Service) GetReadStatus(ctx context.Context, cid string, mid int64) ([]string, error) {
+	var uids []string
+	err := s.storage.DB().Model(&model.ReadReceipt{}).
+		Where("cid = ? AND last_read_mid >= ?", cid, mid).
+		Pluck("uid", &uids).Error
+	return uids, err
+}
@@ -0,0 +1,190 @@
+package event
+
+import (
+	"context"
+	"time"
+
+	"github.com/my-chat/common/pkg/protocol"
+	"github.com/my-chat/relay/internal/model"
+	"gorm.io/gorm"
+)
+
+// ThreadSummary 线程摘要
+type ThreadSummary struct {
+	RootMid      int64    `json:"root_mid"`
+	ReplyCount   int64    `json:"reply_count"`
+	LastReplyMid int64    `json:"last_reply_mid"`
+	LastReplyAt  int64    `json:"last_reply_at"`
+	Participants []string `json:"participants"`
+}
+
+// resolveThreadRoot 查找回复所属线程的根消息，回复的回复归入同一线程
+func (s *Service) resolveThreadRoot(tx *gorm.DB, cid string, replyMid int64) int64 {
+	var target model.Event
+	err := tx.Select("mid", "root_mid").
+		Where("cid = ? AND mid = ?", cid, replyMid).
+		First(&target).Error
+	if err != nil || target.RootMid == 0 {
+		return replyMid
+	}
+	return target.RootMid
+}
+
+// subscribeThreadParticipants 回复时自动为回复者和根消息发送者订阅线程
+func (s *Service) subscribeThreadParticipants(tx *gorm.DB, e *model.Event) error {
+	uids := []string{e.Sender}
+
+	var root model.Event
+	if err := tx.Select("sender").Where("cid = ? AND mid = ?", e.Cid, e.RootMid).First(&root).Error; err == nil {
+		uids = append(uids, root.Sender)
+	}
+
+	for _, uid := range uids {
+		sub := &model.ThreadSubscription{
+			Cid:     e.Cid,
+			RootMid: e.RootMid,
+			Uid:     uid,
+		}
+		// 已退订的用户保持退订状态
+		if err := tx.Where("cid = ? AND root_mid = ? AND uid = ?", e.Cid, e.RootMid, uid).
+			FirstOrCreate(sub).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// GetThread 分页获取线程回复（按mid升序，cursor为上一页最后一条mid）
+func (s *Service) GetThread(ctx context.Context, cid, uid string, rootMid, cursor int64, limit int) ([]model.Event, error) {
+	if limit <= 0 || limit > s.config.MaxQueryLimit {
+		limit = s.config.MaxQueryLimit
+	}
+
+	query := s.storage.DB().Where("cid = ? AND root_mid = ?", cid, rootMid)
+	if cursor > 0 {
+		query = query.Where("mid > ?", cursor)
+	}
+	query = s.excludeHidden(query, cid, uid)
+	query = excludeExpired(query, time.Now().Unix())
+
+	var events []model.Event
+	err := query.Order("mid ASC").Limit(limit).Find(&events).Error
+	return events, err
+}
+
+// GetThreadSummaries 批量获取线程摘要（不计已撤销和已过期的回复），没有回复的根消息不返回
+func (s *Service) GetThreadSummaries(ctx context.Context, cid string, rootMids []int64) ([]ThreadSummary, error) {
+	if len(rootMids) == 0 {
+		return []ThreadSummary{}, nil
+	}
+
+	now := time.Now().Unix()
+
+	var stats []struct {
+		RootMid      int64
+		ReplyCount   int64
+		LastReplyMid int64
+		LastReplyAt  int64
+	}
+	err := excludeExpired(s.storage.DB().Model(&model.Event{}), now).
+		Select("root_mid, count(*) as reply_count, max(mid) as last_reply_mid, max(timestamp) as last_reply_at").
+		Where("cid = ? AND root_mid IN ? AND flags & ? = 0", cid, rootMids, protocol.FlagRevoked).
+		Group("root_mid").
+		Scan(&stats).Error
+	if err != nil {
+		return nil, err
+	}
+
+	var senders []struct {
+		RootMid int64
+		Sender  string
+	}
+	err = excludeExpired(s.storage.DB().Model(&model.Event{}), now).
+		Distinct("root_mid", "sender").
+		Where("cid = ? AND root_mid IN ? AND flags & ? = 0", cid, rootMids, protocol.FlagRevoked).
+		Scan(&senders).Error
+	if err != nil {
+		return nil, err
+	}
+
+	participants := make(map[int64][]string)
+	for _, row := range senders {
+		participants[row.RootMid] = append(participants[row.RootMid], row.Sender)
+	}
+
+	summaries := make([]ThreadSummary, 0, len(stats))
+	for _, st := range stats {
+		summaries = append(summaries, ThreadSummary{
+			RootMid:      st.RootMid,
+			ReplyCount:   st.ReplyCount,
+			LastReplyMid: st.LastReplyMid,
+			LastReplyAt:  st.LastReplyAt,
+			Participants: participants[st.RootMid],
+		})
+	}
+	return summaries, nil
+}
+
+// GetThreadSummariesForEvents 获取事件列表中涉及的线程摘要（用于同步结果）
+func (s *Service) GetThreadSummariesForEvents(ctx context.Context, cid string, events []model.Event) ([]ThreadSummary, error) {
+	return s.GetThreadSummaries(ctx, cid, threadRoots(events))
+}
+
+// threadRoots 收集事件列表中可能拥有线程的根消息ID（去重）
+func threadRoots(events []model.Event) []int64 {
+	seen := make(map[int64]bool)
+	roots := make([]int64, 0)
+	for _, e := range events {
+		mid := e.Mid
+		if e.RootMid > 0 {
+			mid = e.RootMid
+		}
+		if !seen[mid] {
+			seen[mid] = true
+			roots = append(roots, mid)
+		}
+	}
+	return roots
+}
+
+// SubscribeThread 订阅线程
+func (s *Service) SubscribeThread(ctx context.Context, cid, uid string, rootMid int64) error {
+	sub := &model.ThreadSubscription{
+		Cid:     cid,
+		RootMid: rootMid,
+		Uid:     uid,
+	}
+	return s.storage.DB().
+		Where("cid = ? AND root_mid = ? AND uid = ?", cid, rootMid, uid).
+		Assign(map[string]interface{}{"muted": false}).
+		FirstOrCreate(sub).Error
+}
+
+// UnsubscribeThread 退订线程（保留记录，避免再次回复时被自动订阅）
+func (s *Service) UnsubscribeThread(ctx context.Context, cid, uid string, rootMid int64) error {
+	sub := &model.ThreadSubscription{
+		Cid:     cid,
+		RootMid: rootMid,
+		Uid:     uid,
+	}
+	return s.storage.DB().
+		Where("cid = ? AND root_mid = ? AND uid = ?", cid, rootMid, uid).
+		Assign(map[string]interface{}{"muted": true}).
+		FirstOrCreate(sub).Error
+}
+
+// ClearThreadSubscriptions 删除用户在会话中的全部线程订阅（退出或被移出群组时使用）
+func (s *Service) ClearThreadSubscriptions(ctx context.Context, cid, uid string) error {
+	return s.storage.DB().
+		Where("cid = ? AND uid = ?", cid, uid).
+		Delete(&model.ThreadSubscription{}).Error
+}
+
+// GetThreadSubscribers 获取线程订阅者
+func (s *Service) GetThreadSubscribers(ctx context.Context, cid string, rootMid int64) ([]string, error) {
+	var uids []string
+	err := s.storage.DB().Model(&model.ThreadSubscription{}).
+		Where("cid = ? AND root_mid = ? AND muted = ?", cid, rootMid, false).
+		Pluck("uid", &uids).Error
+	return uids, err
+}
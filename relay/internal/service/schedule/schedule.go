@@ -0,0 +1,314 @@
+package schedule
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/my-chat/common/pkg/errors"
+	"github.com/my-chat/common/pkg/protocol"
+	"github.com/my-chat/relay/internal/model"
+	"github.com/my-chat/relay/internal/storage"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	// MaxScheduleAhead 最长可提前多久定时发送
+	MaxScheduleAhead = 365 * 24 * time.Hour
+	// ProcessingTimeout 领取后超过该时间未完成视为worker异常，可被重新领取
+	ProcessingTimeout = 5 * time.Minute
+)
+
+// errScheduledNotProcessing 定时消息已不在领取状态（已发送、已取消或已失败）
+var errScheduledNotProcessing = errors.New(errors.ErrCodeForbidden, "scheduled message is no longer processing")
+
+// Service 定时消息服务
+type Service struct {
+	storage *storage.Storage
+}
+
+// NewService 创建定时消息服务
+func NewService(storage *storage.Storage) *Service {
+	return &Service{storage: storage}
+}
+
+// ScheduledView 定时消息返回结构（事件内容还原为协议格式）
+type ScheduledView struct {
+	ID          uint            `json:"id"`
+	ScheduledAt int64           `json:"scheduled_at"`
+	Status      int             `json:"status"`
+	Mid         int64           `json:"mid,omitempty"`
+	FailReason  string          `json:"fail_reason,omitempty"`
+	Event       *protocol.Event `json:"event"`
+}
+
+// ScheduleRequest 创建定时消息请求
+type ScheduleRequest struct {
+	Event       *protocol.Event `json:"event"`
+	ScheduledAt int64           `json:"scheduled_at"`
+}
+
+// Schedule 创建定时消息
+func (s *Service) Schedule(ctx context.Context, req *ScheduleRequest) (*ScheduledView, error) {
+	if req.Event == nil || req.Event.Cid == "" || req.Event.Sender == "" {
+		return nil, errors.ErrInvalidParam
+	}
+	if err := validateScheduledAt(req.ScheduledAt, time.Now()); err != nil {
+		return nil, err
+	}
+
+	tagsJSON, _ := json.Marshal(req.Event.Tags)
+	dataJSON, _ := json.Marshal(req.Event.Data)
+
+	se := &model.ScheduledEvent{
+		Cid:         req.Event.Cid,
+		Sender:      req.Event.Sender,
+		Kind:        req.Event.Kind,
+		Tags:        string(tagsJSON),
+		Data:        string(dataJSON),
+		Flags:       req.Event.Flags,
+		Sig:         req.Event.Sig,
+		ScheduledAt: req.ScheduledAt,
+		Status:      model.ScheduledStatusPending,
+	}
+	if err := s.storage.DB().Create(se).Error; err != nil {
+		return nil, err
+	}
+
+	return toView(se), nil
+}
+
+// List 获取用户的定时消息（cid为空时返回全部会话）
+func (s *Service) List(ctx context.Context, sender, cid string, includeDone bool) ([]*ScheduledView, error) {
+	query := s.storage.DB().Where("sender = ?", sender)
+	if cid != "" {
+		query = query.Where("cid = ?", cid)
+	}
+	if !includeDone {
+		query = query.Where("status IN ?", []int{model.ScheduledStatusPending, model.ScheduledStatusProcessing})
+	}
+
+	var entries []model.ScheduledEvent
+	if err := query.Order("scheduled_at ASC").Find(&entries).Error; err != nil {
+		return nil, err
+	}
+
+	views := make([]*ScheduledView, 0, len(entries))
+	for i := range entries {
+		views = append(views, toView(&entries[i]))
+	}
+	return views, nil
+}
+
+// UpdateRequest 修改定时消息请求
+type UpdateRequest struct {
+	ID          uint            `json:"id"`
+	Sender      string          `json:"sender"`
+	Event       *protocol.Event `json:"event,omitempty"` // 为空则不修改内容
+	ScheduledAt int64           `json:"scheduled_at"`    // 为0则不修改时间
+}
+
+// Update 修改等待发送的定时消息
+func (s *Service) Update(ctx context.Context, req *UpdateRequest) (*ScheduledView, error) {
+	updates := map[string]interface{}{}
+	if req.Event != nil {
+		tagsJSON, _ := json.Marshal(req.Event.Tags)
+		dataJSON, _ := json.Marshal(req.Event.Data)
+		updates["kind"] = req.Event.Kind
+		updates["tags"] = string(tagsJSON)
+		updates["data"] = string(dataJSON)
+		updates["flags"] = req.Event.Flags
+		updates["sig"] = req.Event.Sig
+	}
+	if req.ScheduledAt != 0 {
+		if err := validateScheduledAt(req.ScheduledAt, time.Now()); err != nil {
+			return nil, err
+		}
+		updates["scheduled_at"] = req.ScheduledAt
+	}
+	if len(updates) == 0 {
+		return nil, errors.ErrInvalidParam
+	}
+
+	var se model.ScheduledEvent
+	err := s.storage.DB().Transaction(func(tx *gorm.DB) error {
+		if err := s.lockPending(tx, req.ID, req.Sender, &se); err != nil {
+			return err
+		}
+		if err := tx.Model(&se).Updates(updates).Error; err != nil {
+			return err
+		}
+		return tx.First(&se, "id = ?", req.ID).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return toView(&se), nil
+}
+
+// Cancel 取消等待发送的定时消息
+func (s *Service) Cancel(ctx context.Context, id uint, sender string) (*ScheduledView, error) {
+	var se model.ScheduledEvent
+	err := s.storage.DB().Transaction(func(tx *gorm.DB) error {
+		if err := s.lockPending(tx, id, sender, &se); err != nil {
+			return err
+		}
+		return tx.Model(&se).Update("status", model.ScheduledStatusCancelled).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	se.Status = model.ScheduledStatusCancelled
+	return toView(&se), nil
+}
+
+// CancelBySender 取消用户所有等待发送的定时消息（账号注销时使用），返回取消的条数
+func (s *Service) CancelBySender(ctx context.Context, sender string) (int64, error) {
+	res := s.storage.DB().Model(&model.ScheduledEvent{}).
+		Where("sender = ? AND status = ?", sender, model.ScheduledStatusPending).
+		Update("status", model.ScheduledStatusCancelled)
+	return res.RowsAffected, res.Error
+}
+
+// lockPending 锁定用户自己的、尚未发送的定时消息
+func (s *Service) lockPending(tx *gorm.DB, id uint, sender string, se *model.ScheduledEvent) error {
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("id = ? AND sender = ?", id, sender).
+		First(se).Error
+	if err != nil {
+		return errors.ErrNotFound
+	}
+	if se.Status != model.ScheduledStatusPending {
+		return errors.New(errors.ErrCodeForbidden, "scheduled message is no longer pending")
+	}
+	return nil
+}
+
+// ClaimDue 领取到期的定时消息（多个worker并发领取互不重复）
+func (s *Service) ClaimDue(ctx context.Context, limit int) ([]*ScheduledView, error) {
+	if limit <= 0 {
+		limit = 100
+	}
+	now := time.Now()
+
+	due := s.storage.DB().Model(&model.ScheduledEvent{}).
+		Select("id").
+		Where("(status = ? AND scheduled_at <= ?) OR (status = ? AND updated_at < ?)",
+			model.ScheduledStatusPending, now.Unix(),
+			model.ScheduledStatusProcessing, now.Add(-ProcessingTimeout)).
+		Order("scheduled_at ASC").
+		Limit(limit).
+		Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"})
+
+	var claimed []model.ScheduledEvent
+	err := s.storage.DB().Model(&claimed).
+		Clauses(clause.Returning{}).
+		Where("id IN (?)", due).
+		Updates(map[string]interface{}{
+			"status":     model.ScheduledStatusProcessing,
+			"updated_at": now,
+		}).Error
+	if err != nil {
+		return nil, err
+	}
+
+	views := make([]*ScheduledView, 0, len(claimed))
+	for i := range claimed {
+		views = append(views, toView(&claimed[i]))
+	}
+	return views, nil
+}
+
+// GetForSend 获取领取中的定时消息用于发送；已发送的返回其状态和消息ID，调用方据此避免重复发送
+func (s *Service) GetForSend(ctx context.Context, id uint) (*ScheduledView, error) {
+	var se model.ScheduledEvent
+	if err := s.storage.DB().First(&se, "id = ?", id).Error; err != nil {
+		return nil, errors.ErrNotFound
+	}
+	if se.Status != model.ScheduledStatusProcessing && se.Status != model.ScheduledStatusSent {
+		return nil, errScheduledNotProcessing
+	}
+	return toView(&se), nil
+}
+
+// MarkSentTx 在存储事件的事务中标记定时消息已发送
+// 只更新领取中的记录，并发重试时仅第一个事务成功，其余回滚不会重复存储
+func (s *Service) MarkSentTx(tx *gorm.DB, id uint, mid int64) error {
+	res := tx.Model(&model.ScheduledEvent{}).
+		Where("id = ? AND status = ?", id, model.ScheduledStatusProcessing).
+		Updates(map[string]interface{}{
+			"status": model.ScheduledStatusSent,
+			"mid":    mid,
+		})
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return errScheduledNotProcessing
+	}
+	return nil
+}
+
+// Complete 记录定时消息的发送结果，reason非空表示发送失败
+func (s *Service) Complete(ctx context.Context, id uint, mid int64, reason string) error {
+	updates := map[string]interface{}{
+		"status": model.ScheduledStatusSent,
+		"mid":    mid,
+	}
+	if reason != "" {
+		updates = map[string]interface{}{
+			"status":      model.ScheduledStatusFailed,
+			"fail_reason": reason,
+		}
+	}
+
+	return s.storage.DB().Model(&model.ScheduledEvent{}).
+		Where("id = ? AND status = ?", id, model.ScheduledStatusProcessing).
+		Updates(updates).Error
+}
+
+// Defer 将领取中的定时消息放回等待状态并修改计划发送时间（不校验提前时间上限）
+func (s *Service) Defer(ctx context.Context, id uint, scheduledAt int64) error {
+	if scheduledAt <= 0 {
+		return errors.ErrInvalidParam
+	}
+
+	return s.storage.DB().Model(&model.ScheduledEvent{}).
+		Where("id = ? AND status = ?", id, model.ScheduledStatusProcessing).
+		Updates(map[string]interface{}{
+			"status":       model.ScheduledStatusPending,
+			"scheduled_at": scheduledAt,
+		}).Error
+}
+
+// validateScheduledAt 校验计划发送时间必须在未来且不超过最长提前时间
+func validateScheduledAt(scheduledAt int64, now time.Time) error {
+	if scheduledAt <= now.Unix() {
+		return errors.New(errors.ErrCodeInvalidParam, "scheduled time must be in the future")
+	}
+	if scheduledAt > now.Add(MaxScheduleAhead).Unix() {
+		return errors.New(errors.ErrCodeInvalidParam, "scheduled time is too far in the future")
+	}
+	return nil
+}
+
+// toView 将存储模型还原为协议事件
+func toView(se *model.ScheduledEvent) *ScheduledView {
+	event := protocol.NewEvent(se.Kind, se.Cid, se.Sender)
+	event.Flags = se.Flags
+	event.Sig = se.Sig
+	_ = json.Unmarshal([]byte(se.Tags), &event.Tags)
+	_ = json.Unmarshal([]byte(se.Data), &event.Data)
+
+	return &ScheduledView{
+		ID:          se.ID,
+		ScheduledAt: se.ScheduledAt,
+		Status:      se.Status,
+		Mid:         se.Mid,
+		FailReason:  se.FailReason,
+		Event:       event,
+	}
+}
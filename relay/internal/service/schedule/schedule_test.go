@@ -0,0 +1,68 @@
+package schedule
+
+import (
+	"testing"
+	"time"
+
+	"github.com/my-chat/common/pkg/protocol"
+	"github.com/my-chat/relay/internal/model"
+)
+
+func TestValidateScheduledAt(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+
+	tests := []struct {
+		name        string
+		scheduledAt int64
+		wantErr     bool
+	}{
+		{"in the past", now.Unix() - 60, true},
+		{"now", now.Unix(), true},
+		{"tomorrow", now.Add(24 * time.Hour).Unix(), false},
+		{"too far ahead", now.Add(MaxScheduleAhead + time.Hour).Unix(), true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateScheduledAt(tt.scheduledAt, now)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("validateScheduledAt() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestToView(t *testing.T) {
+	se := &model.ScheduledEvent{
+		ID:          7,
+		Cid:         "g:group1",
+		Sender:      "user1",
+		Kind:        protocol.KindText,
+		Tags:        `[{"type":1,"value":42}]`,
+		Data:        `{"0":"ciphertext"}`,
+		ScheduledAt: 1700000000,
+		Status:      model.ScheduledStatusPending,
+	}
+
+	view := toView(se)
+
+	if view.ID != 7 || view.ScheduledAt != 1700000000 {
+		t.Errorf("view mismatch: %+v", view)
+	}
+	if view.Event.Cid != "g:group1" || view.Event.Sender != "user1" || view.Event.Kind != protocol.KindText {
+		t.Errorf("event header mismatch: %+v", view.Event)
+	}
+	if view.Event.GetText() != "ciphertext" {
+		t.Errorf("event data mismatch: got %v", view.Event.Data)
+	}
+	if mid, ok := protocol.GetReplyMid(view.Event.Tags); !ok || mid != 42 {
+		t.Errorf("event tags mismatch: got %v", view.Event.Tags)
+	}
+}
+
+func TestScheduledEvent_TableName(t *testing.T) {
+	se := model.ScheduledEvent{}
+	if se.TableName() != "scheduled_events" {
+		t.Errorf("TableName() = %v, want %v", se.TableName(), "scheduled_events")
+	}
+}
@@ -28,14 +28,82 @@ CREATE TABLE IF NOT EXISTS users (
     phone VARCHAR(32),
     email VARCHAR(128),
     status INTEGER DEFAULT 1,
+    email_verified BOOLEAN DEFAULT FALSE,
+    phone_verified BOOLEAN DEFAULT FALSE,
+    discoverable INTEGER DEFAULT 1,
     created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
     updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
 );
 
 CREATE INDEX idx_users_username ON users(username);
+CREATE INDEX idx_users_username_prefix ON users(username text_pattern_ops);
 CREATE INDEX idx_users_phone ON users(phone);
 CREATE INDEX idx_users_email ON users(email);
 
+-- 安全日志表
+CREATE TABLE IF NOT EXISTS security_logs (
+    id SERIAL PRIMARY KEY,
+    user_id VARCHAR(32) NOT NULL,
+    event VARCHAR(32) NOT NULL,
+    ip VARCHAR(64),
+    device_id VARCHAR(64),
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_security_logs_user_id ON security_logs(user_id);
+
+-- 两步验证表
+CREATE TABLE IF NOT EXISTS user_totp (
+    user_id VARCHAR(32) PRIMARY KEY,
+    secret VARCHAR(64) NOT NULL,
+    enabled BOOLEAN DEFAULT FALSE,
+    last_step BIGINT DEFAULT 0,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+-- 两步验证恢复码表
+CREATE TABLE IF NOT EXISTS recovery_codes (
+    id SERIAL PRIMARY KEY,
+    user_id VARCHAR(32) NOT NULL,
+    code_hash VARCHAR(64) NOT NULL,
+    used_at TIMESTAMP,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_recovery_codes_user_id ON recovery_codes(user_id);
+
+-- 账号注销任务表
+CREATE TABLE IF NOT EXISTS account_deletions (
+    user_id VARCHAR(32) PRIMARY KEY,
+    status INTEGER DEFAULT 0,
+    purge_at TIMESTAMP NOT NULL,
+    departure JSONB,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_account_deletions_status ON account_deletions(status);
+CREATE INDEX idx_account_deletions_purge_at ON account_deletions(purge_at);
+
+-- 个人数据导出任务表
+CREATE TABLE IF NOT EXISTS data_exports (
+    id SERIAL PRIMARY KEY,
+    user_id VARCHAR(32) NOT NULL,
+    requested_by VARCHAR(32) NOT NULL,
+    status INTEGER DEFAULT 0,
+    file_key VARCHAR(256),
+    size BIGINT DEFAULT 0,
+    error VARCHAR(256),
+    expires_at TIMESTAMP,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_data_exports_user_id ON data_exports(user_id);
+CREATE INDEX idx_data_exports_status ON data_exports(status);
+CREATE INDEX idx_data_exports_expires_at ON data_exports(expires_at);
+
 -- 好友关系表
 CREATE TABLE IF NOT EXISTS friendships (
     id SERIAL PRIMARY KEY,
@@ -50,6 +118,17 @@ CREATE TABLE IF NOT EXISTS friendships (
 CREATE INDEX idx_friendships_user ON friendships(user_id);
 CREATE INDEX idx_friendships_friend ON friendships(friend_id);
 
+-- 拉黑关系表
+CREATE TABLE IF NOT EXISTS user_blocks (
+    id SERIAL PRIMARY KEY,
+    user_id VARCHAR(32) NOT NULL,
+    blocked_id VARCHAR(32) NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE(user_id, blocked_id)
+);
+
+CREATE INDEX idx_user_blocks_blocked_id ON user_blocks(blocked_id);
+
 -- 好友请求表
 CREATE TABLE IF NOT EXISTS friend_requests (
     id SERIAL PRIMARY KEY,
@@ -72,6 +151,12 @@ CREATE TABLE IF NOT EXISTS groups (
     owner_id VARCHAR(32) NOT NULL,
     max_members INTEGER DEFAULT 500,
     status INTEGER DEFAULT 1,
+    announcement TEXT,
+    announcement_revision INTEGER DEFAULT 0,
+    announcement_encrypted BOOLEAN DEFAULT FALSE,
+    mute_all BOOLEAN DEFAULT FALSE,
+    slow_mode INTEGER DEFAULT 0,
+    join_requests_enabled BOOLEAN DEFAULT TRUE,
     created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
     updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
 );
@@ -87,6 +172,8 @@ CREATE TABLE IF NOT EXISTS group_members (
     nickname VARCHAR(64),
     muted BOOLEAN DEFAULT FALSE,
     muted_at TIMESTAMP,
+    muted_until TIMESTAMP,
+    announcement_seen INTEGER DEFAULT 0,
     joined_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
     UNIQUE(group_id, user_id)
 );
@@ -94,12 +181,58 @@ CREATE TABLE IF NOT EXISTS group_members (
 CREATE INDEX idx_group_members_group ON group_members(group_id);
 CREATE INDEX idx_group_members_user ON group_members(user_id);
 
+-- 群邀请链接表
+CREATE TABLE IF NOT EXISTS group_invites (
+    id SERIAL PRIMARY KEY,
+    group_id VARCHAR(32) NOT NULL,
+    token VARCHAR(32) NOT NULL UNIQUE,
+    created_by VARCHAR(32) NOT NULL,
+    max_uses INTEGER DEFAULT 0,
+    uses INTEGER DEFAULT 0,
+    requires_approval BOOLEAN DEFAULT FALSE,
+    expires_at TIMESTAMP,
+    revoked_at TIMESTAMP,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_group_invites_group_id ON group_invites(group_id);
+
+-- 入群申请表
+CREATE TABLE IF NOT EXISTS group_join_requests (
+    id SERIAL PRIMARY KEY,
+    group_id VARCHAR(32) NOT NULL,
+    user_id VARCHAR(32) NOT NULL,
+    invite_id INTEGER,
+    message VARCHAR(256),
+    status INTEGER DEFAULT 0,
+    handled_by VARCHAR(32),
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_group_join_requests_group_id ON group_join_requests(group_id);
+CREATE INDEX idx_group_join_requests_user_id ON group_join_requests(user_id);
+
+-- 群公告历史表
+CREATE TABLE IF NOT EXISTS group_announcements (
+    id SERIAL PRIMARY KEY,
+    group_id VARCHAR(32) NOT NULL,
+    revision INTEGER NOT NULL,
+    content TEXT,
+    key_version INTEGER DEFAULT 0,
+    author_id VARCHAR(32) NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE(group_id, revision)
+);
+
 -- 会话表
 CREATE TABLE IF NOT EXISTS conversations (
     id VARCHAR(32) PRIMARY KEY,
     type INTEGER NOT NULL,
     name VARCHAR(64),
     avatar VARCHAR(512),
+    message_ttl BIGINT DEFAULT 0,
     created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
     updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
 );
@@ -119,6 +252,41 @@ CREATE TABLE IF NOT EXISTS conversation_members (
 CREATE INDEX idx_conv_members_conv ON conversation_members(conversation_id);
 CREATE INDEX idx_conv_members_user ON conversation_members(user_id);
 
+-- 会话消息策略表
+CREATE TABLE IF NOT EXISTS conversation_policies (
+    conversation_id VARCHAR(64) PRIMARY KEY,
+    revoke_window BIGINT DEFAULT 120,
+    edit_window BIGINT DEFAULT 86400,
+    editable_kinds JSONB DEFAULT '[1]',
+    admin_revoke_any BOOLEAN DEFAULT TRUE,
+    updated_by VARCHAR(32),
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+-- 会话置顶消息表
+CREATE TABLE IF NOT EXISTS pinned_messages (
+    id SERIAL PRIMARY KEY,
+    conversation_id VARCHAR(64) NOT NULL,
+    mid BIGINT NOT NULL,
+    position INTEGER NOT NULL,
+    pinned_by VARCHAR(32) NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE(conversation_id, mid)
+);
+
+-- 会话草稿表（密文）
+CREATE TABLE IF NOT EXISTS drafts (
+    id SERIAL PRIMARY KEY,
+    user_id VARCHAR(32) NOT NULL,
+    conversation_id VARCHAR(64) NOT NULL,
+    data TEXT,
+    ts BIGINT NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE(user_id, conversation_id)
+);
+
 -- 用户密钥表 (加密)
 CREATE TABLE IF NOT EXISTS user_keys (
     id SERIAL PRIMARY KEY,
@@ -178,6 +346,12 @@ CREATE TABLE IF NOT EXISTS events (
     data JSONB,
     sig VARCHAR(256),
     ext JSONB,
+    revoked_by VARCHAR(32),
+    revoke_reason VARCHAR(256),
+    revoked_at TIMESTAMP,
+    reply_mid BIGINT DEFAULT 0,
+    root_mid BIGINT DEFAULT 0,
+    expires_at BIGINT DEFAULT 0,
     created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
 );
 
@@ -185,6 +359,32 @@ CREATE INDEX idx_events_cid_mid ON events(cid, mid);
 CREATE INDEX idx_events_cid_timestamp ON events(cid, timestamp DESC);
 CREATE INDEX idx_events_sender ON events(sender);
 CREATE INDEX idx_events_kind ON events(kind);
+CREATE INDEX idx_events_cid_root ON events(cid, root_mid);
+CREATE INDEX idx_events_expires_at ON events(expires_at) WHERE expires_at > 0;
+
+-- 隐藏消息表（仅自己删除）
+CREATE TABLE IF NOT EXISTS hidden_events (
+    id SERIAL PRIMARY KEY,
+    uid VARCHAR(32) NOT NULL,
+    mid BIGINT NOT NULL,
+    cid VARCHAR(64) NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE(uid, mid)
+);
+
+CREATE INDEX idx_hidden_events_cid ON hidden_events(cid);
+
+-- 线程订阅表
+CREATE TABLE IF NOT EXISTS thread_subscriptions (
+    id SERIAL PRIMARY KEY,
+    cid VARCHAR(64) NOT NULL,
+    root_mid BIGINT NOT NULL,
+    uid VARCHAR(32) NOT NULL,
+    muted BOOLEAN DEFAULT FALSE,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE(cid, root_mid, uid)
+);
 
 -- 已读回执表
 CREATE TABLE IF NOT EXISTS read_receipts (
@@ -198,6 +398,16 @@ CREATE TABLE IF NOT EXISTS read_receipts (
 
 CREATE INDEX idx_receipts_cid ON read_receipts(cid);
 
+-- 送达回执表
+CREATE TABLE IF NOT EXISTS delivery_receipts (
+    id SERIAL PRIMARY KEY,
+    cid VARCHAR(64) NOT NULL,
+    uid VARCHAR(32) NOT NULL,
+    last_delivered_mid BIGINT NOT NULL,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE(cid, uid)
+);
+
 -- 消息反应表
 CREATE TABLE IF NOT EXISTS reactions (
     id SERIAL PRIMARY KEY,
@@ -212,6 +422,28 @@ CREATE TABLE IF NOT EXISTS reactions (
 CREATE INDEX idx_reactions_mid ON reactions(mid);
 CREATE INDEX idx_reactions_cid_mid ON reactions(cid, mid);
 
+-- 定时消息表
+CREATE TABLE IF NOT EXISTS scheduled_events (
+    id SERIAL PRIMARY KEY,
+    cid VARCHAR(64) NOT NULL,
+    sender VARCHAR(32) NOT NULL,
+    kind INTEGER NOT NULL,
+    tags JSONB,
+    data JSONB,
+    flags INTEGER DEFAULT 0,
+    sig VARCHAR(256),
+    scheduled_at BIGINT NOT NULL,
+    status INTEGER DEFAULT 0,
+    mid BIGINT DEFAULT 0,
+    fail_reason VARCHAR(256),
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_scheduled_events_cid ON scheduled_events(cid);
+CREATE INDEX idx_scheduled_events_sender ON scheduled_events(sender);
+CREATE INDEX idx_scheduled_events_due ON scheduled_events(status, scheduled_at);
+
 -- ============================================
 -- 完成
 -- ============================================
@@ -1,6 +1,7 @@
 package main
 
 import (
+	"context"
 	"flag"
 	"strings"
 
@@ -10,6 +11,7 @@ import (
 	"github.com/my-chat/seaking/internal/conf"
 	"github.com/my-chat/seaking/internal/model"
 	"github.com/my-chat/seaking/internal/server"
+	"github.com/my-chat/seaking/internal/service/group"
 	"github.com/my-chat/seaking/internal/storage"
 )
 
@@ -17,6 +19,7 @@ var (
 	configName = flag.String("c", "config", "config file name")
 	configPath = flag.String("cPath", "./,./configs/", "config file paths")
 	migrate    = flag.Bool("migrate", false, "run database migration")
+	reconcile  = flag.Bool("reconcile", false, "repair conversation membership from group membership and exit")
 )
 
 func main() {
@@ -44,12 +47,24 @@ func main() {
 		log.Info().Msg("running database migration")
 		if err := db.AutoMigrate(
 			&model.User{},
+			&model.SecurityLog{},
+			&model.UserTOTP{},
+			&model.RecoveryCode{},
+			&model.AccountDeletion{},
+			&model.DataExport{},
 			&model.Friendship{},
 			&model.FriendRequest{},
+			&model.UserBlock{},
 			&model.Group{},
 			&model.GroupMember{},
+			&model.GroupInvite{},
+			&model.GroupJoinRequest{},
+			&model.GroupAnnouncement{},
 			&model.Conversation{},
 			&model.ConversationMember{},
+			&model.ConversationPolicy{},
+			&model.PinnedMessage{},
+			&model.Draft{},
 			// 加密密钥表
 			&model.UserKey{},
 			&model.ChatKey{},
@@ -69,6 +84,21 @@ func main() {
 	// 创建存储层
 	st := storage.NewStorage(db, redisClient)
 
+	// 一次性修复群成员与会话成员的偏差
+	if *reconcile {
+		result, err := group.NewService(st).ReconcileMembership(context.Background())
+		if err != nil {
+			log.Fatal().Err(err).Msg("failed to reconcile membership")
+		}
+		log.Info().
+			Int("groups", result.Groups).
+			Int("created_conversations", result.CreatedConversation).
+			Int("added", result.Added).
+			Int("removed", result.Removed).
+			Msg("membership reconciliation completed")
+		return
+	}
+
 	// 创建并启动服务器
 	srv := server.NewServer(cfg, st)
 	if err := srv.Run(); err != nil {
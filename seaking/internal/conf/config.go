@@ -9,4 +9,19 @@ type Config struct {
 	Redis    config.RedisConfiguration    `mapstructure:"RedisConfiguration"`
 	Logger   config.LoggerConfiguration   `mapstructure:"LoggerConfiguration"`
 	JWT      config.JWTConfiguration      `mapstructure:"JWTConfiguration"`
+	SeaKing  SeaKingConfiguration         `mapstructure:"SeaKingConfiguration"`
+}
+
+// SeaKingConfiguration SeaKing专属配置
+type SeaKingConfiguration struct {
+	// 每个会话最多置顶消息数（0表示使用默认值）
+	MaxPinnedMessages int `mapstructure:"MaxPinnedMessages"`
+	// 每个用户每分钟最多搜索用户次数（0表示使用默认值）
+	SearchRateLimit int `mapstructure:"SearchRateLimit"`
+	// 两步验证在验证器应用中显示的服务名（为空表示使用默认值）
+	TOTPIssuer string `mapstructure:"TOTPIssuer"`
+	// 注销账号的冷静期（小时），期间账号已禁用但数据保留、可撤销；0表示立即清理
+	DeletionGraceHours int `mapstructure:"DeletionGraceHours"`
+	// 个人数据导出文件保留时间（小时），过期后文件被删除（0表示使用默认值）
+	ExportRetentionHours int `mapstructure:"ExportRetentionHours"`
 }
@@ -1,20 +1,23 @@
 package model
 
 import (
+	"strings"
 	"time"
 
+	"github.com/my-chat/common/pkg/protocol"
 	"gorm.io/gorm"
 )
 
 // Conversation 会话
 type Conversation struct {
-	ID        string         `gorm:"primaryKey;size:64" json:"id"` // 会话ID (cid)
-	Type      int            `gorm:"not null" json:"type"`         // 1=单聊, 2=群聊
-	Name      string         `gorm:"size:64" json:"name"`          // 会话名称（群聊时为群名）
-	Avatar    string         `gorm:"size:256" json:"avatar"`
-	CreatedAt time.Time      `json:"created_at"`
-	UpdatedAt time.Time      `json:"updated_at"`
-	DeletedAt gorm.DeletedAt `gorm:"index" json:"-"`
+	ID         string         `gorm:"primaryKey;size:64" json:"id"` // 会话ID (cid)
+	Type       int            `gorm:"not null" json:"type"`         // 1=单聊, 2=群聊
+	Name       string         `gorm:"size:64" json:"name"`          // 会话名称（群聊时为群名）
+	Avatar     string         `gorm:"size:256" json:"avatar"`
+	MessageTTL int64          `gorm:"default:0" json:"message_ttl"` // 消息过期时间（秒），0=不过期
+	CreatedAt  time.Time      `json:"created_at"`
+	UpdatedAt  time.Time      `json:"updated_at"`
+	DeletedAt  gorm.DeletedAt `gorm:"index" json:"-"`
 }
 
 // TableName 表名
@@ -41,6 +44,80 @@ func (ConversationMember) TableName() string {
 	return "conversation_members"
 }
 
+// ConversationPolicy 会话消息策略（撤销/编辑规则）
+type ConversationPolicy struct {
+	ConversationID string    `gorm:"primaryKey;size:64" json:"conversation_id"`
+	RevokeWindow   int64     `gorm:"not null" json:"revoke_window"`                    // 撤销时间窗口（秒），0=不限制
+	EditWindow     int64     `gorm:"not null" json:"edit_window"`                      // 编辑时间窗口（秒），0=不限制
+	EditableKinds  []int     `gorm:"serializer:json;type:jsonb" json:"editable_kinds"` // 可编辑的消息类型，为空则禁止编辑
+	AdminRevokeAny bool      `gorm:"not null" json:"admin_revoke_any"`                 // 管理员是否可撤销任何人的消息
+	UpdatedBy      string    `gorm:"size:32" json:"updated_by"`                        // 最后修改者
+	CreatedAt      time.Time `json:"created_at"`
+	UpdatedAt      time.Time `json:"updated_at"`
+}
+
+// TableName 表名
+func (ConversationPolicy) TableName() string {
+	return "conversation_policies"
+}
+
+// 会话消息策略默认值
+const (
+	DefaultRevokeWindow = 2 * 60       // 2分钟
+	DefaultEditWindow   = 24 * 60 * 60 // 24小时
+)
+
+// DefaultConversationPolicy 默认会话消息策略：2分钟内可撤销，24小时内可编辑文本，管理员可撤销任何人的消息
+func DefaultConversationPolicy(cid string) *ConversationPolicy {
+	return &ConversationPolicy{
+		ConversationID: cid,
+		RevokeWindow:   DefaultRevokeWindow,
+		EditWindow:     DefaultEditWindow,
+		EditableKinds:  []int{protocol.KindText},
+		AdminRevokeAny: true,
+	}
+}
+
+// MaxMessageTTL 消息过期时间上限（秒）
+const MaxMessageTTL = 365 * 24 * 60 * 60
+
+// PinnedMessage 会话置顶消息
+type PinnedMessage struct {
+	ID             uint      `gorm:"primaryKey" json:"id"`
+	ConversationID string    `gorm:"uniqueIndex:idx_pinned_conv_mid;size:64;not null" json:"conversation_id"`
+	Mid            int64     `gorm:"uniqueIndex:idx_pinned_conv_mid;not null" json:"mid"` // 置顶的消息ID
+	Position       int       `gorm:"not null" json:"position"`                            // 排序位置，越大越靠前
+	PinnedBy       string    `gorm:"size:32;not null" json:"pinned_by"`                   // 置顶者
+	CreatedAt      time.Time `json:"created_at"`
+}
+
+// TableName 表名
+func (PinnedMessage) TableName() string {
+	return "pinned_messages"
+}
+
+// DefaultMaxPinnedMessages 每个会话默认最多置顶消息数
+const DefaultMaxPinnedMessages = 50
+
+// Draft 用户在会话中的草稿（跨设备同步，内容为客户端加密后的密文）
+type Draft struct {
+	ID             uint      `gorm:"primaryKey" json:"id"`
+	UserID         string    `gorm:"uniqueIndex:idx_draft_user_conv;size:32;not null" json:"user_id"`
+	ConversationID string    `gorm:"uniqueIndex:idx_draft_user_conv;size:64;not null" json:"conversation_id"`
+	Data           string    `gorm:"type:text" json:"data"` // 草稿密文，为空表示已清除
+	Ts             int64     `gorm:"not null" json:"ts"`    // 客户端修改时间（毫秒），后写者胜
+	CreatedAt      time.Time `json:"created_at"`
+	UpdatedAt      time.Time `json:"updated_at"`
+}
+
+// TableName 表名
+func (Draft) TableName() string {
+	return "drafts"
+}
+
+// MaxDraftSize 草稿密文最大长度（字节）
+const MaxDraftSize = 64 * 1024
+
 // 会话类型
 const (
 	ConversationTypeDirect = 1 // 单聊
@@ -55,6 +132,18 @@ func GenerateDirectCid(uid1, uid2 string) string {
 	return "d:" + uid2 + ":" + uid1
 }
 
+// ParseDirectCid 解析单聊会话ID，返回双方用户ID
+func ParseDirectCid(cid string) (string, string, bool) {
+	if !strings.HasPrefix(cid, "d:") {
+		return "", "", false
+	}
+	parts := strings.Split(strings.TrimPrefix(cid, "d:"), ":")
+	if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+		return "", "", false
+	}
+	return parts[0], parts[1], true
+}
+
 // GenerateGroupCid 生成群聊会话ID
 func GenerateGroupCid(groupId string) string {
 	return "g:" + groupId
@@ -19,6 +19,40 @@ func TestConversationMember_TableName(t *testing.T) {
 	}
 }
 
+func TestConversationPolicy_TableName(t *testing.T) {
+	p := ConversationPolicy{}
+	if p.TableName() != "conversation_policies" {
+		t.Errorf("TableName() = %v, want %v", p.TableName(), "conversation_policies")
+	}
+}
+
+func TestPinnedMessage_TableName(t *testing.T) {
+	p := PinnedMessage{}
+	if p.TableName() != "pinned_messages" {
+		t.Errorf("TableName() = %v, want %v", p.TableName(), "pinned_messages")
+	}
+}
+
+func TestDefaultConversationPolicy(t *testing.T) {
+	p := DefaultConversationPolicy("g:group1")
+
+	if p.ConversationID != "g:group1" {
+		t.Errorf("ConversationID = %v, want g:group1", p.ConversationID)
+	}
+	if p.RevokeWindow != DefaultRevokeWindow {
+		t.Errorf("RevokeWindow = %v, want %v", p.RevokeWindow, DefaultRevokeWindow)
+	}
+	if p.EditWindow != DefaultEditWindow {
+		t.Errorf("EditWindow = %v, want %v", p.EditWindow, DefaultEditWindow)
+	}
+	if len(p.EditableKinds) != 1 {
+		t.Errorf("EditableKinds = %v, want only text", p.EditableKinds)
+	}
+	if !p.AdminRevokeAny {
+		t.Error("AdminRevokeAny should default to true")
+	}
+}
+
 func TestConversation_Fields(t *testing.T) {
 	now := time.Now()
 	c := Conversation{
@@ -135,6 +169,19 @@ func TestGenerateDirectCid_Symmetry(t *testing.T) {
 	}
 }
 
+func TestParseDirectCid(t *testing.T) {
+	uid1, uid2, ok := ParseDirectCid(GenerateDirectCid("userB", "userA"))
+	if !ok || uid1 != "userA" || uid2 != "userB" {
+		t.Errorf("ParseDirectCid = (%s, %s, %v), want (userA, userB, true)", uid1, uid2, ok)
+	}
+
+	for _, cid := range []string{"g:group1", "d:onlyone", "d::userB", "d:a:b:c", ""} {
+		if _, _, ok := ParseDirectCid(cid); ok {
+			t.Errorf("ParseDirectCid(%q) should fail", cid)
+		}
+	}
+}
+
 func TestGenerateGroupCid(t *testing.T) {
 	tests := []struct {
 		groupId  string
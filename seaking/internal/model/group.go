@@ -8,16 +8,22 @@ import (
 
 // Group 群组
 type Group struct {
-	ID          string         `gorm:"primaryKey;size:32" json:"id"`
-	Name        string         `gorm:"size:64;not null" json:"name"`
-	Avatar      string         `gorm:"size:256" json:"avatar"`
-	Description string         `gorm:"size:512" json:"description"`
-	OwnerID     string         `gorm:"index;size:32;not null" json:"owner_id"`
-	MaxMembers  int            `gorm:"default:500" json:"max_members"`
-	Status      int            `gorm:"default:1" json:"status"` // 1=正常, 0=解散
-	CreatedAt   time.Time      `json:"created_at"`
-	UpdatedAt   time.Time      `json:"updated_at"`
-	DeletedAt   gorm.DeletedAt `gorm:"index" json:"-"`
+	ID                    string         `gorm:"primaryKey;size:32" json:"id"`
+	Name                  string         `gorm:"size:64;not null" json:"name"`
+	Avatar                string         `gorm:"size:256" json:"avatar"`
+	Description           string         `gorm:"size:512" json:"description"`
+	OwnerID               string         `gorm:"index;size:32;not null" json:"owner_id"`
+	MaxMembers            int            `gorm:"default:500" json:"max_members"`
+	Status                int            `gorm:"default:1" json:"status"`                     // 1=正常, 0=解散
+	Announcement          string         `gorm:"type:text" json:"announcement"`               // 当前群公告，加密时为群密钥加密的密文
+	AnnouncementRevision  int            `gorm:"default:0" json:"announcement_revision"`      // 公告版本，每次设置或清除递增
+	AnnouncementEncrypted bool           `gorm:"default:false" json:"announcement_encrypted"` // 公告是否使用群密钥加密存储
+	MuteAll               bool           `gorm:"default:false" json:"mute_all"`               // 全员禁言，仅群主和管理员可发言
+	SlowMode              int            `gorm:"default:0" json:"slow_mode"`                  // 慢速模式：成员两次发言的最小间隔（秒），0表示关闭
+	JoinRequestsEnabled   bool           `gorm:"default:true" json:"join_requests_enabled"`   // 是否接受直接申请入群（需审批的邀请链接不受影响）
+	CreatedAt             time.Time      `json:"created_at"`
+	UpdatedAt             time.Time      `json:"updated_at"`
+	DeletedAt             gorm.DeletedAt `gorm:"index" json:"-"`
 }
 
 // TableName 表名
@@ -27,17 +33,19 @@ func (Group) TableName() string {
 
 // GroupMember 群成员
 type GroupMember struct {
-	ID        uint           `gorm:"primaryKey" json:"id"`
-	GroupID   string         `gorm:"index;size:32;not null" json:"group_id"`
-	UserID    string         `gorm:"index;size:32;not null" json:"user_id"`
-	Role      int            `gorm:"default:0" json:"role"`     // 0=普通成员, 1=管理员, 2=群主
-	Nickname  string         `gorm:"size:64" json:"nickname"`   // 群昵称
-	Muted     bool           `gorm:"default:false" json:"muted"` // 是否被禁言
-	MutedAt   *time.Time     `json:"muted_at"`
-	JoinedAt  time.Time      `json:"joined_at"`
-	CreatedAt time.Time      `json:"created_at"`
-	UpdatedAt time.Time      `json:"updated_at"`
-	DeletedAt gorm.DeletedAt `gorm:"index" json:"-"`
+	ID               uint           `gorm:"primaryKey" json:"id"`
+	GroupID          string         `gorm:"index;size:32;not null" json:"group_id"`
+	UserID           string         `gorm:"index;size:32;not null" json:"user_id"`
+	Role             int            `gorm:"default:0" json:"role"`      // 0=普通成员, 1=管理员, 2=群主
+	Nickname         string         `gorm:"size:64" json:"nickname"`    // 群昵称
+	Muted            bool           `gorm:"default:false" json:"muted"` // 是否被禁言
+	MutedAt          *time.Time     `json:"muted_at"`
+	MutedUntil       *time.Time     `json:"muted_until"`                        // 禁言截止时间，为空表示永久禁言
+	AnnouncementSeen int            `gorm:"default:0" json:"announcement_seen"` // 已确认的群公告版本
+	JoinedAt         time.Time      `json:"joined_at"`
+	CreatedAt        time.Time      `json:"created_at"`
+	UpdatedAt        time.Time      `json:"updated_at"`
+	DeletedAt        gorm.DeletedAt `gorm:"index" json:"-"`
 }
 
 // TableName 表名
@@ -45,6 +53,11 @@ func (GroupMember) TableName() string {
 	return "group_members"
 }
 
+// IsMuted 当前是否处于禁言中（禁言到期后自动解除）
+func (m *GroupMember) IsMuted(now time.Time) bool {
+	return m.Muted && (m.MutedUntil == nil || now.Before(*m.MutedUntil))
+}
+
 // 群组状态
 const (
 	GroupStatusDissolved = 0
@@ -57,3 +70,81 @@ const (
 	GroupRoleAdmin  = 1
 	GroupRoleOwner  = 2
 )
+
+// GroupInvite 群邀请链接，持有 Token 的用户可预览并加入群组
+type GroupInvite struct {
+	ID               uint       `gorm:"primaryKey" json:"id"`
+	GroupID          string     `gorm:"index;size:32;not null" json:"group_id"`
+	Token            string     `gorm:"uniqueIndex;size:32;not null" json:"token"`
+	CreatedBy        string     `gorm:"size:32;not null" json:"created_by"`
+	MaxUses          int        `gorm:"default:0" json:"max_uses"` // 最多使用次数，0表示不限制
+	Uses             int        `gorm:"default:0" json:"uses"`     // 已使用次数
+	RequiresApproval bool       `gorm:"default:false" json:"requires_approval"`
+	ExpiresAt        *time.Time `json:"expires_at"` // 过期时间，为空表示永不过期
+	RevokedAt        *time.Time `json:"revoked_at"`
+	CreatedAt        time.Time  `json:"created_at"`
+	UpdatedAt        time.Time  `json:"updated_at"`
+}
+
+// TableName 表名
+func (GroupInvite) TableName() string {
+	return "group_invites"
+}
+
+// Usable 邀请链接当前是否可用（未撤销、未过期、未用完）
+func (i *GroupInvite) Usable(now time.Time) bool {
+	if i.RevokedAt != nil {
+		return false
+	}
+	if i.ExpiresAt != nil && !now.Before(*i.ExpiresAt) {
+		return false
+	}
+	return i.MaxUses == 0 || i.Uses < i.MaxUses
+}
+
+// GroupJoinRequest 入群申请，由管理员审批
+type GroupJoinRequest struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	GroupID   string    `gorm:"index;size:32;not null" json:"group_id"`
+	UserID    string    `gorm:"index;size:32;not null" json:"user_id"`
+	InviteID  *uint     `json:"invite_id"` // 通过需审批的邀请链接申请时记录链接ID
+	Message   string    `gorm:"size:256" json:"message"`
+	Status    int       `gorm:"default:0" json:"status"` // 0=待处理, 1=同意, 2=拒绝
+	HandledBy string    `gorm:"size:32" json:"handled_by"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// TableName 表名
+func (GroupJoinRequest) TableName() string {
+	return "group_join_requests"
+}
+
+// 入群申请状态
+const (
+	JoinRequestPending  = 0
+	JoinRequestApproved = 1
+	JoinRequestRejected = 2
+)
+
+// MaxSlowMode 慢速模式最长间隔（秒）
+const MaxSlowMode = 3600
+
+// MaxAnnouncementLength 群公告最大长度（字节，加密时为密文长度）
+const MaxAnnouncementLength = 8192
+
+// GroupAnnouncement 群公告历史版本，清除公告也记录为一个内容为空的版本
+type GroupAnnouncement struct {
+	ID         uint      `gorm:"primaryKey" json:"id"`
+	GroupID    string    `gorm:"uniqueIndex:idx_group_announcement_rev;size:32;not null" json:"group_id"`
+	Revision   int       `gorm:"uniqueIndex:idx_group_announcement_rev;not null" json:"revision"`
+	Content    string    `gorm:"type:text" json:"content"`
+	KeyVersion int       `gorm:"default:0" json:"key_version"` // 加密使用的群密钥版本，0表示明文
+	AuthorID   string    `gorm:"size:32;not null" json:"author_id"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+// TableName 表名
+func (GroupAnnouncement) TableName() string {
+	return "group_announcements"
+}
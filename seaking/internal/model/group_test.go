@@ -109,6 +109,30 @@ func TestGroupMember_MutedAt(t *testing.T) {
 	}
 }
 
+func TestGroupMember_IsMuted(t *testing.T) {
+	now := time.Now()
+	until := now.Add(time.Hour)
+	gm := GroupMember{Muted: true, MutedAt: &now}
+
+	// 未设置截止时间为永久禁言
+	if !gm.IsMuted(now) {
+		t.Error("member without MutedUntil should be muted")
+	}
+
+	gm.MutedUntil = &until
+	if !gm.IsMuted(now) {
+		t.Error("member should be muted before MutedUntil")
+	}
+	if gm.IsMuted(until.Add(time.Second)) {
+		t.Error("mute should expire after MutedUntil")
+	}
+
+	gm.Muted = false
+	if gm.IsMuted(now) {
+		t.Error("unmuted member should not be muted")
+	}
+}
+
 func TestGroupStatus_Constants(t *testing.T) {
 	if GroupStatusDissolved != 0 {
 		t.Errorf("GroupStatusDissolved = %v, want %v", GroupStatusDissolved, 0)
@@ -167,3 +191,64 @@ func TestGroupMember_DefaultValues(t *testing.T) {
 		t.Error("Default Muted should be false")
 	}
 }
+
+func TestGroupInvite_TableName(t *testing.T) {
+	i := GroupInvite{}
+	if i.TableName() != "group_invites" {
+		t.Errorf("TableName() = %v, want %v", i.TableName(), "group_invites")
+	}
+}
+
+func TestGroupInvite_Usable(t *testing.T) {
+	now := time.Now()
+	past := now.Add(-time.Minute)
+	future := now.Add(time.Hour)
+
+	tests := []struct {
+		name   string
+		invite GroupInvite
+		want   bool
+	}{
+		{"unlimited", GroupInvite{}, true},
+		{"not expired", GroupInvite{ExpiresAt: &future}, true},
+		{"expired", GroupInvite{ExpiresAt: &past}, false},
+		{"expires now", GroupInvite{ExpiresAt: &now}, false},
+		{"uses left", GroupInvite{MaxUses: 3, Uses: 2}, true},
+		{"used up", GroupInvite{MaxUses: 3, Uses: 3}, false},
+		{"revoked", GroupInvite{RevokedAt: &past}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.invite.Usable(now); got != tt.want {
+				t.Errorf("Usable() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGroupJoinRequest_TableName(t *testing.T) {
+	r := GroupJoinRequest{}
+	if r.TableName() != "group_join_requests" {
+		t.Errorf("TableName() = %v, want %v", r.TableName(), "group_join_requests")
+	}
+}
+
+func TestJoinRequestStatus_Constants(t *testing.T) {
+	if JoinRequestPending != 0 {
+		t.Errorf("JoinRequestPending = %v, want %v", JoinRequestPending, 0)
+	}
+	if JoinRequestApproved != 1 {
+		t.Errorf("JoinRequestApproved = %v, want %v", JoinRequestApproved, 1)
+	}
+	if JoinRequestRejected != 2 {
+		t.Errorf("JoinRequestRejected = %v, want %v", JoinRequestRejected, 2)
+	}
+}
+
+func TestGroupAnnouncement_TableName(t *testing.T) {
+	a := GroupAnnouncement{}
+	if a.TableName() != "group_announcements" {
+		t.Errorf("TableName() = %v, want %v", a.TableName(), "group_announcements")
+	}
+}
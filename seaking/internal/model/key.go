@@ -38,10 +38,10 @@ func (ChatKey) TableName() string {
 // GroupKey 群组加密密钥表
 type GroupKey struct {
 	ID           uint      `gorm:"primaryKey;autoIncrement" json:"id"`
-	GroupID      string    `gorm:"index;size:32;not null" json:"group_id"`    // 群组ID
-	UserID       string    `gorm:"size:32;not null" json:"user_id"`           // 用户ID
-	EncryptedKey string    `gorm:"type:text;not null" json:"encrypted_key"`   // 群密钥 (用该用户公钥加密)
-	Version      int       `gorm:"default:1" json:"version"`                  // 密钥版本
+	GroupID      string    `gorm:"index;uniqueIndex:idx_group_key_version;size:32;not null" json:"group_id"` // 群组ID
+	UserID       string    `gorm:"uniqueIndex:idx_group_key_version;size:32;not null" json:"user_id"`        // 用户ID
+	EncryptedKey string    `gorm:"type:text;not null" json:"encrypted_key"`                                  // 群密钥 (用该用户公钥加密)
+	Version      int       `gorm:"uniqueIndex:idx_group_key_version;default:1" json:"version"`               // 密钥版本
 	CreatedAt    time.Time `json:"created_at"`
 	UpdatedAt    time.Time `json:"updated_at"`
 }
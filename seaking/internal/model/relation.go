@@ -52,3 +52,16 @@ const (
 	FriendRequestAccepted = 1
 	FriendRequestRejected = 2
 )
+
+// UserBlock 拉黑关系（单向，非好友也可拉黑）
+type UserBlock struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	UserID    string    `gorm:"uniqueIndex:idx_user_blocks_pair;size:32;not null" json:"user_id"`
+	BlockedID string    `gorm:"uniqueIndex:idx_user_blocks_pair;index;size:32;not null" json:"blocked_id"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// TableName 表名
+func (UserBlock) TableName() string {
+	return "user_blocks"
+}
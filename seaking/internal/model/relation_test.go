@@ -12,6 +12,13 @@ func TestFriendship_TableName(t *testing.T) {
 	}
 }
 
+func TestUserBlock_TableName(t *testing.T) {
+	b := UserBlock{}
+	if b.TableName() != "user_blocks" {
+		t.Errorf("TableName() = %v, want %v", b.TableName(), "user_blocks")
+	}
+}
+
 func TestFriendRequest_TableName(t *testing.T) {
 	fr := FriendRequest{}
 	if fr.TableName() != "friend_requests" {
@@ -8,17 +8,20 @@ import (
 
 // User 用户模型
 type User struct {
-	ID        string         `gorm:"primaryKey;size:32" json:"id"`
-	Username  string         `gorm:"uniqueIndex;size:64;not null" json:"username"`
-	Nickname  string         `gorm:"size:64" json:"nickname"`
-	Avatar    string         `gorm:"size:256" json:"avatar"`
-	Password  string         `gorm:"size:128;not null" json:"-"`
-	Phone     string         `gorm:"index;size:20" json:"phone"`
-	Email     string         `gorm:"index;size:128" json:"email"`
-	Status    int            `gorm:"default:1" json:"status"` // 1=正常, 0=禁用
-	CreatedAt time.Time      `json:"created_at"`
-	UpdatedAt time.Time      `json:"updated_at"`
-	DeletedAt gorm.DeletedAt `gorm:"index" json:"-"`
+	ID            string         `gorm:"primaryKey;size:32" json:"id"`
+	Username      string         `gorm:"uniqueIndex;index:idx_users_username_prefix,expression:username text_pattern_ops;size:64;not null" json:"username"`
+	Nickname      string         `gorm:"size:64" json:"nickname"`
+	Avatar        string         `gorm:"size:256" json:"avatar"`
+	Password      string         `gorm:"size:128;not null" json:"-"`
+	Phone         string         `gorm:"index;size:20" json:"phone"`
+	Email         string         `gorm:"index;size:128" json:"email"`
+	Status        int            `gorm:"default:1" json:"status"`             // 1=正常, 0=禁用
+	EmailVerified bool           `gorm:"default:false" json:"email_verified"` // 邮箱已验证，验证后才可用于搜索
+	PhoneVerified bool           `gorm:"default:false" json:"phone_verified"` // 手机号已验证，验证后才可用于搜索
+	Discoverable  int            `gorm:"default:1" json:"discoverable"`       // 可被搜索的方式，按位组合
+	CreatedAt     time.Time      `json:"created_at"`
+	UpdatedAt     time.Time      `json:"updated_at"`
+	DeletedAt     gorm.DeletedAt `gorm:"index" json:"-"`
 }
 
 // TableName 表名
@@ -31,3 +34,154 @@ const (
 	UserStatusDisabled = 0
 	UserStatusNormal   = 1
 )
+
+// 可被搜索的方式（按位组合），默认仅可通过用户名搜索
+const (
+	DiscoverByUsername = 1 << 0
+	DiscoverByEmail    = 1 << 1
+	DiscoverByPhone    = 1 << 2
+
+	DiscoverDefault = DiscoverByUsername
+	DiscoverAll     = DiscoverByUsername | DiscoverByEmail | DiscoverByPhone
+)
+
+// DefaultSearchRateLimit 每个用户每分钟默认最多搜索次数
+const DefaultSearchRateLimit = 30
+
+// DiscoverableBy 是否允许通过指定方式被搜索到
+func (u *User) DiscoverableBy(method int) bool {
+	return u.Discoverable&method != 0
+}
+
+// SecurityLog 账号安全日志，用户可查看自己的记录
+type SecurityLog struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	UserID    string    `gorm:"index;size:32;not null" json:"user_id"`
+	Event     string    `gorm:"size:32;not null" json:"event"`
+	IP        string    `gorm:"size:64" json:"ip"`
+	DeviceID  string    `gorm:"size:64" json:"device_id"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// TableName 表名
+func (SecurityLog) TableName() string {
+	return "security_logs"
+}
+
+// 安全日志事件
+const (
+	SecurityEventLoginFailed   = "login_failed"   // 密码错误
+	SecurityEventLoginLocked   = "login_locked"   // 失败次数过多被临时锁定
+	SecurityEventLoginUnlocked = "login_unlocked" // 管理员解除锁定
+	// SecurityEventPasswordConfirmFailed 已登录用户再次确认密码（修改密码、注销账号、两步验证管理）时密码错误
+	SecurityEventPasswordConfirmFailed = "password_confirm_failed"
+)
+
+// 两步验证相关安全日志事件
+const (
+	SecurityEventTOTPEnabled      = "totp_enabled"       // 启用两步验证
+	SecurityEventTOTPDisabled     = "totp_disabled"      // 关闭两步验证
+	SecurityEventTOTPFailed       = "totp_failed"        // 两步验证码错误
+	SecurityEventRecoveryCodeUsed = "recovery_code_used" // 使用恢复码
+)
+
+// UserTOTP 用户两步验证（TOTP）配置
+type UserTOTP struct {
+	UserID    string    `gorm:"primaryKey;size:32" json:"user_id"`
+	Secret    string    `gorm:"size:64;not null" json:"-"`
+	Enabled   bool      `gorm:"default:false" json:"enabled"` // 首次验证通过后才启用
+	LastStep  int64     `gorm:"default:0" json:"-"`           // 最近一次通过校验的时间步，防止验证码重放
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// TableName 表名
+func (UserTOTP) TableName() string {
+	return "user_totp"
+}
+
+// RecoveryCode 两步验证恢复码，只保存哈希，每个只能使用一次
+type RecoveryCode struct {
+	ID        uint       `gorm:"primaryKey" json:"id"`
+	UserID    string     `gorm:"index;size:32;not null" json:"user_id"`
+	CodeHash  string     `gorm:"size:64;not null" json:"-"`
+	UsedAt    *time.Time `json:"used_at"`
+	CreatedAt time.Time  `json:"created_at"`
+}
+
+// TableName 表名
+func (RecoveryCode) TableName() string {
+	return "recovery_codes"
+}
+
+// RecoveryCodeCount 每次生成的恢复码数量
+const RecoveryCodeCount = 10
+
+// DefaultTOTPIssuer 验证器应用中显示的默认服务名
+const DefaultTOTPIssuer = "MyChat"
+
+// AccountDeletion 账号注销任务，冷静期结束后由清理任务分步删除用户数据
+type AccountDeletion struct {
+	UserID    string          `gorm:"primaryKey;size:32" json:"user_id"`
+	Status    int             `gorm:"index;default:0" json:"status"`
+	PurgeAt   time.Time       `gorm:"index;not null" json:"purge_at"`      // 冷静期结束时间
+	Departure *GroupDeparture `gorm:"serializer:json;type:jsonb" json:"-"` // 已退出的群组，与退群在同一事务中写入
+	CreatedAt time.Time       `json:"created_at"`
+	UpdatedAt time.Time       `json:"updated_at"`
+}
+
+// GroupDeparture 注销用户退出所有群组的结果
+type GroupDeparture struct {
+	Left        []string          `json:"left"`        // 退出的群组
+	Transferred map[string]string `json:"transferred"` // 转让群主的群组 -> 新群主
+	Dissolved   []string          `json:"dissolved"`   // 因没有其他成员而解散的群组
+}
+
+// TableName 表名
+func (AccountDeletion) TableName() string {
+	return "account_deletions"
+}
+
+// 账号注销任务状态
+const (
+	DeletionStatusPending    = 0 // 冷静期中，可撤销
+	DeletionStatusProcessing = 1 // 清理中（已被worker领取）
+	DeletionStatusCompleted  = 2 // 已完成
+)
+
+// SecurityEventDeletionRequested 申请注销账号
+const SecurityEventDeletionRequested = "deletion_requested"
+
+// DataExport 个人数据导出任务，由Gateway worker打包后上传到对象存储
+type DataExport struct {
+	ID          uint       `gorm:"primaryKey" json:"id"`
+	UserID      string     `gorm:"index;size:32;not null" json:"user_id"`
+	RequestedBy string     `gorm:"size:32;not null" json:"requested_by"` // 申请者：用户本人ID或运维操作人
+	Status      int        `gorm:"index;default:0" json:"status"`
+	FileKey     string     `gorm:"size:256" json:"file_key"` // 对象存储中的Key
+	Size        int64      `gorm:"default:0" json:"size"`
+	Error       string     `gorm:"size:256" json:"error"`
+	ExpiresAt   *time.Time `gorm:"index" json:"expires_at"` // 导出文件过期时间，过期后文件被删除
+	CreatedAt   time.Time  `json:"created_at"`
+	UpdatedAt   time.Time  `json:"updated_at"`
+}
+
+// TableName 表名
+func (DataExport) TableName() string {
+	return "data_exports"
+}
+
+// 数据导出任务状态
+const (
+	ExportStatusPending    = 0 // 等待打包
+	ExportStatusProcessing = 1 // 打包中（已被worker领取）
+	ExportStatusCompleted  = 2 // 已完成，可下载
+	ExportStatusFailed     = 3 // 失败
+	ExportStatusExpired    = 4 // 已过期，文件已删除
+)
+
+// DefaultExportRetentionHours 导出文件默认保留时间（小时）
+const DefaultExportRetentionHours = 72
+
+// SecurityEventDataExportRequested 申请导出个人数据
+const SecurityEventDataExportRequested = "data_export_requested"
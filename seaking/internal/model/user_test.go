@@ -12,6 +12,36 @@ func TestUser_TableName(t *testing.T) {
 	}
 }
 
+func TestSecurityLog_TableName(t *testing.T) {
+	l := SecurityLog{}
+	if l.TableName() != "security_logs" {
+		t.Errorf("TableName() = %v, want %v", l.TableName(), "security_logs")
+	}
+}
+
+func TestUserTOTP_TableName(t *testing.T) {
+	if (UserTOTP{}).TableName() != "user_totp" {
+		t.Errorf("TableName() = %v, want %v", (UserTOTP{}).TableName(), "user_totp")
+	}
+	if (RecoveryCode{}).TableName() != "recovery_codes" {
+		t.Errorf("TableName() = %v, want %v", (RecoveryCode{}).TableName(), "recovery_codes")
+	}
+}
+
+func TestAccountDeletion_TableName(t *testing.T) {
+	d := AccountDeletion{}
+	if d.TableName() != "account_deletions" {
+		t.Errorf("TableName() = %v, want %v", d.TableName(), "account_deletions")
+	}
+}
+
+func TestDataExport_TableName(t *testing.T) {
+	e := DataExport{}
+	if e.TableName() != "data_exports" {
+		t.Errorf("TableName() = %v, want %v", e.TableName(), "data_exports")
+	}
+}
+
 func TestUser_Fields(t *testing.T) {
 	now := time.Now()
 	u := User{
@@ -152,3 +182,21 @@ func TestUser_StatusTransitions(t *testing.T) {
 		})
 	}
 }
+
+func TestUser_DiscoverableBy(t *testing.T) {
+	u := User{Discoverable: DiscoverDefault}
+	if !u.DiscoverableBy(DiscoverByUsername) {
+		t.Error("default user should be discoverable by username")
+	}
+	if u.DiscoverableBy(DiscoverByEmail) || u.DiscoverableBy(DiscoverByPhone) {
+		t.Error("default user should not be discoverable by email or phone")
+	}
+
+	u.Discoverable = DiscoverByEmail | DiscoverByPhone
+	if u.DiscoverableBy(DiscoverByUsername) {
+		t.Error("user should not be discoverable by username")
+	}
+	if !u.DiscoverableBy(DiscoverByPhone) {
+		t.Error("user should be discoverable by phone")
+	}
+}
@@ -3,9 +3,13 @@ package rpc
 import (
 	"context"
 	"encoding/json"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/my-chat/common/pkg/auth"
+	"github.com/my-chat/common/pkg/crypto"
+	"github.com/my-chat/common/pkg/errors"
+	"github.com/my-chat/seaking/internal/model"
 	"github.com/my-chat/seaking/internal/service/conversation"
 	"github.com/my-chat/seaking/internal/service/group"
 	"github.com/my-chat/seaking/internal/service/key"
@@ -70,8 +74,31 @@ func (h *Handler) registerMethods() {
 	// 用户相关
 	h.methods["seaking.register"] = h.register
 	h.methods["seaking.login"] = h.login
+	h.methods["seaking.verifyLogin"] = h.verifyLogin
 	h.methods["seaking.validateToken"] = h.validateToken
 	h.methods["seaking.getUserInfo"] = h.getUserInfo
+	h.methods["seaking.searchUsers"] = h.searchUsers
+	h.methods["seaking.setDiscoverable"] = h.setDiscoverable
+	h.methods["seaking.updateProfile"] = h.updateProfile
+	h.methods["seaking.changePassword"] = h.changePassword
+	h.methods["seaking.getSecurityLogs"] = h.getSecurityLogs
+	h.methods["seaking.unlockLogin"] = h.unlockLogin
+	h.methods["seaking.getTwoFactorStatus"] = h.getTwoFactorStatus
+	h.methods["seaking.enrollTOTP"] = h.enrollTOTP
+	h.methods["seaking.confirmTOTP"] = h.confirmTOTP
+	h.methods["seaking.disableTOTP"] = h.disableTOTP
+	h.methods["seaking.regenerateRecoveryCodes"] = h.regenerateRecoveryCodes
+	h.methods["seaking.deleteAccount"] = h.deleteAccount
+	h.methods["seaking.cancelAccountDeletion"] = h.cancelAccountDeletion
+	h.methods["seaking.claimDueDeletions"] = h.claimDueDeletions
+	h.methods["seaking.purgeAccount"] = h.purgeAccount
+	h.methods["seaking.requestDataExport"] = h.requestDataExport
+	h.methods["seaking.getDataExports"] = h.getDataExports
+	h.methods["seaking.claimDueExports"] = h.claimDueExports
+	h.methods["seaking.exportUserData"] = h.exportUserData
+	h.methods["seaking.completeDataExport"] = h.completeDataExport
+	h.methods["seaking.getExpiredExports"] = h.getExpiredExports
+	h.methods["seaking.expireDataExport"] = h.expireDataExport
 
 	// 会话相关
 	h.methods["seaking.checkAccess"] = h.checkAccess
@@ -79,6 +106,14 @@ func (h *Handler) registerMethods() {
 	h.methods["seaking.getConversationMembers"] = h.getConversationMembers
 	h.methods["seaking.createConversation"] = h.createConversation
 	h.methods["seaking.getUserConversations"] = h.getUserConversations
+	h.methods["seaking.getConversationPolicy"] = h.getConversationPolicy
+	h.methods["seaking.setConversationPolicy"] = h.setConversationPolicy
+	h.methods["seaking.pinMessage"] = h.pinMessage
+	h.methods["seaking.unpinMessage"] = h.unpinMessage
+	h.methods["seaking.getPinnedMessages"] = h.getPinnedMessages
+	h.methods["seaking.setMessageTTL"] = h.setMessageTTL
+	h.methods["seaking.saveDraft"] = h.saveDraft
+	h.methods["seaking.getDrafts"] = h.getDrafts
 
 	// 好友相关
 	h.methods["seaking.getFriends"] = h.getFriends
@@ -87,12 +122,42 @@ func (h *Handler) registerMethods() {
 	h.methods["seaking.acceptFriendRequest"] = h.acceptFriendRequest
 	h.methods["seaking.rejectFriendRequest"] = h.rejectFriendRequest
 	h.methods["seaking.deleteFriend"] = h.deleteFriend
+	h.methods["seaking.blockUser"] = h.blockUser
+	h.methods["seaking.unblockUser"] = h.unblockUser
+	h.methods["seaking.getBlockedUsers"] = h.getBlockedUsers
 
 	// 群组相关
 	h.methods["seaking.getUserGroups"] = h.getUserGroups
 	h.methods["seaking.createGroup"] = h.createGroup
 	h.methods["seaking.getGroupInfo"] = h.getGroupInfo
 	h.methods["seaking.getGroupMembers"] = h.getGroupMembers
+	h.methods["seaking.updateGroup"] = h.updateGroup
+	h.methods["seaking.dismissGroup"] = h.dismissGroup
+	h.methods["seaking.addGroupMember"] = h.addGroupMember
+	h.methods["seaking.removeGroupMember"] = h.removeGroupMember
+	h.methods["seaking.leaveGroup"] = h.leaveGroup
+	h.methods["seaking.setGroupAdmin"] = h.setGroupAdmin
+	h.methods["seaking.transferGroupOwner"] = h.transferGroupOwner
+	h.methods["seaking.muteGroupMember"] = h.muteGroupMember
+	h.methods["seaking.unmuteGroupMember"] = h.unmuteGroupMember
+	h.methods["seaking.setGroupMuteAll"] = h.setGroupMuteAll
+	h.methods["seaking.setGroupJoinRequests"] = h.setGroupJoinRequests
+	h.methods["seaking.setGroupSlowMode"] = h.setGroupSlowMode
+	h.methods["seaking.createGroupInvite"] = h.createGroupInvite
+	h.methods["seaking.getGroupInvites"] = h.getGroupInvites
+	h.methods["seaking.revokeGroupInvite"] = h.revokeGroupInvite
+	h.methods["seaking.previewGroupInvite"] = h.previewGroupInvite
+	h.methods["seaking.joinGroupByInvite"] = h.joinGroupByInvite
+	h.methods["seaking.requestGroupJoin"] = h.requestGroupJoin
+	h.methods["seaking.getGroupJoinRequests"] = h.getGroupJoinRequests
+	h.methods["seaking.approveGroupJoinRequest"] = h.approveGroupJoinRequest
+	h.methods["seaking.rejectGroupJoinRequest"] = h.rejectGroupJoinRequest
+	h.methods["seaking.setGroupAnnouncement"] = h.setGroupAnnouncement
+	h.methods["seaking.clearGroupAnnouncement"] = h.clearGroupAnnouncement
+	h.methods["seaking.getGroupAnnouncement"] = h.getGroupAnnouncement
+	h.methods["seaking.ackGroupAnnouncement"] = h.ackGroupAnnouncement
+	h.methods["seaking.getGroupAnnouncementHistory"] = h.getGroupAnnouncementHistory
+	h.methods["seaking.setGroupAnnouncementEncryption"] = h.setGroupAnnouncementEncryption
 
 	// 加密密钥相关
 	h.methods["seaking.getUserPublicKey"] = h.getUserPublicKey
@@ -161,15 +226,26 @@ func (h *Handler) checkAccess(ctx context.Context, params json.RawMessage) (inte
 		return nil, err
 	}
 
-	hasAccess, role, muted, err := h.convService.CheckAccess(ctx, req.Uid, req.Cid)
+	access, err := h.convService.CheckAccess(ctx, req.Uid, req.Cid)
 	if err != nil {
 		return nil, err
 	}
 
+	var messageTTL int64
+	var blocked bool
+	if access.HasAccess {
+		messageTTL = h.convService.GetMessageTTL(ctx, req.Cid)
+		blocked = h.convService.IsDirectBlocked(ctx, req.Uid, req.Cid)
+	}
+
 	return map[string]interface{}{
-		"has_access": hasAccess,
-		"role":       role,
-		"muted":      muted,
+		"has_access":  access.HasAccess,
+		"role":        access.Role,
+		"muted":       access.Muted,
+		"can_send":    access.CanSend,
+		"slow_mode":   access.SlowMode,
+		"blocked":     blocked,
+		"message_ttl": messageTTL,
 	}, nil
 }
 
@@ -190,11 +266,12 @@ func (h *Handler) getConversation(ctx context.Context, params json.RawMessage) (
 	memberIds, _ := h.convService.GetConversationMemberIds(ctx, req.Cid)
 
 	return map[string]interface{}{
-		"cid":        conv.ID,
-		"type":       conv.Type,
-		"name":       conv.Name,
-		"avatar":     conv.Avatar,
-		"member_ids": memberIds,
+		"cid":         conv.ID,
+		"type":        conv.Type,
+		"name":        conv.Name,
+		"avatar":      conv.Avatar,
+		"member_ids":  memberIds,
+		"message_ttl": conv.MessageTTL,
 	}, nil
 }
 
@@ -262,15 +339,23 @@ func (h *Handler) getUserConversations(ctx context.Context, params json.RawMessa
 		return nil, err
 	}
 
+	memberships, err := h.convService.GetUserMemberships(ctx, req.Uid)
+	if err != nil {
+		return nil, err
+	}
+
 	var convInfos []map[string]interface{}
 	for _, c := range convs {
 		memberIds, _ := h.convService.GetConversationMemberIds(ctx, c.ID)
 		convInfos = append(convInfos, map[string]interface{}{
-			"cid":        c.ID,
-			"type":       c.Type,
-			"name":       c.Name,
-			"avatar":     c.Avatar,
-			"member_ids": memberIds,
+			"cid":         c.ID,
+			"type":        c.Type,
+			"name":        c.Name,
+			"avatar":      c.Avatar,
+			"member_ids":  memberIds,
+			"message_ttl": c.MessageTTL,
+			"pinned":      memberships[c.ID].Pinned,
+			"muted":       memberships[c.ID].Muted,
 		})
 	}
 
@@ -279,6 +364,172 @@ func (h *Handler) getUserConversations(ctx context.Context, params json.RawMessa
 	}, nil
 }
 
+// getConversationPolicy 获取会话消息策略
+func (h *Handler) getConversationPolicy(ctx context.Context, params json.RawMessage) (interface{}, error) {
+	var req struct {
+		Cid string `json:"cid"`
+	}
+	if err := json.Unmarshal(params, &req); err != nil {
+		return nil, err
+	}
+
+	policy, err := h.convService.GetPolicy(ctx, req.Cid)
+	if err != nil {
+		return nil, err
+	}
+
+	return policyInfo(policy), nil
+}
+
+// setConversationPolicy 设置会话消息策略
+func (h *Handler) setConversationPolicy(ctx context.Context, params json.RawMessage) (interface{}, error) {
+	var req conversation.SetPolicyRequest
+	if err := json.Unmarshal(params, &req); err != nil {
+		return nil, err
+	}
+
+	policy, err := h.convService.SetPolicy(ctx, &req)
+	if err != nil {
+		return nil, err
+	}
+
+	return policyInfo(policy), nil
+}
+
+// policyInfo 会话消息策略返回结构
+func policyInfo(policy *model.ConversationPolicy) map[string]interface{} {
+	return map[string]interface{}{
+		"cid":              policy.ConversationID,
+		"revoke_window":    policy.RevokeWindow,
+		"edit_window":      policy.EditWindow,
+		"editable_kinds":   policy.EditableKinds,
+		"admin_revoke_any": policy.AdminRevokeAny,
+	}
+}
+
+// pinMessage 置顶消息
+func (h *Handler) pinMessage(ctx context.Context, params json.RawMessage) (interface{}, error) {
+	var req struct {
+		Cid string `json:"cid"`
+		Uid string `json:"uid"`
+		Mid int64  `json:"mid"`
+	}
+	if err := json.Unmarshal(params, &req); err != nil {
+		return nil, err
+	}
+
+	pin, err := h.convService.PinMessage(ctx, req.Cid, req.Uid, req.Mid)
+	if err != nil {
+		return nil, err
+	}
+
+	return pin, nil
+}
+
+// unpinMessage 取消置顶消息
+func (h *Handler) unpinMessage(ctx context.Context, params json.RawMessage) (interface{}, error) {
+	var req struct {
+		Cid string `json:"cid"`
+		Uid string `json:"uid"`
+		Mid int64  `json:"mid"`
+	}
+	if err := json.Unmarshal(params, &req); err != nil {
+		return nil, err
+	}
+
+	if err := h.convService.UnpinMessage(ctx, req.Cid, req.Uid, req.Mid); err != nil {
+		return nil, err
+	}
+
+	return map[string]interface{}{
+		"success": true,
+	}, nil
+}
+
+// setMessageTTL 设置会话消息过期时间
+func (h *Handler) setMessageTTL(ctx context.Context, params json.RawMessage) (interface{}, error) {
+	var req struct {
+		Cid string `json:"cid"`
+		Uid string `json:"uid"`
+		TTL int64  `json:"ttl"`
+	}
+	if err := json.Unmarshal(params, &req); err != nil {
+		return nil, err
+	}
+
+	conv, err := h.convService.SetMessageTTL(ctx, req.Cid, req.Uid, req.TTL)
+	if err != nil {
+		return nil, err
+	}
+
+	return map[string]interface{}{
+		"cid":         conv.ID,
+		"message_ttl": conv.MessageTTL,
+	}, nil
+}
+
+// saveDraft 保存草稿（data为空表示清除）
+func (h *Handler) saveDraft(ctx context.Context, params json.RawMessage) (interface{}, error) {
+	var req struct {
+		Uid  string `json:"uid"`
+		Cid  string `json:"cid"`
+		Data string `json:"data"`
+		Ts   int64  `json:"ts"`
+	}
+	if err := json.Unmarshal(params, &req); err != nil {
+		return nil, err
+	}
+
+	draft, applied, err := h.convService.SaveDraft(ctx, req.Uid, req.Cid, req.Data, req.Ts)
+	if err != nil {
+		return nil, err
+	}
+
+	return map[string]interface{}{
+		"draft":   draft,
+		"applied": applied,
+	}, nil
+}
+
+// getDrafts 获取用户草稿
+func (h *Handler) getDrafts(ctx context.Context, params json.RawMessage) (interface{}, error) {
+	var req struct {
+		Uid string `json:"uid"`
+		Cid string `json:"cid"`
+	}
+	if err := json.Unmarshal(params, &req); err != nil {
+		return nil, err
+	}
+
+	drafts, err := h.convService.GetDrafts(ctx, req.Uid, req.Cid)
+	if err != nil {
+		return nil, err
+	}
+
+	return map[string]interface{}{
+		"drafts": drafts,
+	}, nil
+}
+
+// getPinnedMessages 获取会话置顶消息
+func (h *Handler) getPinnedMessages(ctx context.Context, params json.RawMessage) (interface{}, error) {
+	var req struct {
+		Cid string `json:"cid"`
+	}
+	if err := json.Unmarshal(params, &req); err != nil {
+		return nil, err
+	}
+
+	pins, err := h.convService.GetPinnedMessages(ctx, req.Cid)
+	if err != nil {
+		return nil, err
+	}
+
+	return map[string]interface{}{
+		"pins": pins,
+	}, nil
+}
+
 // validateToken 验证Token
 func (h *Handler) validateToken(ctx context.Context, params json.RawMessage) (interface{}, error) {
 	var req struct {
@@ -288,7 +539,7 @@ func (h *Handler) validateToken(ctx context.Context, params json.RawMessage) (in
 		return nil, err
 	}
 
-	claims, err := h.jwtManager.ParseToken(req.Token)
+	claims, err := h.jwtManager.ValidateToken(ctx, req.Token)
 	if err != nil {
 		return map[string]interface{}{
 			"valid": false,
@@ -326,6 +577,120 @@ func (h *Handler) getUserInfo(ctx context.Context, params json.RawMessage) (inte
 	}, nil
 }
 
+// searchUsers 搜索用户，只返回公开资料和公钥指纹
+func (h *Handler) searchUsers(ctx context.Context, params json.RawMessage) (interface{}, error) {
+	var req struct {
+		Uid string `json:"uid"`
+		user.SearchRequest
+	}
+	if err := json.Unmarshal(params, &req); err != nil {
+		return nil, err
+	}
+
+	users, nextCursor, err := h.userService.SearchUsers(ctx, req.Uid, &req.SearchRequest)
+	if err != nil {
+		return nil, err
+	}
+
+	uids := make([]string, 0, len(users))
+	for _, u := range users {
+		uids = append(uids, u.ID)
+	}
+	publicKeys, err := h.keyService.GetUserPublicKeys(ctx, uids)
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([]map[string]interface{}, 0, len(users))
+	for _, u := range users {
+		// 未上传密钥或密钥无法解析时指纹为空
+		fingerprint := ""
+		if pk, ok := publicKeys[u.ID]; ok {
+			fingerprint, _ = crypto.PublicKeyFingerprint(pk)
+		}
+		results = append(results, map[string]interface{}{
+			"uid":                    u.ID,
+			"username":               u.Username,
+			"nickname":               u.Nickname,
+			"avatar":                 u.Avatar,
+			"public_key_fingerprint": fingerprint,
+		})
+	}
+
+	return map[string]interface{}{
+		"users":       results,
+		"next_cursor": nextCursor,
+	}, nil
+}
+
+// setDiscoverable 设置可被搜索的方式
+func (h *Handler) setDiscoverable(ctx context.Context, params json.RawMessage) (interface{}, error) {
+	var req struct {
+		Uid          string `json:"uid"`
+		Discoverable int    `json:"discoverable"`
+	}
+	if err := json.Unmarshal(params, &req); err != nil {
+		return nil, err
+	}
+
+	if err := h.userService.SetDiscoverable(ctx, req.Uid, req.Discoverable); err != nil {
+		return nil, err
+	}
+
+	return map[string]interface{}{
+		"success": true,
+	}, nil
+}
+
+// updateProfile 更新用户资料
+func (h *Handler) updateProfile(ctx context.Context, params json.RawMessage) (interface{}, error) {
+	var req struct {
+		Uid      string `json:"uid"`
+		Nickname string `json:"nickname"`
+		Avatar   string `json:"avatar"`
+	}
+	if err := json.Unmarshal(params, &req); err != nil {
+		return nil, err
+	}
+
+	if err := h.userService.UpdateProfile(ctx, req.Uid, req.Nickname, req.Avatar); err != nil {
+		return nil, err
+	}
+
+	return h.getUserInfo(ctx, params)
+}
+
+// changePassword 修改密码，注销该用户的其他会话并为当前设备签发新Token
+func (h *Handler) changePassword(ctx context.Context, params json.RawMessage) (interface{}, error) {
+	var req struct {
+		Uid      string `json:"uid"`
+		DeviceId string `json:"device_id"`
+		Platform string `json:"platform"`
+		user.ChangePasswordRequest
+	}
+	if err := json.Unmarshal(params, &req); err != nil {
+		return nil, err
+	}
+
+	if err := h.userService.ChangePassword(ctx, req.Uid, &req.ChangePasswordRequest); err != nil {
+		return nil, err
+	}
+
+	// 密码已修改，注销失败时需提示客户端重试登出其他设备
+	if err := h.jwtManager.RevokeSessions(ctx, req.Uid); err != nil {
+		return nil, errors.New(errors.ErrCodeInternal, "password changed but failed to revoke sessions")
+	}
+
+	token, err := h.jwtManager.GenerateToken(req.Uid, req.DeviceId, req.Platform)
+	if err != nil {
+		return nil, err
+	}
+
+	return map[string]interface{}{
+		"token": token,
+	}, nil
+}
+
 // register 用户注册
 func (h *Handler) register(ctx context.Context, params json.RawMessage) (interface{}, error) {
 	var req struct {
@@ -380,6 +745,7 @@ func (h *Handler) login(ctx context.Context, params json.RawMessage) (interface{
 		Password string `json:"password"`
 		DeviceId string `json:"device_id"`
 		Platform string `json:"platform"`
+		IP       string `json:"ip"`
 	}
 	if err := json.Unmarshal(params, &req); err != nil {
 		return nil, err
@@ -388,12 +754,60 @@ func (h *Handler) login(ctx context.Context, params json.RawMessage) (interface{
 	u, err := h.userService.Login(ctx, &user.LoginRequest{
 		Username: req.Username,
 		Password: req.Password,
+		IP:       req.IP,
+		DeviceId: req.DeviceId,
 	})
 	if err != nil {
 		return nil, err
 	}
 
-	token, err := h.jwtManager.GenerateToken(u.ID, req.DeviceId, req.Platform)
+	// 启用两步验证时只返回挑战Token，需调用 verifyLogin 提交验证码
+	enabled, err := h.userService.TOTPEnabled(ctx, u.ID)
+	if err != nil {
+		return nil, err
+	}
+	if enabled {
+		challenge, err := h.jwtManager.GenerateChallengeToken(u.ID, req.DeviceId, req.Platform)
+		if err != nil {
+			return nil, err
+		}
+		return map[string]interface{}{
+			"two_factor_required": true,
+			"challenge_token":     challenge,
+			"expires_in":          int(auth.ChallengeTTL.Seconds()),
+		}, nil
+	}
+
+	return h.loginResult(ctx, u, req.DeviceId, req.Platform)
+}
+
+// verifyLogin 提交两步验证码（或恢复码），完成登录
+func (h *Handler) verifyLogin(ctx context.Context, params json.RawMessage) (interface{}, error) {
+	var req struct {
+		ChallengeToken string `json:"challenge_token"`
+		Code           string `json:"code"`
+		IP             string `json:"ip"`
+	}
+	if err := json.Unmarshal(params, &req); err != nil {
+		return nil, err
+	}
+
+	claims, err := h.jwtManager.ParseChallengeToken(req.ChallengeToken)
+	if err != nil {
+		return nil, errors.ErrInvalidToken
+	}
+
+	u, err := h.userService.VerifyLogin(ctx, claims.Uid, req.Code, req.IP, claims.DeviceId)
+	if err != nil {
+		return nil, err
+	}
+
+	return h.loginResult(ctx, u, claims.DeviceId, claims.Platform)
+}
+
+// loginResult 签发访问Token并组装登录结果
+func (h *Handler) loginResult(ctx context.Context, u *model.User, deviceId, platform string) (interface{}, error) {
+	token, err := h.jwtManager.GenerateToken(u.ID, deviceId, platform)
 	if err != nil {
 		return nil, err
 	}
@@ -564,125 +978,1398 @@ func (h *Handler) deleteFriend(ctx context.Context, params json.RawMessage) (int
 	}, nil
 }
 
-// getUserGroups 获取用户群组列表
-func (h *Handler) getUserGroups(ctx context.Context, params json.RawMessage) (interface{}, error) {
+// blockUser 拉黑用户
+func (h *Handler) blockUser(ctx context.Context, params json.RawMessage) (interface{}, error) {
 	var req struct {
-		Uid string `json:"uid"`
+		Uid       string `json:"uid"`
+		TargetUid string `json:"target_uid"`
 	}
 	if err := json.Unmarshal(params, &req); err != nil {
 		return nil, err
 	}
 
-	groups, err := h.groupService.GetUserGroups(ctx, req.Uid)
-	if err != nil {
+	if _, err := h.userService.GetByID(ctx, req.TargetUid); err != nil {
 		return nil, err
 	}
 
-	var groupInfos []map[string]interface{}
-	for _, g := range groups {
-		groupInfos = append(groupInfos, map[string]interface{}{
-			"id":          g.ID,
-			"name":        g.Name,
-			"description": g.Description,
-			"avatar":      g.Avatar,
-			"owner_id":    g.OwnerID,
-			"max_members": g.MaxMembers,
-		})
+	if err := h.relationService.BlockUser(ctx, req.Uid, req.TargetUid); err != nil {
+		return nil, err
 	}
 
 	return map[string]interface{}{
-		"groups": groupInfos,
+		"success": true,
 	}, nil
 }
 
-// createGroup 创建群组
-func (h *Handler) createGroup(ctx context.Context, params json.RawMessage) (interface{}, error) {
+// unblockUser 取消拉黑
+func (h *Handler) unblockUser(ctx context.Context, params json.RawMessage) (interface{}, error) {
 	var req struct {
-		OwnerId     string   `json:"owner_id"`
-		Name        string   `json:"name"`
-		Description string   `json:"description"`
-		MemberIds   []string `json:"member_ids"`
+		Uid       string `json:"uid"`
+		TargetUid string `json:"target_uid"`
 	}
 	if err := json.Unmarshal(params, &req); err != nil {
 		return nil, err
 	}
 
-	g, err := h.groupService.CreateGroup(ctx, req.OwnerId, &group.CreateGroupRequest{
-		Name:        req.Name,
-		Description: req.Description,
-		MemberIDs:   req.MemberIds,
-	})
-	if err != nil {
+	if err := h.relationService.UnblockUser(ctx, req.Uid, req.TargetUid); err != nil {
 		return nil, err
 	}
 
 	return map[string]interface{}{
-		"id":          g.ID,
-		"name":        g.Name,
-		"description": g.Description,
-		"avatar":      g.Avatar,
-		"owner_id":    g.OwnerID,
+		"success": true,
+	}, nil
+}
+
+// getTwoFactorStatus 获取两步验证状态
+func (h *Handler) getTwoFactorStatus(ctx context.Context, params json.RawMessage) (interface{}, error) {
+	var req struct {
+		Uid string `json:"uid"`
+	}
+	if err := json.Unmarshal(params, &req); err != nil {
+		return nil, err
+	}
+
+	enabled, err := h.userService.TOTPEnabled(ctx, req.Uid)
+	if err != nil {
+		return nil, err
+	}
+	remaining, err := h.userService.RemainingRecoveryCodes(ctx, req.Uid)
+	if err != nil {
+		return nil, err
+	}
+
+	return map[string]interface{}{
+		"enabled":                  enabled,
+		"recovery_codes_remaining": remaining,
+	}, nil
+}
+
+// enrollTOTP 开始启用两步验证
+func (h *Handler) enrollTOTP(ctx context.Context, params json.RawMessage) (interface{}, error) {
+	var req struct {
+		Uid string `json:"uid"`
+	}
+	if err := json.Unmarshal(params, &req); err != nil {
+		return nil, err
+	}
+
+	secret, uri, err := h.userService.EnrollTOTP(ctx, req.Uid)
+	if err != nil {
+		return nil, err
+	}
+
+	return map[string]interface{}{
+		"secret": secret,
+		"uri":    uri,
+	}, nil
+}
+
+// confirmTOTP 验证验证码并启用两步验证
+func (h *Handler) confirmTOTP(ctx context.Context, params json.RawMessage) (interface{}, error) {
+	var req struct {
+		Uid  string `json:"uid"`
+		Code string `json:"code"`
+	}
+	if err := json.Unmarshal(params, &req); err != nil {
+		return nil, err
+	}
+
+	codes, err := h.userService.ConfirmTOTP(ctx, req.Uid, req.Code)
+	if err != nil {
+		return nil, err
+	}
+
+	return map[string]interface{}{
+		"recovery_codes": codes,
+	}, nil
+}
+
+// disableTOTP 关闭两步验证
+func (h *Handler) disableTOTP(ctx context.Context, params json.RawMessage) (interface{}, error) {
+	var req struct {
+		Uid      string `json:"uid"`
+		Password string `json:"password"`
+		Code     string `json:"code"`
+	}
+	if err := json.Unmarshal(params, &req); err != nil {
+		return nil, err
+	}
+
+	if err := h.userService.DisableTOTP(ctx, req.Uid, req.Password, req.Code); err != nil {
+		return nil, err
+	}
+
+	return map[string]interface{}{
+		"success": true,
+	}, nil
+}
+
+// regenerateRecoveryCodes 重新生成恢复码
+func (h *Handler) regenerateRecoveryCodes(ctx context.Context, params json.RawMessage) (interface{}, error) {
+	var req struct {
+		Uid  string `json:"uid"`
+		Code string `json:"code"`
+	}
+	if err := json.Unmarshal(params, &req); err != nil {
+		return nil, err
+	}
+
+	codes, err := h.userService.RegenerateRecoveryCodes(ctx, req.Uid, req.Code)
+	if err != nil {
+		return nil, err
+	}
+
+	return map[string]interface{}{
+		"recovery_codes": codes,
+	}, nil
+}
+
+// deleteAccount 申请注销账号，立即禁用账号并注销所有会话
+func (h *Handler) deleteAccount(ctx context.Context, params json.RawMessage) (interface{}, error) {
+	var req struct {
+		Uid      string `json:"uid"`
+		Password string `json:"password"`
+		IP       string `json:"ip"`
+		DeviceId string `json:"device_id"`
+	}
+	if err := json.Unmarshal(params, &req); err != nil {
+		return nil, err
+	}
+
+	deletion, err := h.userService.RequestDeletion(ctx, req.Uid, req.Password, req.IP, req.DeviceId)
+	if err != nil {
+		return nil, err
+	}
+
+	// 重复申请时再次注销会话，保证失败后可重试
+	if err := h.jwtManager.RevokeSessions(ctx, req.Uid); err != nil {
+		return nil, errors.New(errors.ErrCodeInternal, "account deletion scheduled but failed to revoke sessions")
+	}
+
+	return map[string]interface{}{
+		"purge_at": deletion.PurgeAt.Unix(),
+	}, nil
+}
+
+// cancelAccountDeletion 冷静期内撤销注销（管理接口，不经网关暴露）
+func (h *Handler) cancelAccountDeletion(ctx context.Context, params json.RawMessage) (interface{}, error) {
+	var req struct {
+		Uid string `json:"uid"`
+	}
+	if err := json.Unmarshal(params, &req); err != nil {
+		return nil, err
+	}
+
+	if err := h.userService.CancelDeletion(ctx, req.Uid); err != nil {
+		return nil, err
+	}
+
+	return map[string]interface{}{
+		"success": true,
+	}, nil
+}
+
+// claimDueDeletions 领取冷静期已结束的注销任务（供Gateway worker调用）
+func (h *Handler) claimDueDeletions(ctx context.Context, params json.RawMessage) (interface{}, error) {
+	var req struct {
+		Limit int `json:"limit"`
+	}
+	if err := json.Unmarshal(params, &req); err != nil {
+		return nil, err
+	}
+
+	deletions, err := h.userService.ClaimDueDeletions(ctx, req.Limit)
+	if err != nil {
+		return nil, err
+	}
+
+	uids := make([]string, 0, len(deletions))
+	for _, d := range deletions {
+		uids = append(uids, d.UserID)
+	}
+
+	return map[string]interface{}{
+		"uids": uids,
+	}, nil
+}
+
+// purgeAccount 清理已注销用户在SeaKing中的数据（Relay中的数据需先清理完成）
+// 每一步都可重复执行，中断后由worker重新领取并从头执行；返回的退群结果包含之前中断的执行中已退出的群组
+func (h *Handler) purgeAccount(ctx context.Context, params json.RawMessage) (interface{}, error) {
+	var req struct {
+		Uid string `json:"uid"`
+	}
+	if err := json.Unmarshal(params, &req); err != nil {
+		return nil, err
+	}
+
+	deletion, err := h.userService.GetDeletion(ctx, req.Uid)
+	if err != nil {
+		return nil, err
+	}
+	if deletion.Status == model.DeletionStatusPending {
+		return nil, errors.New(errors.ErrCodeInvalidParam, "account deletion not claimed")
+	}
+
+	departure, err := h.groupService.DepartUser(ctx, req.Uid)
+	if err != nil {
+		return nil, err
+	}
+	if err := h.relationService.PurgeUser(ctx, req.Uid); err != nil {
+		return nil, err
+	}
+	if err := h.convService.PurgeUser(ctx, req.Uid); err != nil {
+		return nil, err
+	}
+	if err := h.keyService.DeleteUserKeys(ctx, req.Uid); err != nil {
+		return nil, err
+	}
+	if err := h.userService.PurgeUser(ctx, req.Uid); err != nil {
+		return nil, err
+	}
+
+	return departure, nil
+}
+
+// requestDataExport 申请导出个人数据，requested_by 为空时视为用户本人申请
+func (h *Handler) requestDataExport(ctx context.Context, params json.RawMessage) (interface{}, error) {
+	var req struct {
+		Uid         string `json:"uid"`
+		RequestedBy string `json:"requested_by"`
+		IP          string `json:"ip"`
+		DeviceId    string `json:"device_id"`
+	}
+	if err := json.Unmarshal(params, &req); err != nil {
+		return nil, err
+	}
+	if req.Uid == "" {
+		return nil, errors.ErrInvalidParam
+	}
+	if req.RequestedBy == "" {
+		req.RequestedBy = req.Uid
+	}
+
+	export, err := h.userService.RequestExport(ctx, req.Uid, req.RequestedBy, req.IP, req.DeviceId)
+	if err != nil {
+		return nil, err
+	}
+
+	return dataExportInfo(export), nil
+}
+
+// getDataExports 获取用户最近的导出记录
+func (h *Handler) getDataExports(ctx context.Context, params json.RawMessage) (interface{}, error) {
+	var req struct {
+		Uid string `json:"uid"`
+	}
+	if err := json.Unmarshal(params, &req); err != nil {
+		return nil, err
+	}
+
+	exports, err := h.userService.ListExports(ctx, req.Uid)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]map[string]interface{}, 0, len(exports))
+	for i := range exports {
+		result = append(result, dataExportInfo(&exports[i]))
+	}
+
+	return map[string]interface{}{
+		"exports": result,
+	}, nil
+}
+
+// claimDueExports 领取待打包的导出任务（供Gateway worker调用）
+func (h *Handler) claimDueExports(ctx context.Context, params json.RawMessage) (interface{}, error) {
+	var req struct {
+		Limit int `json:"limit"`
+	}
+	if err := json.Unmarshal(params, &req); err != nil {
+		return nil, err
+	}
+
+	exports, err := h.userService.ClaimDueExports(ctx, req.Limit)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]map[string]interface{}, 0, len(exports))
+	for i := range exports {
+		result = append(result, dataExportInfo(&exports[i]))
+	}
+
+	return map[string]interface{}{
+		"exports": result,
+	}, nil
+}
+
+// exportUserData 收集用户在SeaKing中的全部数据（密钥仍为加密状态）
+func (h *Handler) exportUserData(ctx context.Context, params json.RawMessage) (interface{}, error) {
+	var req struct {
+		Uid string `json:"uid"`
+	}
+	if err := json.Unmarshal(params, &req); err != nil {
+		return nil, err
+	}
+
+	account, err := h.userService.ExportAccount(ctx, req.Uid)
+	if err != nil {
+		return nil, err
+	}
+
+	friends, err := h.relationService.GetFriends(ctx, req.Uid)
+	if err != nil {
+		return nil, err
+	}
+	requests, err := h.relationService.GetUserFriendRequests(ctx, req.Uid)
+	if err != nil {
+		return nil, err
+	}
+	blocks, err := h.relationService.GetBlockedUsers(ctx, req.Uid)
+	if err != nil {
+		return nil, err
+	}
+
+	groups, err := h.groupService.GetUserGroups(ctx, req.Uid)
+	if err != nil {
+		return nil, err
+	}
+	groupMemberships, err := h.groupService.GetUserMemberships(ctx, req.Uid)
+	if err != nil {
+		return nil, err
+	}
+	groupByID := make(map[string]model.Group, len(groups))
+	for _, g := range groups {
+		groupByID[g.ID] = g
+	}
+	groupList := make([]map[string]interface{}, 0, len(groupMemberships))
+	for _, m := range groupMemberships {
+		g, ok := groupByID[m.GroupID]
+		if !ok {
+			continue
+		}
+		groupList = append(groupList, map[string]interface{}{
+			"group":  g,
+			"member": m,
+		})
+	}
+
+	convs, err := h.convService.GetUserConversations(ctx, req.Uid)
+	if err != nil {
+		return nil, err
+	}
+	convMemberships, err := h.convService.GetUserMemberships(ctx, req.Uid)
+	if err != nil {
+		return nil, err
+	}
+	drafts, err := h.convService.GetDrafts(ctx, req.Uid, "")
+	if err != nil {
+		return nil, err
+	}
+	cids := make([]string, 0, len(convs))
+	convList := make([]map[string]interface{}, 0, len(convs))
+	for _, c := range convs {
+		cids = append(cids, c.ID)
+		convList = append(convList, map[string]interface{}{
+			"conversation": c,
+			"member":       convMemberships[c.ID],
+		})
+	}
+
+	var userKey *model.UserKey
+	if k, err := h.keyService.GetUserKey(ctx, req.Uid); err == nil {
+		userKey = k
+	} else if !errors.IsError(err, errors.ErrCodeNotFound) {
+		return nil, err
+	}
+	chatKeys, err := h.keyService.GetUserChatKeys(ctx, req.Uid)
+	if err != nil {
+		return nil, err
+	}
+	groupKeys, err := h.keyService.GetUserGroupKeys(ctx, req.Uid)
+	if err != nil {
+		return nil, err
+	}
+
+	return map[string]interface{}{
+		"profile": map[string]interface{}{
+			"user":               account.Profile,
+			"two_factor_enabled": account.TwoFactorEnabled,
+		},
+		"security_logs": account.SecurityLogs,
+		"relations": map[string]interface{}{
+			"friends":         friends,
+			"friend_requests": requests,
+			"blocked_users":   blocks,
+		},
+		"groups": groupList,
+		"conversations": map[string]interface{}{
+			"conversations": convList,
+			"drafts":        drafts,
+		},
+		"keys": map[string]interface{}{
+			"user_key":   userKey,
+			"chat_keys":  chatKeys,
+			"group_keys": groupKeys,
+		},
+		"cids": cids,
+	}, nil
+}
+
+// completeDataExport 记录打包结果（供Gateway worker调用），error 不为空表示失败
+func (h *Handler) completeDataExport(ctx context.Context, params json.RawMessage) (interface{}, error) {
+	var req struct {
+		ID      uint   `json:"id"`
+		FileKey string `json:"file_key"`
+		Size    int64  `json:"size"`
+		Error   string `json:"error"`
+	}
+	if err := json.Unmarshal(params, &req); err != nil {
+		return nil, err
+	}
+	if req.Error == "" && req.FileKey == "" {
+		return nil, errors.ErrInvalidParam
+	}
+
+	export, err := h.userService.CompleteExport(ctx, req.ID, req.FileKey, req.Size, req.Error)
+	if err != nil {
+		return nil, err
+	}
+
+	return dataExportInfo(export), nil
+}
+
+// getExpiredExports 获取文件已过期的导出任务（供Gateway worker删除文件）
+func (h *Handler) getExpiredExports(ctx context.Context, params json.RawMessage) (interface{}, error) {
+	var req struct {
+		Limit int `json:"limit"`
+	}
+	if err := json.Unmarshal(params, &req); err != nil {
+		return nil, err
+	}
+
+	exports, err := h.userService.GetExpiredExports(ctx, req.Limit)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]map[string]interface{}, 0, len(exports))
+	for i := range exports {
+		result = append(result, dataExportInfo(&exports[i]))
+	}
+
+	return map[string]interface{}{
+		"exports": result,
+	}, nil
+}
+
+// expireDataExport 导出文件删除后标记任务已过期
+func (h *Handler) expireDataExport(ctx context.Context, params json.RawMessage) (interface{}, error) {
+	var req struct {
+		ID uint `json:"id"`
+	}
+	if err := json.Unmarshal(params, &req); err != nil {
+		return nil, err
+	}
+
+	if err := h.userService.ExpireExport(ctx, req.ID); err != nil {
+		return nil, err
+	}
+
+	return map[string]interface{}{
+		"success": true,
+	}, nil
+}
+
+// dataExportInfo 导出任务信息
+func dataExportInfo(e *model.DataExport) map[string]interface{} {
+	var expiresAt int64
+	if e.ExpiresAt != nil {
+		expiresAt = e.ExpiresAt.Unix()
+	}
+	return map[string]interface{}{
+		"id":           e.ID,
+		"uid":          e.UserID,
+		"requested_by": e.RequestedBy,
+		"status":       e.Status,
+		"file_key":     e.FileKey,
+		"size":         e.Size,
+		"error":        e.Error,
+		"expires_at":   expiresAt,
+		"created_at":   e.CreatedAt.Unix(),
+	}
+}
+
+// getSecurityLogs 获取用户安全日志
+func (h *Handler) getSecurityLogs(ctx context.Context, params json.RawMessage) (interface{}, error) {
+	var req struct {
+		Uid    string `json:"uid"`
+		Cursor uint   `json:"cursor"`
+		Limit  int    `json:"limit"`
+	}
+	if err := json.Unmarshal(params, &req); err != nil {
+		return nil, err
+	}
+
+	logs, err := h.userService.GetSecurityLogs(ctx, req.Uid, req.Cursor, req.Limit)
+	if err != nil {
+		return nil, err
+	}
+
+	var nextCursor uint
+	result := make([]map[string]interface{}, 0, len(logs))
+	for _, l := range logs {
+		result = append(result, map[string]interface{}{
+			"id":         l.ID,
+			"event":      l.Event,
+			"ip":         l.IP,
+			"device_id":  l.DeviceID,
+			"created_at": l.CreatedAt.Unix(),
+		})
+		nextCursor = l.ID
+	}
+
+	return map[string]interface{}{
+		"logs":        result,
+		"next_cursor": nextCursor,
+	}, nil
+}
+
+// unlockLogin 解除登录锁定（管理接口，不经网关暴露）
+func (h *Handler) unlockLogin(ctx context.Context, params json.RawMessage) (interface{}, error) {
+	var req struct {
+		Username string `json:"username"`
+		IP       string `json:"ip"`
+	}
+	if err := json.Unmarshal(params, &req); err != nil {
+		return nil, err
+	}
+	if req.Username == "" && req.IP == "" {
+		return nil, errors.ErrInvalidParam
+	}
+
+	if err := h.userService.UnlockLogin(ctx, req.Username, req.IP); err != nil {
+		return nil, err
+	}
+
+	return map[string]interface{}{
+		"success": true,
+	}, nil
+}
+
+// getBlockedUsers 获取拉黑列表
+func (h *Handler) getBlockedUsers(ctx context.Context, params json.RawMessage) (interface{}, error) {
+	var req struct {
+		Uid string `json:"uid"`
+	}
+	if err := json.Unmarshal(params, &req); err != nil {
+		return nil, err
+	}
+
+	blocks, err := h.relationService.GetBlockedUsers(ctx, req.Uid)
+	if err != nil {
+		return nil, err
+	}
+
+	var users []map[string]interface{}
+	for _, b := range blocks {
+		u, err := h.userService.GetByID(ctx, b.BlockedID)
+		if err != nil {
+			continue
+		}
+		users = append(users, map[string]interface{}{
+			"uid":        u.ID,
+			"username":   u.Username,
+			"nickname":   u.Nickname,
+			"avatar":     u.Avatar,
+			"blocked_at": b.CreatedAt.Unix(),
+		})
+	}
+
+	return map[string]interface{}{
+		"users": users,
+	}, nil
+}
+
+// getUserGroups 获取用户群组列表
+func (h *Handler) getUserGroups(ctx context.Context, params json.RawMessage) (interface{}, error) {
+	var req struct {
+		Uid string `json:"uid"`
+	}
+	if err := json.Unmarshal(params, &req); err != nil {
+		return nil, err
+	}
+
+	groups, err := h.groupService.GetUserGroups(ctx, req.Uid)
+	if err != nil {
+		return nil, err
+	}
+
+	var groupInfos []map[string]interface{}
+	for _, g := range groups {
+		groupInfos = append(groupInfos, map[string]interface{}{
+			"id":                     g.ID,
+			"name":                   g.Name,
+			"description":            g.Description,
+			"avatar":                 g.Avatar,
+			"owner_id":               g.OwnerID,
+			"max_members":            g.MaxMembers,
+			"announcement_revision":  g.AnnouncementRevision,
+			"announcement_encrypted": g.AnnouncementEncrypted,
+			"mute_all":               g.MuteAll,
+			"slow_mode":              g.SlowMode,
+			"join_requests_enabled":  g.JoinRequestsEnabled,
+		})
+	}
+
+	return map[string]interface{}{
+		"groups": groupInfos,
+	}, nil
+}
+
+// createGroup 创建群组
+func (h *Handler) createGroup(ctx context.Context, params json.RawMessage) (interface{}, error) {
+	var req struct {
+		OwnerId     string   `json:"owner_id"`
+		Name        string   `json:"name"`
+		Description string   `json:"description"`
+		MemberIds   []string `json:"member_ids"`
+	}
+	if err := json.Unmarshal(params, &req); err != nil {
+		return nil, err
+	}
+
+	g, err := h.groupService.CreateGroup(ctx, req.OwnerId, &group.CreateGroupRequest{
+		Name:        req.Name,
+		Description: req.Description,
+		MemberIDs:   req.MemberIds,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return map[string]interface{}{
+		"id":          g.ID,
+		"name":        g.Name,
+		"description": g.Description,
+		"avatar":      g.Avatar,
+		"owner_id":    g.OwnerID,
 		"max_members": g.MaxMembers,
 	}, nil
 }
 
-// getGroupInfo 获取群组信息
-func (h *Handler) getGroupInfo(ctx context.Context, params json.RawMessage) (interface{}, error) {
+// getGroupInfo 获取群组信息
+func (h *Handler) getGroupInfo(ctx context.Context, params json.RawMessage) (interface{}, error) {
+	var req struct {
+		GroupId string `json:"group_id"`
+	}
+	if err := json.Unmarshal(params, &req); err != nil {
+		return nil, err
+	}
+
+	g, err := h.groupService.GetGroup(ctx, req.GroupId)
+	if err != nil {
+		return nil, err
+	}
+
+	return map[string]interface{}{
+		"id":                     g.ID,
+		"name":                   g.Name,
+		"description":            g.Description,
+		"avatar":                 g.Avatar,
+		"owner_id":               g.OwnerID,
+		"max_members":            g.MaxMembers,
+		"announcement_revision":  g.AnnouncementRevision,
+		"announcement_encrypted": g.AnnouncementEncrypted,
+		"mute_all":               g.MuteAll,
+		"slow_mode":              g.SlowMode,
+		"join_requests_enabled":  g.JoinRequestsEnabled,
+	}, nil
+}
+
+// getGroupMembers 获取群组成员
+func (h *Handler) getGroupMembers(ctx context.Context, params json.RawMessage) (interface{}, error) {
+	var req struct {
+		GroupId string `json:"group_id"`
+	}
+	if err := json.Unmarshal(params, &req); err != nil {
+		return nil, err
+	}
+
+	members, err := h.groupService.GetMembers(ctx, req.GroupId)
+	if err != nil {
+		return nil, err
+	}
+
+	var memberInfos []map[string]interface{}
+	for _, m := range members {
+		u, err := h.userService.GetByID(ctx, m.UserID)
+		if err != nil {
+			continue
+		}
+		memberInfos = append(memberInfos, map[string]interface{}{
+			"uid":      u.ID,
+			"nickname": u.Nickname,
+			"avatar":   u.Avatar,
+			"role":     m.Role,
+		})
+	}
+
+	return map[string]interface{}{
+		"members": memberInfos,
+	}, nil
+}
+
+// updateGroup 更新群组信息（管理员）
+func (h *Handler) updateGroup(ctx context.Context, params json.RawMessage) (interface{}, error) {
+	var req struct {
+		GroupId     string `json:"group_id"`
+		OperatorId  string `json:"operator_id"`
+		Name        string `json:"name"`
+		Description string `json:"description"`
+		Avatar      string `json:"avatar"`
+	}
+	if err := json.Unmarshal(params, &req); err != nil {
+		return nil, err
+	}
+
+	if err := h.groupService.UpdateGroup(ctx, req.GroupId, req.OperatorId, req.Name, req.Description, req.Avatar); err != nil {
+		return nil, err
+	}
+
+	return h.getGroupInfo(ctx, params)
+}
+
+// dismissGroup 解散群组（群主）
+func (h *Handler) dismissGroup(ctx context.Context, params json.RawMessage) (interface{}, error) {
+	var req struct {
+		GroupId    string `json:"group_id"`
+		OperatorId string `json:"operator_id"`
+	}
+	if err := json.Unmarshal(params, &req); err != nil {
+		return nil, err
+	}
+
+	if err := h.groupService.DismissGroup(ctx, req.GroupId, req.OperatorId); err != nil {
+		return nil, err
+	}
+
+	return map[string]interface{}{
+		"success": true,
+	}, nil
+}
+
+// addGroupMember 添加群成员（管理员）
+func (h *Handler) addGroupMember(ctx context.Context, params json.RawMessage) (interface{}, error) {
+	var req struct {
+		GroupId    string `json:"group_id"`
+		OperatorId string `json:"operator_id"`
+		Uid        string `json:"uid"`
+	}
+	if err := json.Unmarshal(params, &req); err != nil {
+		return nil, err
+	}
+
+	if err := h.groupService.AddMember(ctx, req.GroupId, req.OperatorId, req.Uid); err != nil {
+		return nil, err
+	}
+
+	return map[string]interface{}{
+		"success": true,
+	}, nil
+}
+
+// removeGroupMember 移除群成员（管理员）
+func (h *Handler) removeGroupMember(ctx context.Context, params json.RawMessage) (interface{}, error) {
+	var req struct {
+		GroupId    string `json:"group_id"`
+		OperatorId string `json:"operator_id"`
+		Uid        string `json:"uid"`
+	}
+	if err := json.Unmarshal(params, &req); err != nil {
+		return nil, err
+	}
+
+	if err := h.groupService.RemoveMember(ctx, req.GroupId, req.OperatorId, req.Uid); err != nil {
+		return nil, err
+	}
+
+	return map[string]interface{}{
+		"success": true,
+	}, nil
+}
+
+// leaveGroup 退出群组
+func (h *Handler) leaveGroup(ctx context.Context, params json.RawMessage) (interface{}, error) {
+	var req struct {
+		GroupId string `json:"group_id"`
+		Uid     string `json:"uid"`
+	}
+	if err := json.Unmarshal(params, &req); err != nil {
+		return nil, err
+	}
+
+	if err := h.groupService.LeaveGroup(ctx, req.GroupId, req.Uid); err != nil {
+		return nil, err
+	}
+
+	return map[string]interface{}{
+		"success": true,
+	}, nil
+}
+
+// setGroupAdmin 设置/取消管理员（群主）
+func (h *Handler) setGroupAdmin(ctx context.Context, params json.RawMessage) (interface{}, error) {
+	var req struct {
+		GroupId    string `json:"group_id"`
+		OperatorId string `json:"operator_id"`
+		Uid        string `json:"uid"`
+		IsAdmin    bool   `json:"is_admin"`
+	}
+	if err := json.Unmarshal(params, &req); err != nil {
+		return nil, err
+	}
+
+	if err := h.groupService.SetAdmin(ctx, req.GroupId, req.OperatorId, req.Uid, req.IsAdmin); err != nil {
+		return nil, err
+	}
+
+	return map[string]interface{}{
+		"success": true,
+	}, nil
+}
+
+// transferGroupOwner 转让群主（群主）
+func (h *Handler) transferGroupOwner(ctx context.Context, params json.RawMessage) (interface{}, error) {
+	var req struct {
+		GroupId    string `json:"group_id"`
+		OperatorId string `json:"operator_id"`
+		NewOwnerId string `json:"new_owner_id"`
+	}
+	if err := json.Unmarshal(params, &req); err != nil {
+		return nil, err
+	}
+
+	if err := h.groupService.TransferOwner(ctx, req.GroupId, req.OperatorId, req.NewOwnerId); err != nil {
+		return nil, err
+	}
+
+	return map[string]interface{}{
+		"success": true,
+	}, nil
+}
+
+// muteGroupMember 禁言群成员（管理员），duration 为禁言时长（秒），0 表示永久
+func (h *Handler) muteGroupMember(ctx context.Context, params json.RawMessage) (interface{}, error) {
+	var req struct {
+		GroupId    string `json:"group_id"`
+		OperatorId string `json:"operator_id"`
+		Uid        string `json:"uid"`
+		Duration   int64  `json:"duration"`
+	}
+	if err := json.Unmarshal(params, &req); err != nil {
+		return nil, err
+	}
+
+	if err := h.groupService.MuteMember(ctx, req.GroupId, req.OperatorId, req.Uid, time.Duration(req.Duration)*time.Second); err != nil {
+		return nil, err
+	}
+
+	return map[string]interface{}{
+		"success": true,
+	}, nil
+}
+
+// unmuteGroupMember 取消禁言（管理员）
+func (h *Handler) unmuteGroupMember(ctx context.Context, params json.RawMessage) (interface{}, error) {
+	var req struct {
+		GroupId    string `json:"group_id"`
+		OperatorId string `json:"operator_id"`
+		Uid        string `json:"uid"`
+	}
+	if err := json.Unmarshal(params, &req); err != nil {
+		return nil, err
+	}
+
+	if err := h.groupService.UnmuteMember(ctx, req.GroupId, req.OperatorId, req.Uid); err != nil {
+		return nil, err
+	}
+
+	return map[string]interface{}{
+		"success": true,
+	}, nil
+}
+
+// setGroupMuteAll 开启或关闭全员禁言（管理员）
+func (h *Handler) setGroupMuteAll(ctx context.Context, params json.RawMessage) (interface{}, error) {
+	var req struct {
+		GroupId    string `json:"group_id"`
+		OperatorId string `json:"operator_id"`
+		Enabled    bool   `json:"enabled"`
+	}
+	if err := json.Unmarshal(params, &req); err != nil {
+		return nil, err
+	}
+
+	if err := h.groupService.SetMuteAll(ctx, req.GroupId, req.OperatorId, req.Enabled); err != nil {
+		return nil, err
+	}
+
+	return map[string]interface{}{
+		"success": true,
+	}, nil
+}
+
+// setGroupJoinRequests 开启或关闭直接申请入群（管理员）
+func (h *Handler) setGroupJoinRequests(ctx context.Context, params json.RawMessage) (interface{}, error) {
+	var req struct {
+		GroupId    string `json:"group_id"`
+		OperatorId string `json:"operator_id"`
+		Enabled    bool   `json:"enabled"`
+	}
+	if err := json.Unmarshal(params, &req); err != nil {
+		return nil, err
+	}
+
+	if err := h.groupService.SetJoinRequests(ctx, req.GroupId, req.OperatorId, req.Enabled); err != nil {
+		return nil, err
+	}
+
+	return map[string]interface{}{
+		"success": true,
+	}, nil
+}
+
+// setGroupSlowMode 设置慢速模式（管理员）
+func (h *Handler) setGroupSlowMode(ctx context.Context, params json.RawMessage) (interface{}, error) {
 	var req struct {
-		GroupId string `json:"group_id"`
+		GroupId    string `json:"group_id"`
+		OperatorId string `json:"operator_id"`
+		Seconds    int    `json:"seconds"`
 	}
 	if err := json.Unmarshal(params, &req); err != nil {
 		return nil, err
 	}
 
-	g, err := h.groupService.GetGroup(ctx, req.GroupId)
+	if err := h.groupService.SetSlowMode(ctx, req.GroupId, req.OperatorId, req.Seconds); err != nil {
+		return nil, err
+	}
+
+	return map[string]interface{}{
+		"success": true,
+	}, nil
+}
+
+// createGroupInvite 创建群邀请链接（管理员）
+func (h *Handler) createGroupInvite(ctx context.Context, params json.RawMessage) (interface{}, error) {
+	var req struct {
+		GroupId    string `json:"group_id"`
+		OperatorId string `json:"operator_id"`
+		group.CreateInviteRequest
+	}
+	if err := json.Unmarshal(params, &req); err != nil {
+		return nil, err
+	}
+
+	invite, err := h.groupService.CreateInvite(ctx, req.GroupId, req.OperatorId, &req.CreateInviteRequest)
+	if err != nil {
+		return nil, err
+	}
+
+	return groupInviteInfo(invite), nil
+}
+
+// getGroupInvites 获取群组未撤销的邀请链接（管理员）
+func (h *Handler) getGroupInvites(ctx context.Context, params json.RawMessage) (interface{}, error) {
+	var req struct {
+		GroupId    string `json:"group_id"`
+		OperatorId string `json:"operator_id"`
+	}
+	if err := json.Unmarshal(params, &req); err != nil {
+		return nil, err
+	}
+
+	invites, err := h.groupService.ListInvites(ctx, req.GroupId, req.OperatorId)
 	if err != nil {
 		return nil, err
 	}
 
+	result := make([]map[string]interface{}, 0, len(invites))
+	for i := range invites {
+		result = append(result, groupInviteInfo(&invites[i]))
+	}
+
 	return map[string]interface{}{
-		"id":          g.ID,
-		"name":        g.Name,
-		"description": g.Description,
-		"avatar":      g.Avatar,
-		"owner_id":    g.OwnerID,
-		"max_members": g.MaxMembers,
+		"invites": result,
 	}, nil
 }
 
-// getGroupMembers 获取群组成员
-func (h *Handler) getGroupMembers(ctx context.Context, params json.RawMessage) (interface{}, error) {
+// revokeGroupInvite 撤销群邀请链接（管理员）
+func (h *Handler) revokeGroupInvite(ctx context.Context, params json.RawMessage) (interface{}, error) {
+	var req struct {
+		GroupId    string `json:"group_id"`
+		OperatorId string `json:"operator_id"`
+		InviteId   uint   `json:"invite_id"`
+	}
+	if err := json.Unmarshal(params, &req); err != nil {
+		return nil, err
+	}
+
+	if err := h.groupService.RevokeInvite(ctx, req.GroupId, req.OperatorId, req.InviteId); err != nil {
+		return nil, err
+	}
+
+	return map[string]interface{}{
+		"success": true,
+	}, nil
+}
+
+// previewGroupInvite 通过邀请链接预览群组
+func (h *Handler) previewGroupInvite(ctx context.Context, params json.RawMessage) (interface{}, error) {
+	var req struct {
+		Token string `json:"token"`
+	}
+	if err := json.Unmarshal(params, &req); err != nil {
+		return nil, err
+	}
+
+	preview, err := h.groupService.PreviewInvite(ctx, req.Token)
+	if err != nil {
+		return nil, err
+	}
+
+	var expiresAt int64
+	if preview.ExpiresAt != nil {
+		expiresAt = preview.ExpiresAt.Unix()
+	}
+	return map[string]interface{}{
+		"group_id":          preview.GroupID,
+		"name":              preview.Name,
+		"avatar":            preview.Avatar,
+		"description":       preview.Description,
+		"member_count":      preview.MemberCount,
+		"requires_approval": preview.RequiresApproval,
+		"expires_at":        expiresAt,
+	}, nil
+}
+
+// joinGroupByInvite 通过邀请链接加入群组
+func (h *Handler) joinGroupByInvite(ctx context.Context, params json.RawMessage) (interface{}, error) {
+	var req struct {
+		Token string `json:"token"`
+		Uid   string `json:"uid"`
+	}
+	if err := json.Unmarshal(params, &req); err != nil {
+		return nil, err
+	}
+
+	groupID, joinReq, err := h.groupService.JoinByInvite(ctx, req.Token, req.Uid)
+	if err != nil {
+		return nil, err
+	}
+	if joinReq != nil {
+		return h.newJoinRequestResult(ctx, joinReq)
+	}
+
+	return map[string]interface{}{
+		"group_id": groupID,
+	}, nil
+}
+
+// groupInviteInfo 邀请链接信息
+func groupInviteInfo(i *model.GroupInvite) map[string]interface{} {
+	var expiresAt int64
+	if i.ExpiresAt != nil {
+		expiresAt = i.ExpiresAt.Unix()
+	}
+	return map[string]interface{}{
+		"id":                i.ID,
+		"group_id":          i.GroupID,
+		"token":             i.Token,
+		"created_by":        i.CreatedBy,
+		"max_uses":          i.MaxUses,
+		"uses":              i.Uses,
+		"requires_approval": i.RequiresApproval,
+		"expires_at":        expiresAt,
+		"created_at":        i.CreatedAt.Unix(),
+	}
+}
+
+// requestGroupJoin 申请加入群组
+func (h *Handler) requestGroupJoin(ctx context.Context, params json.RawMessage) (interface{}, error) {
 	var req struct {
 		GroupId string `json:"group_id"`
+		Uid     string `json:"uid"`
+		Message string `json:"message"`
 	}
 	if err := json.Unmarshal(params, &req); err != nil {
 		return nil, err
 	}
 
-	members, err := h.groupService.GetMembers(ctx, req.GroupId)
+	joinReq, err := h.groupService.RequestJoin(ctx, req.GroupId, req.Uid, req.Message)
 	if err != nil {
 		return nil, err
 	}
 
-	var memberInfos []map[string]interface{}
-	for _, m := range members {
-		u, err := h.userService.GetByID(ctx, m.UserID)
-		if err != nil {
-			continue
+	return h.newJoinRequestResult(ctx, joinReq)
+}
+
+// getGroupJoinRequests 获取群组待处理的入群申请（管理员）
+func (h *Handler) getGroupJoinRequests(ctx context.Context, params json.RawMessage) (interface{}, error) {
+	var req struct {
+		GroupId    string `json:"group_id"`
+		OperatorId string `json:"operator_id"`
+	}
+	if err := json.Unmarshal(params, &req); err != nil {
+		return nil, err
+	}
+
+	requests, err := h.groupService.ListJoinRequests(ctx, req.GroupId, req.OperatorId)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]map[string]interface{}, 0, len(requests))
+	for i := range requests {
+		info := groupJoinRequestInfo(&requests[i])
+		if u, err := h.userService.GetByID(ctx, requests[i].UserID); err == nil {
+			info["nickname"] = u.Nickname
+			info["avatar"] = u.Avatar
 		}
-		memberInfos = append(memberInfos, map[string]interface{}{
-			"uid":      u.ID,
-			"nickname": u.Nickname,
-			"avatar":   u.Avatar,
-			"role":     m.Role,
-		})
+		result = append(result, info)
 	}
 
 	return map[string]interface{}{
-		"members": memberInfos,
+		"requests": result,
+	}, nil
+}
+
+// approveGroupJoinRequest 同意入群申请（管理员）
+func (h *Handler) approveGroupJoinRequest(ctx context.Context, params json.RawMessage) (interface{}, error) {
+	var req struct {
+		GroupId    string `json:"group_id"`
+		RequestId  uint   `json:"request_id"`
+		OperatorId string `json:"operator_id"`
+	}
+	if err := json.Unmarshal(params, &req); err != nil {
+		return nil, err
+	}
+
+	joinReq, err := h.groupService.ApproveJoinRequest(ctx, req.GroupId, req.RequestId, req.OperatorId)
+	if err != nil {
+		return nil, err
+	}
+
+	return h.joinRequestResult(ctx, joinReq)
+}
+
+// rejectGroupJoinRequest 拒绝入群申请（管理员）
+func (h *Handler) rejectGroupJoinRequest(ctx context.Context, params json.RawMessage) (interface{}, error) {
+	var req struct {
+		GroupId    string `json:"group_id"`
+		RequestId  uint   `json:"request_id"`
+		OperatorId string `json:"operator_id"`
+	}
+	if err := json.Unmarshal(params, &req); err != nil {
+		return nil, err
+	}
+
+	joinReq, err := h.groupService.RejectJoinRequest(ctx, req.GroupId, req.RequestId, req.OperatorId)
+	if err != nil {
+		return nil, err
+	}
+
+	return h.joinRequestResult(ctx, joinReq)
+}
+
+// newJoinRequestResult 返回新建的入群申请，同一群组推送间隔内的申请不返回管理员（不推送）
+func (h *Handler) newJoinRequestResult(ctx context.Context, r *model.GroupJoinRequest) (interface{}, error) {
+	if !h.groupService.ShouldNotifyJoinRequest(ctx, r.GroupID) {
+		return map[string]interface{}{
+			"request": groupJoinRequestInfo(r),
+			"admins":  []string{},
+		}, nil
+	}
+	return h.joinRequestResult(ctx, r)
+}
+
+// joinRequestResult 返回入群申请及群组管理员（供网关推送申请状态）
+func (h *Handler) joinRequestResult(ctx context.Context, r *model.GroupJoinRequest) (interface{}, error) {
+	admins, err := h.groupService.GetAdminIDs(ctx, r.GroupID)
+	if err != nil {
+		return nil, err
+	}
+
+	return map[string]interface{}{
+		"request": groupJoinRequestInfo(r),
+		"admins":  admins,
+	}, nil
+}
+
+// groupJoinRequestInfo 入群申请信息
+func groupJoinRequestInfo(r *model.GroupJoinRequest) map[string]interface{} {
+	return map[string]interface{}{
+		"id":         r.ID,
+		"group_id":   r.GroupID,
+		"uid":        r.UserID,
+		"message":    r.Message,
+		"status":     r.Status,
+		"via_invite": r.InviteID != nil,
+		"handled_by": r.HandledBy,
+		"created_at": r.CreatedAt.Unix(),
+	}
+}
+
+// setGroupAnnouncement 发布群公告（管理员）
+func (h *Handler) setGroupAnnouncement(ctx context.Context, params json.RawMessage) (interface{}, error) {
+	var req struct {
+		GroupId    string `json:"group_id"`
+		OperatorId string `json:"operator_id"`
+		Content    string `json:"content"`
+		KeyVersion int    `json:"key_version"`
+	}
+	if err := json.Unmarshal(params, &req); err != nil {
+		return nil, err
+	}
+
+	ann, err := h.groupService.SetAnnouncement(ctx, req.GroupId, req.OperatorId, req.Content, req.KeyVersion)
+	if err != nil {
+		return nil, err
+	}
+
+	return groupAnnouncementInfo(ann), nil
+}
+
+// clearGroupAnnouncement 清除群公告（管理员）
+func (h *Handler) clearGroupAnnouncement(ctx context.Context, params json.RawMessage) (interface{}, error) {
+	var req struct {
+		GroupId    string `json:"group_id"`
+		OperatorId string `json:"operator_id"`
+	}
+	if err := json.Unmarshal(params, &req); err != nil {
+		return nil, err
+	}
+
+	ann, err := h.groupService.ClearAnnouncement(ctx, req.GroupId, req.OperatorId)
+	if err != nil {
+		return nil, err
+	}
+
+	return groupAnnouncementInfo(ann), nil
+}
+
+// getGroupAnnouncement 获取当前群公告（成员）
+func (h *Handler) getGroupAnnouncement(ctx context.Context, params json.RawMessage) (interface{}, error) {
+	var req struct {
+		GroupId string `json:"group_id"`
+		Uid     string `json:"uid"`
+	}
+	if err := json.Unmarshal(params, &req); err != nil {
+		return nil, err
+	}
+
+	view, err := h.groupService.GetAnnouncement(ctx, req.GroupId, req.Uid)
+	if err != nil {
+		return nil, err
+	}
+
+	var updatedAt int64
+	if !view.UpdatedAt.IsZero() {
+		updatedAt = view.UpdatedAt.Unix()
+	}
+	return map[string]interface{}{
+		"group_id":      view.GroupID,
+		"revision":      view.Revision,
+		"content":       view.Content,
+		"key_version":   view.KeyVersion,
+		"encrypted":     view.Encrypted,
+		"author_id":     view.AuthorID,
+		"updated_at":    updatedAt,
+		"seen_revision": view.SeenRevision,
+		"needs_ack":     view.NeedsAck,
+	}, nil
+}
+
+// ackGroupAnnouncement 确认已阅读群公告
+func (h *Handler) ackGroupAnnouncement(ctx context.Context, params json.RawMessage) (interface{}, error) {
+	var req struct {
+		GroupId  string `json:"group_id"`
+		Uid      string `json:"uid"`
+		Revision int    `json:"revision"`
+	}
+	if err := json.Unmarshal(params, &req); err != nil {
+		return nil, err
+	}
+
+	if err := h.groupService.AckAnnouncement(ctx, req.GroupId, req.Uid, req.Revision); err != nil {
+		return nil, err
+	}
+
+	return map[string]interface{}{
+		"success": true,
+	}, nil
+}
+
+// getGroupAnnouncementHistory 获取群公告历史版本（成员）
+func (h *Handler) getGroupAnnouncementHistory(ctx context.Context, params json.RawMessage) (interface{}, error) {
+	var req struct {
+		GroupId string `json:"group_id"`
+		Uid     string `json:"uid"`
+		Limit   int    `json:"limit"`
+	}
+	if err := json.Unmarshal(params, &req); err != nil {
+		return nil, err
+	}
+
+	history, err := h.groupService.ListAnnouncementHistory(ctx, req.GroupId, req.Uid, req.Limit)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]map[string]interface{}, 0, len(history))
+	for i := range history {
+		result = append(result, groupAnnouncementInfo(&history[i]))
+	}
+
+	return map[string]interface{}{
+		"history": result,
+	}, nil
+}
+
+// setGroupAnnouncementEncryption 开启或关闭群公告加密（管理员）
+func (h *Handler) setGroupAnnouncementEncryption(ctx context.Context, params json.RawMessage) (interface{}, error) {
+	var req struct {
+		GroupId    string `json:"group_id"`
+		OperatorId string `json:"operator_id"`
+		Enabled    bool   `json:"enabled"`
+	}
+	if err := json.Unmarshal(params, &req); err != nil {
+		return nil, err
+	}
+
+	if err := h.groupService.SetAnnouncementEncryption(ctx, req.GroupId, req.OperatorId, req.Enabled); err != nil {
+		return nil, err
+	}
+
+	return map[string]interface{}{
+		"success": true,
 	}, nil
 }
 
+// groupAnnouncementInfo 群公告版本信息
+func groupAnnouncementInfo(a *model.GroupAnnouncement) map[string]interface{} {
+	return map[string]interface{}{
+		"group_id":    a.GroupID,
+		"revision":    a.Revision,
+		"content":     a.Content,
+		"key_version": a.KeyVersion,
+		"author_id":   a.AuthorID,
+		"created_at":  a.CreatedAt.Unix(),
+	}
+}
+
 // ==================== 加密密钥相关 ====================
 
 // getUserPublicKey 获取用户公钥
@@ -29,13 +29,13 @@ type Server struct {
 
 // NewServer 创建服务器
 func NewServer(config conf.Config, storage *storage.Storage) *Server {
-	jwtManager := auth.NewJWTManager(config.JWT.Secret, config.JWT.ExpireHour)
+	jwtManager := auth.NewJWTManager(config.JWT.Secret, config.JWT.ExpireHour).WithRevocation(storage.Redis())
 
 	// 创建服务
-	userService := user.NewService(storage)
+	userService := user.NewService(storage, config.SeaKing)
 	relationService := relation.NewService(storage)
 	groupService := group.NewService(storage)
-	convService := conversation.NewService(storage)
+	convService := conversation.NewService(storage, config.SeaKing)
 	keyService := key.NewService(storage)
 
 	// 创建RPC处理器（内部服务通信）
@@ -6,6 +6,8 @@ import (
 	"time"
 
 	"github.com/my-chat/common/pkg/errors"
+	"github.com/my-chat/common/pkg/protocol"
+	"github.com/my-chat/seaking/internal/conf"
 	"github.com/my-chat/seaking/internal/model"
 	"github.com/my-chat/seaking/internal/storage"
 	"github.com/rs/xid"
@@ -15,11 +17,15 @@ import (
 // Service 会话服务
 type Service struct {
 	storage *storage.Storage
+	config  conf.SeaKingConfiguration
 }
 
 // NewService 创建会话服务
-func NewService(storage *storage.Storage) *Service {
-	return &Service{storage: storage}
+func NewService(storage *storage.Storage, config conf.SeaKingConfiguration) *Service {
+	return &Service{
+		storage: storage,
+		config:  config,
+	}
 }
 
 // CreateDirectConversation 创建单聊会话
@@ -147,29 +153,80 @@ func (s *Service) GetUserConversations(ctx context.Context, uid string) ([]model
 	return convs, err
 }
 
-// CheckAccess 检查用户是否有权访问会话
-func (s *Service) CheckAccess(ctx context.Context, uid, cid string) (bool, int, bool, error) {
+// GetUserMemberships 获取用户在各会话中的成员设置（置顶、免打扰等），按会话ID索引
+func (s *Service) GetUserMemberships(ctx context.Context, uid string) (map[string]model.ConversationMember, error) {
+	var members []model.ConversationMember
+	if err := s.storage.DB().Where("user_id = ?", uid).Find(&members).Error; err != nil {
+		return nil, err
+	}
+
+	memberships := make(map[string]model.ConversationMember, len(members))
+	for _, m := range members {
+		memberships[m.ConversationID] = m
+	}
+	return memberships, nil
+}
+
+// Access 用户在会话中的访问权限
+type Access struct {
+	HasAccess bool
+	Role      int  // 群聊中的角色，单聊为0
+	Muted     bool // 是否被单独禁言
+	CanSend   bool // 是否可以发言（未被禁言且不受全员禁言限制）
+	SlowMode  int  // 适用于该用户的慢速模式间隔（秒），群主和管理员为0
+}
+
+// CheckAccess 检查用户是否有权访问会话，并计算实际的发言权限
+func (s *Service) CheckAccess(ctx context.Context, uid, cid string) (*Access, error) {
 	var member model.ConversationMember
 	err := s.storage.DB().Where("conversation_id = ? AND user_id = ?", cid, uid).First(&member).Error
 	if err != nil {
-		return false, 0, false, nil
+		return &Access{}, nil
 	}
 
-	// 获取角色信息（如果是群聊）
-	role := 0
-	muted := false
+	access := &Access{HasAccess: true, CanSend: true}
 
 	if strings.HasPrefix(cid, "g:") {
-		// 群聊，获取群成员角色
+		// 群聊，获取群成员角色和群组发言设置
 		groupId := strings.TrimPrefix(cid, "g:")
 		var groupMember model.GroupMember
 		if err := s.storage.DB().Where("group_id = ? AND user_id = ?", groupId, uid).First(&groupMember).Error; err == nil {
-			role = groupMember.Role
-			muted = groupMember.Muted
+			access.Role = groupMember.Role
+			access.Muted = groupMember.IsMuted(time.Now())
+		}
+
+		var group model.Group
+		if err := s.storage.DB().Select("mute_all", "slow_mode").First(&group, "id = ?", groupId).Error; err == nil {
+			// 群主和管理员不受全员禁言和慢速模式限制
+			if access.Role < model.GroupRoleAdmin {
+				access.CanSend = !group.MuteAll
+				access.SlowMode = group.SlowMode
+			}
+		}
+		if access.Muted {
+			access.CanSend = false
 		}
 	}
 
-	return true, role, muted, nil
+	return access, nil
+}
+
+// IsDirectBlocked 检查单聊双方是否存在拉黑关系，存在时禁止在该会话中发送消息
+func (s *Service) IsDirectBlocked(ctx context.Context, uid, cid string) bool {
+	uid1, uid2, ok := model.ParseDirectCid(cid)
+	if !ok {
+		return false
+	}
+	peer := uid1
+	if peer == uid {
+		peer = uid2
+	}
+
+	var count int64
+	s.storage.DB().Model(&model.UserBlock{}).
+		Where("(user_id = ? AND blocked_id = ?) OR (user_id = ? AND blocked_id = ?)", peer, uid, uid, peer).
+		Count(&count)
+	return count > 0
 }
 
 // AddMember 添加会话成员
@@ -188,6 +245,17 @@ func (s *Service) RemoveMember(ctx context.Context, cid, uid string) error {
 		Delete(&model.ConversationMember{}).Error
 }
 
+// PurgeUser 移除用户的所有会话成员身份和草稿（账号注销时使用）
+// 群聊成员身份需先通过群组服务移除，以保证群成员与会话成员一致
+func (s *Service) PurgeUser(ctx context.Context, uid string) error {
+	return s.storage.DB().Transaction(func(tx *gorm.DB) error {
+		if err := tx.Unscoped().Where("user_id = ?", uid).Delete(&model.ConversationMember{}).Error; err != nil {
+			return err
+		}
+		return tx.Where("user_id = ?", uid).Delete(&model.Draft{}).Error
+	})
+}
+
 // UpdateLastReadMid 更新最后已读消息ID
 func (s *Service) UpdateLastReadMid(ctx context.Context, cid, uid string, lastReadMid int64) error {
 	return s.storage.DB().Model(&model.ConversationMember{}).
@@ -209,6 +277,89 @@ func (s *Service) SetPinned(ctx context.Context, cid, uid string, pinned bool) e
 		Update("pinned", pinned).Error
 }
 
+// GetPolicy 获取会话消息策略，未设置时返回默认策略
+func (s *Service) GetPolicy(ctx context.Context, cid string) (*model.ConversationPolicy, error) {
+	var policy model.ConversationPolicy
+	err := s.storage.DB().First(&policy, "conversation_id = ?", cid).Error
+	if err == gorm.ErrRecordNotFound {
+		return model.DefaultConversationPolicy(cid), nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &policy, nil
+}
+
+// SetPolicyRequest 设置会话消息策略请求
+type SetPolicyRequest struct {
+	Cid            string `json:"cid"`
+	OperatorID     string `json:"operator_id"`
+	RevokeWindow   int64  `json:"revoke_window"`
+	EditWindow     int64  `json:"edit_window"`
+	EditableKinds  []int  `json:"editable_kinds"`
+	AdminRevokeAny bool   `json:"admin_revoke_any"`
+}
+
+// SetPolicy 设置会话消息策略（仅群聊，需要群主或管理员权限）
+func (s *Service) SetPolicy(ctx context.Context, req *SetPolicyRequest) (*model.ConversationPolicy, error) {
+	if !strings.HasPrefix(req.Cid, "g:") {
+		return nil, errors.New(errors.ErrCodeInvalidParam, "policy can only be set on group conversations")
+	}
+
+	if req.RevokeWindow < 0 || req.EditWindow < 0 {
+		return nil, errors.ErrInvalidParam
+	}
+
+	for _, kind := range req.EditableKinds {
+		if !isEditableKind(kind) {
+			return nil, errors.Newf(errors.ErrCodeInvalidParam, "kind %d is not editable", kind)
+		}
+	}
+
+	var member model.GroupMember
+	groupId := strings.TrimPrefix(req.Cid, "g:")
+	if err := s.storage.DB().Where("group_id = ? AND user_id = ?", groupId, req.OperatorID).First(&member).Error; err != nil {
+		return nil, errors.ErrNotGroupMember
+	}
+	if member.Role < model.GroupRoleAdmin {
+		return nil, errors.ErrNoPermission
+	}
+
+	editableKinds := req.EditableKinds
+	if editableKinds == nil {
+		editableKinds = []int{}
+	}
+
+	// 保留已有记录的创建时间
+	var policy model.ConversationPolicy
+	err := s.storage.DB().First(&policy, "conversation_id = ?", req.Cid).Error
+	if err != nil && err != gorm.ErrRecordNotFound {
+		return nil, err
+	}
+
+	policy.ConversationID = req.Cid
+	policy.RevokeWindow = req.RevokeWindow
+	policy.EditWindow = req.EditWindow
+	policy.EditableKinds = editableKinds
+	policy.AdminRevokeAny = req.AdminRevokeAny
+	policy.UpdatedBy = req.OperatorID
+	if err := s.storage.DB().Save(&policy).Error; err != nil {
+		return nil, err
+	}
+
+	return &policy, nil
+}
+
+// isEditableKind 判断消息类型是否允许配置为可编辑（仅内容类消息）
+func isEditableKind(kind int) bool {
+	switch kind {
+	case protocol.KindText, protocol.KindFile, protocol.KindForward:
+		return true
+	default:
+		return false
+	}
+}
+
 // CreateConversationRequest 创建会话请求
 type CreateConversationRequest struct {
 	Type      int      `json:"type"`
@@ -1,8 +1,13 @@
 package conversation
 
 import (
+	"strings"
 	"testing"
+	"time"
 
+	"github.com/my-chat/common/pkg/errors"
+	"github.com/my-chat/common/pkg/protocol"
+	"github.com/my-chat/seaking/internal/conf"
 	"github.com/my-chat/seaking/internal/model"
 )
 
@@ -157,3 +162,94 @@ func TestCidParsing(t *testing.T) {
 		})
 	}
 }
+
+func TestIsEditableKind(t *testing.T) {
+	tests := []struct {
+		kind int
+		want bool
+	}{
+		{protocol.KindText, true},
+		{protocol.KindFile, true},
+		{protocol.KindForward, true},
+		{protocol.KindRevoke, false},
+		{protocol.KindReaction, false},
+	}
+
+	for _, tt := range tests {
+		if got := isEditableKind(tt.kind); got != tt.want {
+			t.Errorf("isEditableKind(%d) = %v, want %v", tt.kind, got, tt.want)
+		}
+	}
+}
+
+func TestCheckPinLimit(t *testing.T) {
+	tests := []struct {
+		name       string
+		configured int
+		count      int64
+		wantErr    bool
+	}{
+		{"empty conversation", 0, 0, false},
+		{"below default limit", 0, int64(model.DefaultMaxPinnedMessages) - 1, false},
+		{"at default limit", 0, int64(model.DefaultMaxPinnedMessages), true},
+		{"below configured limit", 2, 1, false},
+		{"at configured limit", 2, 2, true},
+		{"over configured limit", 2, 5, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := NewService(nil, conf.SeaKingConfiguration{MaxPinnedMessages: tt.configured})
+			err := s.checkPinLimit(tt.count)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("checkPinLimit(%d) error = %v, wantErr %v", tt.count, err, tt.wantErr)
+			}
+			if err != nil && err != errors.ErrPinLimit {
+				t.Errorf("checkPinLimit(%d) error = %v, want ErrPinLimit", tt.count, err)
+			}
+		})
+	}
+}
+
+func TestValidateMessageTTL(t *testing.T) {
+	tests := []struct {
+		ttl     int64
+		wantErr bool
+	}{
+		{0, false},
+		{60, false},
+		{model.MaxMessageTTL, false},
+		{-1, true},
+		{model.MaxMessageTTL + 1, true},
+	}
+
+	for _, tt := range tests {
+		if err := validateMessageTTL(tt.ttl); (err != nil) != tt.wantErr {
+			t.Errorf("validateMessageTTL(%d) error = %v, wantErr %v", tt.ttl, err, tt.wantErr)
+		}
+	}
+}
+
+func TestValidateDraft(t *testing.T) {
+	now := time.Now()
+	tests := []struct {
+		name    string
+		data    string
+		ts      int64
+		wantErr bool
+	}{
+		{"valid", "ciphertext", now.UnixMilli(), false},
+		{"clear", "", now.UnixMilli(), false},
+		{"missing timestamp", "ciphertext", 0, true},
+		{"future timestamp", "ciphertext", now.Add(time.Hour).UnixMilli(), true},
+		{"too large", strings.Repeat("a", model.MaxDraftSize+1), now.UnixMilli(), true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := validateDraft(tt.data, tt.ts, now); (err != nil) != tt.wantErr {
+				t.Errorf("validateDraft() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
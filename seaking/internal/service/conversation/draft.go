@@ -0,0 +1,90 @@
+package conversation
+
+import (
+	"context"
+	"time"
+
+	"github.com/my-chat/common/pkg/errors"
+	"github.com/my-chat/seaking/internal/model"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// maxDraftClockSkew 允许客户端时间超前服务器的最大值，避免错误时钟使草稿无法再被覆盖
+const maxDraftClockSkew = 5 * time.Minute
+
+// SaveDraft 保存草稿（后写者胜），data为空表示清除
+// 返回当前生效的草稿以及本次写入是否生效
+func (s *Service) SaveDraft(ctx context.Context, uid, cid, data string, ts int64) (*model.Draft, bool, error) {
+	if err := validateDraft(data, ts, time.Now()); err != nil {
+		return nil, false, err
+	}
+
+	access, err := s.CheckAccess(ctx, uid, cid)
+	if err != nil {
+		return nil, false, err
+	}
+	if !access.HasAccess {
+		return nil, false, errors.ErrNotInConversation
+	}
+
+	draft := &model.Draft{
+		UserID:         uid,
+		ConversationID: cid,
+		Data:           data,
+		Ts:             ts,
+	}
+
+	var applied bool
+	err = s.storage.DB().Transaction(func(tx *gorm.DB) error {
+		// 仅当新时间戳更大时覆盖
+		result := tx.Clauses(clause.OnConflict{
+			Columns: []clause.Column{{Name: "user_id"}, {Name: "conversation_id"}},
+			DoUpdates: clause.Assignments(map[string]interface{}{
+				"data":       data,
+				"ts":         ts,
+				"updated_at": time.Now(),
+			}),
+			Where: clause.Where{Exprs: []clause.Expression{
+				clause.Expr{SQL: "drafts.ts < ?", Vars: []interface{}{ts}},
+			}},
+		}).Create(draft)
+		if result.Error != nil {
+			return result.Error
+		}
+		applied = result.RowsAffected > 0
+
+		return tx.Where("user_id = ? AND conversation_id = ?", uid, cid).First(draft).Error
+	})
+	if err != nil {
+		return nil, false, err
+	}
+
+	return draft, applied, nil
+}
+
+// GetDrafts 获取用户草稿（cid为空时返回全部会话），已清除的草稿不返回
+func (s *Service) GetDrafts(ctx context.Context, uid, cid string) ([]model.Draft, error) {
+	query := s.storage.DB().Where("user_id = ? AND data <> ''", uid)
+	if cid != "" {
+		query = query.Where("conversation_id = ?", cid)
+	}
+
+	var drafts []model.Draft
+	err := query.Order("ts DESC").Find(&drafts).Error
+	return drafts, err
+}
+
+// validateDraft 校验草稿大小和时间戳
+func validateDraft(data string, ts int64, now time.Time) error {
+	if ts <= 0 {
+		return errors.New(errors.ErrCodeInvalidParam, "draft timestamp is required")
+	}
+	if ts > now.Add(maxDraftClockSkew).UnixMilli() {
+		return errors.New(errors.ErrCodeInvalidParam, "draft timestamp is in the future")
+	}
+	if len(data) > model.MaxDraftSize {
+		return errors.New(errors.ErrCodeInvalidParam, "draft is too large")
+	}
+	return nil
+}
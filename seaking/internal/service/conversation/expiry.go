@@ -0,0 +1,47 @@
+package conversation
+
+import (
+	"context"
+
+	"github.com/my-chat/common/pkg/errors"
+	"github.com/my-chat/seaking/internal/model"
+)
+
+// SetMessageTTL 设置会话消息过期时间（秒），0表示关闭
+func (s *Service) SetMessageTTL(ctx context.Context, cid, uid string, ttl int64) (*model.Conversation, error) {
+	if err := validateMessageTTL(ttl); err != nil {
+		return nil, err
+	}
+	if err := s.checkManagePermission(ctx, cid, uid); err != nil {
+		return nil, err
+	}
+
+	conv, err := s.GetConversation(ctx, cid)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.storage.DB().Model(conv).Update("message_ttl", ttl).Error; err != nil {
+		return nil, err
+	}
+	conv.MessageTTL = ttl
+
+	return conv, nil
+}
+
+// GetMessageTTL 获取会话消息过期时间（秒），会话不存在时视为不过期
+func (s *Service) GetMessageTTL(ctx context.Context, cid string) int64 {
+	var conv model.Conversation
+	if err := s.storage.DB().Select("message_ttl").First(&conv, "id = ?", cid).Error; err != nil {
+		return 0
+	}
+	return conv.MessageTTL
+}
+
+// validateMessageTTL 校验消息过期时间
+func validateMessageTTL(ttl int64) error {
+	if ttl < 0 || ttl > model.MaxMessageTTL {
+		return errors.Newf(errors.ErrCodeInvalidParam, "message ttl must be between 0 and %d seconds", model.MaxMessageTTL)
+	}
+	return nil
+}
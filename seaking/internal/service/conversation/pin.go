@@ -0,0 +1,112 @@
+package conversation
+
+import (
+	"context"
+	"strings"
+
+	"github.com/my-chat/common/pkg/errors"
+	"github.com/my-chat/seaking/internal/model"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// checkManagePermission 检查会话管理权限（置顶、消息过期等）：群聊需要群主或管理员，单聊双方均可
+func (s *Service) checkManagePermission(ctx context.Context, cid, uid string) error {
+	access, err := s.CheckAccess(ctx, uid, cid)
+	if err != nil {
+		return err
+	}
+	if !access.HasAccess {
+		return errors.ErrNotInConversation
+	}
+	if strings.HasPrefix(cid, "g:") && access.Role < model.GroupRoleAdmin {
+		return errors.ErrNoPermission
+	}
+	return nil
+}
+
+// maxPinnedMessages 每个会话最多置顶消息数
+func (s *Service) maxPinnedMessages() int {
+	if s.config.MaxPinnedMessages > 0 {
+		return s.config.MaxPinnedMessages
+	}
+	return model.DefaultMaxPinnedMessages
+}
+
+// checkPinLimit 检查会话已有 count 条置顶时能否再置顶一条
+func (s *Service) checkPinLimit(count int64) error {
+	if int(count) >= s.maxPinnedMessages() {
+		return errors.ErrPinLimit
+	}
+	return nil
+}
+
+// PinMessage 置顶消息（重复置顶直接返回已有记录）
+func (s *Service) PinMessage(ctx context.Context, cid, uid string, mid int64) (*model.PinnedMessage, error) {
+	if err := s.checkManagePermission(ctx, cid, uid); err != nil {
+		return nil, err
+	}
+
+	var pin model.PinnedMessage
+	err := s.storage.DB().Transaction(func(tx *gorm.DB) error {
+		// 锁定会话行以串行化同一会话的并发置顶，保证不超过置顶上限
+		var conv model.Conversation
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&conv, "id = ?", cid).Error; err != nil {
+			return errors.ErrConversationNotFound
+		}
+
+		if err := tx.Where("conversation_id = ? AND mid = ?", cid, mid).First(&pin).Error; err == nil {
+			return nil
+		}
+
+		var count int64
+		if err := tx.Model(&model.PinnedMessage{}).Where("conversation_id = ?", cid).Count(&count).Error; err != nil {
+			return err
+		}
+		if err := s.checkPinLimit(count); err != nil {
+			return err
+		}
+
+		var maxPosition int
+		if err := tx.Model(&model.PinnedMessage{}).
+			Where("conversation_id = ?", cid).
+			Select("COALESCE(MAX(position), 0)").
+			Scan(&maxPosition).Error; err != nil {
+			return err
+		}
+
+		pin = model.PinnedMessage{
+			ConversationID: cid,
+			Mid:            mid,
+			Position:       maxPosition + 1,
+			PinnedBy:       uid,
+		}
+		return tx.Create(&pin).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &pin, nil
+}
+
+// UnpinMessage 取消置顶消息
+func (s *Service) UnpinMessage(ctx context.Context, cid, uid string, mid int64) error {
+	if err := s.checkManagePermission(ctx, cid, uid); err != nil {
+		return err
+	}
+
+	return s.storage.DB().
+		Where("conversation_id = ? AND mid = ?", cid, mid).
+		Delete(&model.PinnedMessage{}).Error
+}
+
+// GetPinnedMessages 获取会话置顶消息（最新置顶的在前）
+func (s *Service) GetPinnedMessages(ctx context.Context, cid string) ([]model.PinnedMessage, error) {
+	var pins []model.PinnedMessage
+	err := s.storage.DB().
+		Where("conversation_id = ?", cid).
+		Order("position DESC").
+		Find(&pins).Error
+	return pins, err
+}
@@ -0,0 +1,189 @@
+package group
+
+import (
+	"context"
+	"time"
+
+	"github.com/my-chat/common/pkg/errors"
+	"github.com/my-chat/seaking/internal/model"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// maxAnnouncementHistory 查询公告历史时最多返回的条数
+const maxAnnouncementHistory = 100
+
+// AnnouncementView 成员看到的当前群公告
+type AnnouncementView struct {
+	GroupID      string    `json:"group_id"`
+	Revision     int       `json:"revision"`
+	Content      string    `json:"content"`
+	KeyVersion   int       `json:"key_version"` // 加密使用的群密钥版本，0表示明文
+	Encrypted    bool      `json:"encrypted"`   // 群组是否启用公告加密（决定下次发布的格式）
+	AuthorID     string    `json:"author_id"`
+	UpdatedAt    time.Time `json:"updated_at"`
+	SeenRevision int       `json:"seen_revision"`
+	NeedsAck     bool      `json:"needs_ack"` // 有内容且当前成员尚未确认
+}
+
+// SetAnnouncement 发布新版本群公告（管理员）
+// 群组启用公告加密时 content 为群密钥加密的密文，keyVersion 为使用的密钥版本；否则 keyVersion 必须为0
+func (s *Service) SetAnnouncement(ctx context.Context, groupID, operatorID, content string, keyVersion int) (*model.GroupAnnouncement, error) {
+	if content == "" || len(content) > model.MaxAnnouncementLength || keyVersion < 0 {
+		return nil, errors.ErrInvalidParam
+	}
+	return s.publishAnnouncement(ctx, groupID, operatorID, content, keyVersion)
+}
+
+// ClearAnnouncement 清除群公告（管理员），记录为一个内容为空的新版本
+func (s *Service) ClearAnnouncement(ctx context.Context, groupID, operatorID string) (*model.GroupAnnouncement, error) {
+	return s.publishAnnouncement(ctx, groupID, operatorID, "", 0)
+}
+
+// publishAnnouncement 写入公告历史并更新群组的当前公告，发布者自动确认该版本
+func (s *Service) publishAnnouncement(ctx context.Context, groupID, operatorID, content string, keyVersion int) (*model.GroupAnnouncement, error) {
+	if !s.HasPermission(ctx, groupID, operatorID, model.GroupRoleAdmin) {
+		return nil, errors.ErrNoPermission
+	}
+
+	var ann *model.GroupAnnouncement
+	err := s.storage.DB().Transaction(func(tx *gorm.DB) error {
+		// 锁定群组行，并发发布时版本号不会重复
+		var group model.Group
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&group, "id = ?", groupID).Error; err != nil {
+			return errors.ErrGroupNotFound
+		}
+		if group.Status != model.GroupStatusNormal {
+			return errors.ErrGroupNotFound
+		}
+
+		if content == "" {
+			if group.Announcement == "" {
+				return errors.New(errors.ErrCodeInvalidParam, "no announcement to clear")
+			}
+		} else if group.AnnouncementEncrypted {
+			if keyVersion == 0 {
+				return errors.New(errors.ErrCodeInvalidParam, "announcement must be encrypted with the group key")
+			}
+			var exists int64
+			if err := tx.Model(&model.GroupKey{}).
+				Where("group_id = ? AND version = ?", groupID, keyVersion).
+				Count(&exists).Error; err != nil {
+				return err
+			}
+			if exists == 0 {
+				return errors.New(errors.ErrCodeInvalidParam, "group key version not found")
+			}
+		} else if keyVersion != 0 {
+			return errors.New(errors.ErrCodeInvalidParam, "announcement encryption is not enabled")
+		}
+
+		ann = &model.GroupAnnouncement{
+			GroupID:    groupID,
+			Revision:   group.AnnouncementRevision + 1,
+			Content:    content,
+			KeyVersion: keyVersion,
+			AuthorID:   operatorID,
+		}
+		if err := tx.Create(ann).Error; err != nil {
+			return err
+		}
+
+		if err := tx.Model(&group).Updates(map[string]interface{}{
+			"announcement":          content,
+			"announcement_revision": ann.Revision,
+		}).Error; err != nil {
+			return err
+		}
+
+		return tx.Model(&model.GroupMember{}).
+			Where("group_id = ? AND user_id = ?", groupID, operatorID).
+			Update("announcement_seen", ann.Revision).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return ann, nil
+}
+
+// SetAnnouncementEncryption 开启或关闭公告加密（管理员），只影响之后发布的版本
+func (s *Service) SetAnnouncementEncryption(ctx context.Context, groupID, operatorID string, enabled bool) error {
+	if !s.HasPermission(ctx, groupID, operatorID, model.GroupRoleAdmin) {
+		return errors.ErrNoPermission
+	}
+
+	return s.storage.DB().Model(&model.Group{}).
+		Where("id = ? AND status = ?", groupID, model.GroupStatusNormal).
+		Update("announcement_encrypted", enabled).Error
+}
+
+// GetAnnouncement 获取当前群公告及当前成员的确认状态
+func (s *Service) GetAnnouncement(ctx context.Context, groupID, userID string) (*AnnouncementView, error) {
+	var member model.GroupMember
+	if err := s.storage.DB().Where("group_id = ? AND user_id = ?", groupID, userID).First(&member).Error; err != nil {
+		return nil, errors.ErrNotGroupMember
+	}
+
+	group, err := s.GetGroup(ctx, groupID)
+	if err != nil {
+		return nil, err
+	}
+
+	view := &AnnouncementView{
+		GroupID:      groupID,
+		Revision:     group.AnnouncementRevision,
+		Content:      group.Announcement,
+		Encrypted:    group.AnnouncementEncrypted,
+		SeenRevision: member.AnnouncementSeen,
+		NeedsAck:     group.Announcement != "" && member.AnnouncementSeen < group.AnnouncementRevision,
+	}
+	if group.AnnouncementRevision > 0 {
+		var ann model.GroupAnnouncement
+		err := s.storage.DB().
+			Where("group_id = ? AND revision = ?", groupID, group.AnnouncementRevision).
+			First(&ann).Error
+		if err != nil && err != gorm.ErrRecordNotFound {
+			return nil, err
+		}
+		view.KeyVersion = ann.KeyVersion
+		view.AuthorID = ann.AuthorID
+		view.UpdatedAt = ann.CreatedAt
+	}
+	return view, nil
+}
+
+// AckAnnouncement 确认已阅读群公告，revision 不能超过当前版本，确认版本只增不减
+func (s *Service) AckAnnouncement(ctx context.Context, groupID, userID string, revision int) error {
+	group, err := s.GetGroup(ctx, groupID)
+	if err != nil {
+		return err
+	}
+	if revision <= 0 || revision > group.AnnouncementRevision {
+		return errors.ErrInvalidParam
+	}
+
+	if !s.IsMember(ctx, groupID, userID) {
+		return errors.ErrNotGroupMember
+	}
+	return s.storage.DB().Model(&model.GroupMember{}).
+		Where("group_id = ? AND user_id = ? AND announcement_seen < ?", groupID, userID, revision).
+		Update("announcement_seen", revision).Error
+}
+
+// ListAnnouncementHistory 获取群公告历史版本（按版本倒序），成员可查看
+func (s *Service) ListAnnouncementHistory(ctx context.Context, groupID, userID string, limit int) ([]model.GroupAnnouncement, error) {
+	if !s.IsMember(ctx, groupID, userID) {
+		return nil, errors.ErrNotGroupMember
+	}
+	if limit <= 0 || limit > maxAnnouncementHistory {
+		limit = maxAnnouncementHistory
+	}
+
+	var history []model.GroupAnnouncement
+	err := s.storage.DB().
+		Where("group_id = ?", groupID).
+		Order("revision DESC").
+		Limit(limit).
+		Find(&history).Error
+	return history, err
+}
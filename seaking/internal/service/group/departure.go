@@ -0,0 +1,94 @@
+package group
+
+import (
+	"context"
+
+	"github.com/my-chat/seaking/internal/model"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// DepartUser 将用户移出所有群组（账号注销时使用）
+// 用户为群主时转让给最早加入的管理员（没有则为最早加入的成员），没有其他成员时解散群组；可重复调用
+// 每退出一个群组都在同一事务中把累计结果写入注销任务，重复调用时返回包含之前已退出群组的完整结果
+func (s *Service) DepartUser(ctx context.Context, uid string) (*model.GroupDeparture, error) {
+	var deletion model.AccountDeletion
+	err := s.storage.DB().Select("user_id", "departure").First(&deletion, "user_id = ?", uid).Error
+	if err != nil && err != gorm.ErrRecordNotFound {
+		return nil, err
+	}
+	result := deletion.Departure
+	if result == nil {
+		result = &model.GroupDeparture{}
+	}
+	if result.Transferred == nil {
+		result.Transferred = make(map[string]string)
+	}
+
+	var groupIDs []string
+	if err := s.storage.DB().Model(&model.GroupMember{}).
+		Where("user_id = ?", uid).
+		Pluck("group_id", &groupIDs).Error; err != nil {
+		return nil, err
+	}
+
+	for _, groupID := range groupIDs {
+		err := s.storage.DB().Transaction(func(tx *gorm.DB) error {
+			if err := departGroupTx(tx, groupID, uid, result); err != nil {
+				return err
+			}
+			return tx.Model(&model.AccountDeletion{UserID: uid}).Select("departure").
+				Updates(&model.AccountDeletion{Departure: result}).Error
+		})
+		if err != nil {
+			return result, err
+		}
+	}
+
+	// 未处理的入群申请不再有效
+	if err := s.storage.DB().Where("user_id = ? AND status = ?", uid, model.JoinRequestPending).
+		Delete(&model.GroupJoinRequest{}).Error; err != nil {
+		return result, err
+	}
+	return result, nil
+}
+
+// departGroupTx 在事务中将用户移出单个群组，必要时先转让群主或解散群组
+func departGroupTx(tx *gorm.DB, groupID, uid string, result *model.GroupDeparture) error {
+	var group model.Group
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&group, "id = ?", groupID).Error
+	if err != nil && err != gorm.ErrRecordNotFound {
+		return err
+	}
+
+	if err == nil && group.OwnerID == uid && group.Status == model.GroupStatusNormal {
+		var successor model.GroupMember
+		err := tx.Where("group_id = ? AND user_id <> ?", groupID, uid).
+			Order("role DESC, joined_at ASC, id ASC").
+			First(&successor).Error
+		if err == gorm.ErrRecordNotFound {
+			if err := dissolveTx(tx, &group); err != nil {
+				return err
+			}
+			result.Dissolved = append(result.Dissolved, groupID)
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		if err := tx.Model(&group).Update("owner_id", successor.UserID).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&successor).Update("role", model.GroupRoleOwner).Error; err != nil {
+			return err
+		}
+		result.Transferred[groupID] = successor.UserID
+	}
+
+	if err := leaveTx(tx, groupID, uid); err != nil {
+		return err
+	}
+	result.Left = append(result.Left, groupID)
+	return nil
+}
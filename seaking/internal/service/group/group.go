@@ -9,6 +9,7 @@ import (
 	"github.com/my-chat/seaking/internal/storage"
 	"github.com/rs/xid"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 // Service 群组服务
@@ -40,34 +41,28 @@ func (s *Service) CreateGroup(ctx context.Context, ownerID string, req *CreateGr
 	}
 
 	err := s.storage.DB().Transaction(func(tx *gorm.DB) error {
-		// 创建群组
+		// 创建群组及对应的群聊会话
 		if err := tx.Create(group).Error; err != nil {
 			return err
 		}
+		if _, err := ensureConversationTx(tx, group); err != nil {
+			return err
+		}
 
 		// 添加群主为成员
-		ownerMember := &model.GroupMember{
-			GroupID:  group.ID,
-			UserID:   ownerID,
-			Role:     model.GroupRoleOwner,
-			JoinedAt: time.Now(),
-		}
-		if err := tx.Create(ownerMember).Error; err != nil {
+		now := time.Now()
+		if err := joinTx(tx, group.ID, ownerID, model.GroupRoleOwner, now); err != nil {
 			return err
 		}
 
 		// 添加初始成员
+		added := map[string]bool{ownerID: true}
 		for _, memberID := range req.MemberIDs {
-			if memberID == ownerID {
+			if added[memberID] {
 				continue
 			}
-			member := &model.GroupMember{
-				GroupID:  group.ID,
-				UserID:   memberID,
-				Role:     model.GroupRoleMember,
-				JoinedAt: time.Now(),
-			}
-			if err := tx.Create(member).Error; err != nil {
+			added[memberID] = true
+			if err := joinTx(tx, group.ID, memberID, model.GroupRoleMember, now); err != nil {
 				return err
 			}
 		}
@@ -108,8 +103,28 @@ func (s *Service) UpdateGroup(ctx context.Context, groupID, operatorID string, n
 	if avatar != "" {
 		updates["avatar"] = avatar
 	}
+	if len(updates) == 0 {
+		return nil
+	}
 
-	return s.storage.DB().Model(&model.Group{}).Where("id = ?", groupID).Updates(updates).Error
+	return s.storage.DB().Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&model.Group{}).Where("id = ?", groupID).Updates(updates).Error; err != nil {
+			return err
+		}
+
+		// 同步群聊会话的名称和头像
+		convUpdates := map[string]interface{}{}
+		if name != "" {
+			convUpdates["name"] = name
+		}
+		if avatar != "" {
+			convUpdates["avatar"] = avatar
+		}
+		if len(convUpdates) == 0 {
+			return nil
+		}
+		return tx.Model(&model.Conversation{}).Where("id = ?", model.GenerateGroupCid(groupID)).Updates(convUpdates).Error
+	})
 }
 
 // DismissGroup 解散群组
@@ -124,12 +139,7 @@ func (s *Service) DismissGroup(ctx context.Context, groupID, operatorID string)
 	}
 
 	return s.storage.DB().Transaction(func(tx *gorm.DB) error {
-		// 删除所有成员
-		if err := tx.Where("group_id = ?", groupID).Delete(&model.GroupMember{}).Error; err != nil {
-			return err
-		}
-		// 标记群组为解散
-		return tx.Model(&group).Update("status", model.GroupStatusDissolved).Error
+		return dissolveTx(tx, &group)
 	})
 }
 
@@ -140,33 +150,9 @@ func (s *Service) AddMember(ctx context.Context, groupID, operatorID, userID str
 		return errors.ErrNoPermission
 	}
 
-	// 检查群组是否存在
-	var group model.Group
-	if err := s.storage.DB().First(&group, "id = ?", groupID).Error; err != nil {
-		return errors.ErrGroupNotFound
-	}
-
-	// 检查是否已是成员
-	var existMember model.GroupMember
-	if err := s.storage.DB().Where("group_id = ? AND user_id = ?", groupID, userID).First(&existMember).Error; err == nil {
-		return errors.New(errors.ErrCodeInvalidParam, "user already in group")
-	}
-
-	// 检查人数限制
-	var count int64
-	s.storage.DB().Model(&model.GroupMember{}).Where("group_id = ?", groupID).Count(&count)
-	if int(count) >= group.MaxMembers {
-		return errors.ErrConversationFull
-	}
-
-	member := &model.GroupMember{
-		GroupID:  groupID,
-		UserID:   userID,
-		Role:     model.GroupRoleMember,
-		JoinedAt: time.Now(),
-	}
-
-	return s.storage.DB().Create(member).Error
+	return s.storage.DB().Transaction(func(tx *gorm.DB) error {
+		return admitTx(tx, groupID, userID, time.Now())
+	})
 }
 
 // RemoveMember 移除成员
@@ -185,7 +171,12 @@ func (s *Service) RemoveMember(ctx context.Context, groupID, operatorID, userID
 		return errors.New(errors.ErrCodeInvalidParam, "cannot remove owner")
 	}
 
-	return s.storage.DB().Where("group_id = ? AND user_id = ?", groupID, userID).Delete(&model.GroupMember{}).Error
+	return s.storage.DB().Transaction(func(tx *gorm.DB) error {
+		if _, err := targetMemberTx(tx, groupID, operatorID, userID, model.GroupRoleAdmin); err != nil {
+			return err
+		}
+		return leaveTx(tx, groupID, userID)
+	})
 }
 
 // LeaveGroup 退出群组
@@ -200,7 +191,9 @@ func (s *Service) LeaveGroup(ctx context.Context, groupID, userID string) error
 		return errors.New(errors.ErrCodeInvalidParam, "owner cannot leave, transfer or dismiss instead")
 	}
 
-	return s.storage.DB().Where("group_id = ? AND user_id = ?", groupID, userID).Delete(&model.GroupMember{}).Error
+	return s.storage.DB().Transaction(func(tx *gorm.DB) error {
+		return leaveTx(tx, groupID, userID)
+	})
 }
 
 // SetAdmin 设置/取消管理员
@@ -220,23 +213,45 @@ func (s *Service) SetAdmin(ctx context.Context, groupID, operatorID, userID stri
 		role = model.GroupRoleAdmin
 	}
 
-	return s.storage.DB().Model(&model.GroupMember{}).
-		Where("group_id = ? AND user_id = ?", groupID, userID).
-		Update("role", role).Error
+	return s.storage.DB().Transaction(func(tx *gorm.DB) error {
+		member, err := targetMemberTx(tx, groupID, operatorID, userID, model.GroupRoleOwner)
+		if err != nil {
+			return err
+		}
+		return tx.Model(member).Update("role", role).Error
+	})
 }
 
 // TransferOwner 转让群主
 func (s *Service) TransferOwner(ctx context.Context, groupID, ownerID, newOwnerID string) error {
-	var group model.Group
-	if err := s.storage.DB().First(&group, "id = ?", groupID).Error; err != nil {
-		return errors.ErrGroupNotFound
-	}
-
-	if group.OwnerID != ownerID {
-		return errors.ErrNoPermission
+	if newOwnerID == ownerID {
+		return errors.New(errors.ErrCodeInvalidParam, "already the owner")
 	}
 
 	return s.storage.DB().Transaction(func(tx *gorm.DB) error {
+		// 锁定群组后再检查群主，避免并发转让产生多个群主
+		var group model.Group
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&group, "id = ?", groupID).Error; err != nil {
+			if err == gorm.ErrRecordNotFound {
+				return errors.ErrGroupNotFound
+			}
+			return err
+		}
+		if group.OwnerID != ownerID {
+			return errors.ErrNoPermission
+		}
+
+		// 新群主必须是当前成员
+		var newOwner model.GroupMember
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("group_id = ? AND user_id = ?", groupID, newOwnerID).
+			First(&newOwner).Error; err != nil {
+			if err == gorm.ErrRecordNotFound {
+				return errors.ErrNotGroupMember
+			}
+			return err
+		}
+
 		// 更新群组所有者
 		if err := tx.Model(&group).Update("owner_id", newOwnerID).Error; err != nil {
 			return err
@@ -250,9 +265,7 @@ func (s *Service) TransferOwner(ctx context.Context, groupID, ownerID, newOwnerI
 		}
 
 		// 更新新群主角色
-		return tx.Model(&model.GroupMember{}).
-			Where("group_id = ? AND user_id = ?", groupID, newOwnerID).
-			Update("role", model.GroupRoleOwner).Error
+		return tx.Model(&newOwner).Update("role", model.GroupRoleOwner).Error
 	})
 }
 
@@ -273,6 +286,13 @@ func (s *Service) GetUserGroups(ctx context.Context, userID string) ([]model.Gro
 	return groups, err
 }
 
+// GetUserMemberships 获取用户在各群组中的成员记录（角色、群昵称等）
+func (s *Service) GetUserMemberships(ctx context.Context, userID string) ([]model.GroupMember, error) {
+	var members []model.GroupMember
+	err := s.storage.DB().Where("user_id = ?", userID).Find(&members).Error
+	return members, err
+}
+
 // IsMember 检查是否是群成员
 func (s *Service) IsMember(ctx context.Context, groupID, userID string) bool {
 	var member model.GroupMember
@@ -290,31 +310,74 @@ func (s *Service) HasPermission(ctx context.Context, groupID, userID string, min
 	return member.Role >= minRole
 }
 
-// MuteMember 禁言成员
+// MuteMember 禁言成员，duration 为 0 表示永久禁言
 func (s *Service) MuteMember(ctx context.Context, groupID, operatorID, userID string, duration time.Duration) error {
+	if duration < 0 {
+		return errors.ErrInvalidParam
+	}
 	if !s.HasPermission(ctx, groupID, operatorID, model.GroupRoleAdmin) {
 		return errors.ErrNoPermission
 	}
 
 	mutedAt := time.Now()
-	return s.storage.DB().Model(&model.GroupMember{}).
-		Where("group_id = ? AND user_id = ?", groupID, userID).
-		Updates(map[string]interface{}{
-			"muted":    true,
-			"muted_at": mutedAt,
+	var mutedUntil *time.Time
+	if duration > 0 {
+		until := mutedAt.Add(duration)
+		mutedUntil = &until
+	}
+	return s.storage.DB().Transaction(func(tx *gorm.DB) error {
+		member, err := targetMemberTx(tx, groupID, operatorID, userID, model.GroupRoleAdmin)
+		if err != nil {
+			return err
+		}
+		return tx.Model(member).Updates(map[string]interface{}{
+			"muted":       true,
+			"muted_at":    mutedAt,
+			"muted_until": mutedUntil,
 		}).Error
+	})
 }
 
-// UnmuteMember 取消禁言
+// UnmuteMember 取消禁言，与禁言相同只能操作角色低于自己的成员
 func (s *Service) UnmuteMember(ctx context.Context, groupID, operatorID, userID string) error {
 	if !s.HasPermission(ctx, groupID, operatorID, model.GroupRoleAdmin) {
 		return errors.ErrNoPermission
 	}
 
-	return s.storage.DB().Model(&model.GroupMember{}).
-		Where("group_id = ? AND user_id = ?", groupID, userID).
-		Updates(map[string]interface{}{
-			"muted":    false,
-			"muted_at": nil,
+	return s.storage.DB().Transaction(func(tx *gorm.DB) error {
+		member, err := targetMemberTx(tx, groupID, operatorID, userID, model.GroupRoleAdmin)
+		if err != nil {
+			return err
+		}
+		return tx.Model(member).Updates(map[string]interface{}{
+			"muted":       false,
+			"muted_at":    nil,
+			"muted_until": nil,
 		}).Error
+	})
+}
+
+// SetMuteAll 开启或关闭全员禁言（管理员），开启后仅群主和管理员可发言
+func (s *Service) SetMuteAll(ctx context.Context, groupID, operatorID string, enabled bool) error {
+	if !s.HasPermission(ctx, groupID, operatorID, model.GroupRoleAdmin) {
+		return errors.ErrNoPermission
+	}
+
+	return s.storage.DB().Model(&model.Group{}).
+		Where("id = ? AND status = ?", groupID, model.GroupStatusNormal).
+		Update("mute_all", enabled).Error
+}
+
+// SetSlowMode 设置慢速模式（管理员），seconds 为成员两次发言的最小间隔，0 表示关闭
+func (s *Service) SetSlowMode(ctx context.Context, groupID, operatorID string, seconds int) error {
+	if seconds < 0 || seconds > model.MaxSlowMode {
+		return errors.ErrInvalidParam
+	}
+	if !s.HasPermission(ctx, groupID, operatorID, model.GroupRoleAdmin) {
+		return errors.ErrNoPermission
+	}
+
+	return s.storage.DB().Model(&model.Group{}).
+		Where("id = ? AND status = ?", groupID, model.GroupStatusNormal).
+		Update("slow_mode", seconds).Error
 }
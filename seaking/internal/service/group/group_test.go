@@ -2,6 +2,8 @@ package group
 
 import (
 	"testing"
+
+	"github.com/my-chat/seaking/internal/model"
 )
 
 func TestCreateGroupRequest_Validation(t *testing.T) {
@@ -86,3 +88,48 @@ func TestGroupRoles(t *testing.T) {
 		})
 	}
 }
+
+func TestDiffMembers(t *testing.T) {
+	expected := []string{"owner", "alice", "bob"}
+	// carol 已被移出群组但仍在会话中，bob 加入群组后未写入会话
+	actual := []string{"owner", "alice", "carol", "carol"}
+
+	missing, extra := diffMembers(expected, actual)
+	if len(missing) != 1 || missing[0] != "bob" {
+		t.Errorf("missing = %v, want [bob]", missing)
+	}
+	if len(extra) != 1 || extra[0] != "carol" {
+		t.Errorf("extra = %v, want [carol]", extra)
+	}
+
+	// 已解散的群组期望成员为空，所有会话成员都应移除
+	missing, extra = diffMembers(nil, []string{"owner", "alice"})
+	if len(missing) != 0 || len(extra) != 2 {
+		t.Errorf("dissolved group: missing = %v, extra = %v", missing, extra)
+	}
+}
+
+func TestCanOperateOn(t *testing.T) {
+	tests := []struct {
+		name     string
+		operator int
+		target   int
+		want     bool
+	}{
+		{"owner on admin", model.GroupRoleOwner, model.GroupRoleAdmin, true},
+		{"owner on member", model.GroupRoleOwner, model.GroupRoleMember, true},
+		{"admin on member", model.GroupRoleAdmin, model.GroupRoleMember, true},
+		{"admin on admin", model.GroupRoleAdmin, model.GroupRoleAdmin, false},
+		{"admin on owner", model.GroupRoleAdmin, model.GroupRoleOwner, false},
+		{"owner on self", model.GroupRoleOwner, model.GroupRoleOwner, false},
+		{"member on member", model.GroupRoleMember, model.GroupRoleMember, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := canOperateOn(tt.operator, tt.target); got != tt.want {
+				t.Errorf("canOperateOn(%d, %d) = %v, want %v", tt.operator, tt.target, got, tt.want)
+			}
+		})
+	}
+}
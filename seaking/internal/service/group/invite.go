@@ -0,0 +1,183 @@
+package group
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"time"
+
+	"github.com/my-chat/common/pkg/errors"
+	"github.com/my-chat/seaking/internal/model"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// generateInviteToken 生成邀请链接 Token（128 位随机数，URL 安全）
+func generateInviteToken() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// CreateInviteRequest 创建邀请链接请求
+type CreateInviteRequest struct {
+	ExpiresIn        int64 `json:"expires_in"` // 有效期（秒），0表示永不过期
+	MaxUses          int   `json:"max_uses"`   // 最多使用次数，0表示不限制
+	RequiresApproval bool  `json:"requires_approval"`
+}
+
+// CreateInvite 创建邀请链接（管理员）
+func (s *Service) CreateInvite(ctx context.Context, groupID, operatorID string, req *CreateInviteRequest) (*model.GroupInvite, error) {
+	if req.ExpiresIn < 0 || req.MaxUses < 0 {
+		return nil, errors.ErrInvalidParam
+	}
+	if !s.HasPermission(ctx, groupID, operatorID, model.GroupRoleAdmin) {
+		return nil, errors.ErrNoPermission
+	}
+
+	token, err := generateInviteToken()
+	if err != nil {
+		return nil, err
+	}
+
+	invite := &model.GroupInvite{
+		GroupID:          groupID,
+		Token:            token,
+		CreatedBy:        operatorID,
+		MaxUses:          req.MaxUses,
+		RequiresApproval: req.RequiresApproval,
+	}
+	if req.ExpiresIn > 0 {
+		expiresAt := time.Now().Add(time.Duration(req.ExpiresIn) * time.Second)
+		invite.ExpiresAt = &expiresAt
+	}
+
+	if err := s.storage.DB().Create(invite).Error; err != nil {
+		return nil, err
+	}
+	return invite, nil
+}
+
+// ListInvites 获取群组未撤销的邀请链接（管理员），包括已过期和已用完的
+func (s *Service) ListInvites(ctx context.Context, groupID, operatorID string) ([]model.GroupInvite, error) {
+	if !s.HasPermission(ctx, groupID, operatorID, model.GroupRoleAdmin) {
+		return nil, errors.ErrNoPermission
+	}
+
+	var invites []model.GroupInvite
+	err := s.storage.DB().
+		Where("group_id = ? AND revoked_at IS NULL", groupID).
+		Order("id DESC").
+		Find(&invites).Error
+	return invites, err
+}
+
+// RevokeInvite 撤销邀请链接（管理员），撤销后无法再预览或加入
+func (s *Service) RevokeInvite(ctx context.Context, groupID, operatorID string, inviteID uint) error {
+	if !s.HasPermission(ctx, groupID, operatorID, model.GroupRoleAdmin) {
+		return errors.ErrNoPermission
+	}
+
+	res := s.storage.DB().Model(&model.GroupInvite{}).
+		Where("id = ? AND group_id = ? AND revoked_at IS NULL", inviteID, groupID).
+		Update("revoked_at", time.Now())
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return errors.ErrInviteInvalid
+	}
+	return nil
+}
+
+// InvitePreview 通过邀请链接看到的群组信息
+type InvitePreview struct {
+	GroupID          string     `json:"group_id"`
+	Name             string     `json:"name"`
+	Avatar           string     `json:"avatar"`
+	Description      string     `json:"description"`
+	MemberCount      int64      `json:"member_count"`
+	RequiresApproval bool       `json:"requires_approval"`
+	ExpiresAt        *time.Time `json:"expires_at"`
+}
+
+// getUsableInvite 获取可用的邀请链接及其群组，lock 为 true 时锁定邀请链接行
+func getUsableInvite(db *gorm.DB, token string, now time.Time, lock bool) (*model.GroupInvite, *model.Group, error) {
+	query := db.Where("token = ?", token)
+	if lock {
+		query = query.Clauses(clause.Locking{Strength: "UPDATE"})
+	}
+
+	var invite model.GroupInvite
+	if err := query.First(&invite).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, nil, errors.ErrInviteInvalid
+		}
+		return nil, nil, err
+	}
+	if !invite.Usable(now) {
+		return nil, nil, errors.ErrInviteInvalid
+	}
+
+	var group model.Group
+	if err := db.First(&group, "id = ?", invite.GroupID).Error; err != nil || group.Status != model.GroupStatusNormal {
+		return nil, nil, errors.ErrInviteInvalid
+	}
+	return &invite, &group, nil
+}
+
+// PreviewInvite 通过邀请链接预览群组（无需是群成员）
+func (s *Service) PreviewInvite(ctx context.Context, token string) (*InvitePreview, error) {
+	invite, group, err := getUsableInvite(s.storage.DB(), token, time.Now(), false)
+	if err != nil {
+		return nil, err
+	}
+
+	var count int64
+	if err := s.storage.DB().Model(&model.GroupMember{}).Where("group_id = ?", group.ID).Count(&count).Error; err != nil {
+		return nil, err
+	}
+
+	return &InvitePreview{
+		GroupID:          group.ID,
+		Name:             group.Name,
+		Avatar:           group.Avatar,
+		Description:      group.Description,
+		MemberCount:      count,
+		RequiresApproval: invite.RequiresApproval,
+		ExpiresAt:        invite.ExpiresAt,
+	}, nil
+}
+
+// JoinByInvite 通过邀请链接加入群组，返回群组ID
+// 链接需要审批时不直接加入，而是创建入群申请并返回该申请；两种情况都计入使用次数
+func (s *Service) JoinByInvite(ctx context.Context, token, userID string) (string, *model.GroupJoinRequest, error) {
+	var groupID string
+	var req *model.GroupJoinRequest
+	err := s.storage.DB().Transaction(func(tx *gorm.DB) error {
+		// 锁定邀请链接，并发加入时使用次数不会超出上限
+		invite, _, err := getUsableInvite(tx, token, time.Now(), true)
+		if err != nil {
+			return err
+		}
+
+		if invite.RequiresApproval {
+			req, err = requestJoinTx(tx, invite.GroupID, userID, "", &invite.ID)
+		} else {
+			err = admitTx(tx, invite.GroupID, userID, time.Now())
+		}
+		if err != nil {
+			return err
+		}
+		groupID = invite.GroupID
+
+		return tx.Model(&model.GroupInvite{}).Where("id = ?", invite.ID).
+			Update("uses", gorm.Expr("uses + 1")).Error
+	})
+	if err != nil {
+		return "", nil, err
+	}
+	return groupID, req, nil
+}
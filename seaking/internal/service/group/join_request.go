@@ -0,0 +1,185 @@
+package group
+
+import (
+	"context"
+	"time"
+
+	"github.com/my-chat/common/pkg/errors"
+	"github.com/my-chat/common/pkg/log"
+	"github.com/my-chat/seaking/internal/model"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	// JoinRequestCooldown 申请被拒绝后再次申请同一群组的最小间隔
+	JoinRequestCooldown = 24 * time.Hour
+	// JoinRequestNotifyInterval 同一群组向管理员推送新申请的最小间隔，间隔内的新申请只能在待审批列表中看到
+	JoinRequestNotifyInterval = time.Minute
+)
+
+// RequestJoin 申请加入群组，等待管理员审批
+func (s *Service) RequestJoin(ctx context.Context, groupID, userID, message string) (*model.GroupJoinRequest, error) {
+	var req *model.GroupJoinRequest
+	err := s.storage.DB().Transaction(func(tx *gorm.DB) error {
+		var err error
+		req, err = requestJoinTx(tx, groupID, userID, message, nil)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return req, nil
+}
+
+// requestJoinTx 在事务中创建入群申请
+// 锁定群组行以串行化同一群组的并发申请，每个用户同时最多一个待处理的申请
+func requestJoinTx(tx *gorm.DB, groupID, userID, message string, inviteID *uint) (*model.GroupJoinRequest, error) {
+	var group model.Group
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&group, "id = ?", groupID).Error; err != nil {
+		return nil, errors.ErrGroupNotFound
+	}
+	if group.Status != model.GroupStatusNormal {
+		return nil, errors.ErrGroupNotFound
+	}
+	if inviteID == nil && !group.JoinRequestsEnabled {
+		return nil, errors.ErrJoinRequestsClosed
+	}
+
+	var exists int64
+	if err := tx.Model(&model.GroupMember{}).Where("group_id = ? AND user_id = ?", groupID, userID).Count(&exists).Error; err != nil {
+		return nil, err
+	}
+	if exists > 0 {
+		return nil, errors.New(errors.ErrCodeInvalidParam, "user already in group")
+	}
+
+	var pending int64
+	if err := tx.Model(&model.GroupJoinRequest{}).
+		Where("group_id = ? AND user_id = ? AND status = ?", groupID, userID, model.JoinRequestPending).
+		Count(&pending).Error; err != nil {
+		return nil, err
+	}
+	if pending > 0 {
+		return nil, errors.ErrJoinRequestPending
+	}
+
+	var rejected int64
+	if err := tx.Model(&model.GroupJoinRequest{}).
+		Where("group_id = ? AND user_id = ? AND status = ? AND updated_at > ?",
+			groupID, userID, model.JoinRequestRejected, time.Now().Add(-JoinRequestCooldown)).
+		Count(&rejected).Error; err != nil {
+		return nil, err
+	}
+	if rejected > 0 {
+		return nil, errors.ErrJoinRequestCooldown
+	}
+
+	req := &model.GroupJoinRequest{
+		GroupID:  groupID,
+		UserID:   userID,
+		InviteID: inviteID,
+		Message:  message,
+		Status:   model.JoinRequestPending,
+	}
+	if err := tx.Create(req).Error; err != nil {
+		return nil, err
+	}
+	return req, nil
+}
+
+// SetJoinRequests 开启或关闭直接申请入群（管理员），已有的待处理申请不受影响
+func (s *Service) SetJoinRequests(ctx context.Context, groupID, operatorID string, enabled bool) error {
+	if !s.HasPermission(ctx, groupID, operatorID, model.GroupRoleAdmin) {
+		return errors.ErrNoPermission
+	}
+
+	return s.storage.DB().Model(&model.Group{}).
+		Where("id = ? AND status = ?", groupID, model.GroupStatusNormal).
+		Update("join_requests_enabled", enabled).Error
+}
+
+// ShouldNotifyJoinRequest 判断新申请是否推送给管理员，同一群组每个间隔最多推送一次；Redis 不可用时总是推送
+func (s *Service) ShouldNotifyJoinRequest(ctx context.Context, groupID string) bool {
+	rdb := s.storage.Redis()
+	if rdb == nil {
+		return true
+	}
+
+	ok, err := rdb.SetNX(ctx, "join_request_notify:"+groupID, 1, JoinRequestNotifyInterval).Result()
+	if err != nil {
+		log.Error().Err(err).Str("group_id", groupID).Msg("failed to throttle join request notification")
+		return true
+	}
+	return ok
+}
+
+// ListJoinRequests 获取群组待处理的入群申请（管理员）
+func (s *Service) ListJoinRequests(ctx context.Context, groupID, operatorID string) ([]model.GroupJoinRequest, error) {
+	if !s.HasPermission(ctx, groupID, operatorID, model.GroupRoleAdmin) {
+		return nil, errors.ErrNoPermission
+	}
+
+	var requests []model.GroupJoinRequest
+	err := s.storage.DB().
+		Where("group_id = ? AND status = ?", groupID, model.JoinRequestPending).
+		Order("id ASC").
+		Find(&requests).Error
+	return requests, err
+}
+
+// ApproveJoinRequest 同意入群申请（管理员），申请者按正常流程加入群组，受人数上限限制
+func (s *Service) ApproveJoinRequest(ctx context.Context, groupID string, requestID uint, operatorID string) (*model.GroupJoinRequest, error) {
+	return s.handleJoinRequest(ctx, groupID, requestID, operatorID, true)
+}
+
+// RejectJoinRequest 拒绝入群申请（管理员）
+func (s *Service) RejectJoinRequest(ctx context.Context, groupID string, requestID uint, operatorID string) (*model.GroupJoinRequest, error) {
+	return s.handleJoinRequest(ctx, groupID, requestID, operatorID, false)
+}
+
+// handleJoinRequest 处理入群申请，锁定申请行保证只被处理一次
+func (s *Service) handleJoinRequest(ctx context.Context, groupID string, requestID uint, operatorID string, approve bool) (*model.GroupJoinRequest, error) {
+	if !s.HasPermission(ctx, groupID, operatorID, model.GroupRoleAdmin) {
+		return nil, errors.ErrNoPermission
+	}
+
+	var req model.GroupJoinRequest
+	err := s.storage.DB().Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			First(&req, "id = ? AND group_id = ?", requestID, groupID).Error; err != nil {
+			return errors.ErrNotFound
+		}
+		if req.Status != model.JoinRequestPending {
+			return errors.New(errors.ErrCodeInvalidParam, "request already handled")
+		}
+
+		status := model.JoinRequestRejected
+		if approve {
+			if err := admitTx(tx, groupID, req.UserID, time.Now()); err != nil {
+				return err
+			}
+			status = model.JoinRequestApproved
+		}
+
+		req.Status = status
+		req.HandledBy = operatorID
+		return tx.Model(&req).Updates(map[string]interface{}{
+			"status":     status,
+			"handled_by": operatorID,
+		}).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &req, nil
+}
+
+// GetAdminIDs 获取群主和管理员ID（用于推送入群申请）
+func (s *Service) GetAdminIDs(ctx context.Context, groupID string) ([]string, error) {
+	var ids []string
+	err := s.storage.DB().Model(&model.GroupMember{}).
+		Where("group_id = ? AND role >= ?", groupID, model.GroupRoleAdmin).
+		Pluck("user_id", &ids).Error
+	return ids, err
+}
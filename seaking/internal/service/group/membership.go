@@ -0,0 +1,276 @@
+package group
+
+import (
+	"context"
+	"time"
+
+	"github.com/my-chat/common/pkg/errors"
+	"github.com/my-chat/seaking/internal/model"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// 群成员与群聊会话成员（conversation_members）必须同步变更：
+// 群权限基于 group_members，而消息收发的访问控制基于 conversation_members。
+
+// joinTx 在事务中将用户同时加入群组和群聊会话
+func joinTx(tx *gorm.DB, groupID, userID string, role int, now time.Time) error {
+	if err := addGroupMemberTx(tx, groupID, userID, role, now); err != nil {
+		return err
+	}
+	return addConversationMemberTx(tx, model.GenerateGroupCid(groupID), userID, now)
+}
+
+// admitTx 在事务中将新成员加入群组（管理员添加、邀请链接等共用）
+// 锁定群组行以串行化并发加入，保证不超过人数上限
+func admitTx(tx *gorm.DB, groupID, userID string, now time.Time) error {
+	var group model.Group
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&group, "id = ?", groupID).Error; err != nil {
+		return errors.ErrGroupNotFound
+	}
+	if group.Status != model.GroupStatusNormal {
+		return errors.ErrGroupNotFound
+	}
+
+	var exists int64
+	if err := tx.Model(&model.GroupMember{}).Where("group_id = ? AND user_id = ?", groupID, userID).Count(&exists).Error; err != nil {
+		return err
+	}
+	if exists > 0 {
+		return errors.New(errors.ErrCodeInvalidParam, "user already in group")
+	}
+
+	var count int64
+	if err := tx.Model(&model.GroupMember{}).Where("group_id = ?", groupID).Count(&count).Error; err != nil {
+		return err
+	}
+	if int(count) >= group.MaxMembers {
+		return errors.ErrConversationFull
+	}
+
+	return joinTx(tx, groupID, userID, model.GroupRoleMember, now)
+}
+
+// canOperateOn 判断操作者能否管理目标成员（移除、禁言、设置角色），只能管理角色低于自己的成员
+func canOperateOn(operatorRole, targetRole int) bool {
+	return operatorRole > targetRole
+}
+
+// targetMemberTx 在事务中锁定被操作成员的记录并检查操作者权限
+// 操作者角色需不低于 minRole 且高于被操作者；被操作者不是群成员时返回 ErrNotGroupMember
+func targetMemberTx(tx *gorm.DB, groupID, operatorID, userID string, minRole int) (*model.GroupMember, error) {
+	var operator model.GroupMember
+	if err := tx.Where("group_id = ? AND user_id = ?", groupID, operatorID).First(&operator).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, errors.ErrNoPermission
+		}
+		return nil, err
+	}
+	if operator.Role < minRole {
+		return nil, errors.ErrNoPermission
+	}
+
+	var member model.GroupMember
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("group_id = ? AND user_id = ?", groupID, userID).
+		First(&member).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, errors.ErrNotGroupMember
+		}
+		return nil, err
+	}
+	if !canOperateOn(operator.Role, member.Role) {
+		return nil, errors.ErrNoPermission
+	}
+	return &member, nil
+}
+
+// leaveTx 在事务中将用户同时移出群组和群聊会话
+func leaveTx(tx *gorm.DB, groupID, userID string) error {
+	if err := tx.Where("group_id = ? AND user_id = ?", groupID, userID).Delete(&model.GroupMember{}).Error; err != nil {
+		return err
+	}
+	return tx.Where("conversation_id = ? AND user_id = ?", model.GenerateGroupCid(groupID), userID).
+		Delete(&model.ConversationMember{}).Error
+}
+
+// dissolveTx 在事务中解散群组，同时移除所有群成员和会话成员
+func dissolveTx(tx *gorm.DB, group *model.Group) error {
+	if err := tx.Where("group_id = ?", group.ID).Delete(&model.GroupMember{}).Error; err != nil {
+		return err
+	}
+	if err := tx.Where("conversation_id = ?", model.GenerateGroupCid(group.ID)).Delete(&model.ConversationMember{}).Error; err != nil {
+		return err
+	}
+	return tx.Model(group).Update("status", model.GroupStatusDissolved).Error
+}
+
+// addGroupMemberTx 写入群成员，曾退出的成员恢复原记录（避免违反 (group_id, user_id) 唯一约束）
+func addGroupMemberTx(tx *gorm.DB, groupID, userID string, role int, now time.Time) error {
+	var member model.GroupMember
+	err := tx.Unscoped().Where("group_id = ? AND user_id = ?", groupID, userID).First(&member).Error
+	if err == gorm.ErrRecordNotFound {
+		return tx.Create(&model.GroupMember{
+			GroupID:  groupID,
+			UserID:   userID,
+			Role:     role,
+			JoinedAt: now,
+		}).Error
+	}
+	if err != nil {
+		return err
+	}
+
+	return tx.Unscoped().Model(&member).Updates(map[string]interface{}{
+		"role":        role,
+		"nickname":    "",
+		"muted":       false,
+		"muted_at":    nil,
+		"muted_until": nil,
+		"joined_at":   now,
+		"deleted_at":  nil,
+	}).Error
+}
+
+// addConversationMemberTx 写入会话成员，曾退出的成员恢复原记录（避免违反 (conversation_id, user_id) 唯一约束）
+func addConversationMemberTx(tx *gorm.DB, cid, userID string, now time.Time) error {
+	var member model.ConversationMember
+	err := tx.Unscoped().Where("conversation_id = ? AND user_id = ?", cid, userID).First(&member).Error
+	if err == gorm.ErrRecordNotFound {
+		return tx.Create(&model.ConversationMember{
+			ConversationID: cid,
+			UserID:         userID,
+			JoinedAt:       now,
+		}).Error
+	}
+	if err != nil {
+		return err
+	}
+
+	return tx.Unscoped().Model(&member).Updates(map[string]interface{}{
+		"muted":      false,
+		"pinned":     false,
+		"joined_at":  now,
+		"deleted_at": nil,
+	}).Error
+}
+
+// ReconcileResult 成员同步修复结果
+type ReconcileResult struct {
+	Groups              int // 检查的群组数
+	CreatedConversation int // 补建的群聊会话数
+	Added               int // 补充的会话成员数
+	Removed             int // 移除的多余会话成员数
+}
+
+// ReconcileMembership 以 group_members 为准修复 conversation_members 的历史偏差（一次性修复工具）
+func (s *Service) ReconcileMembership(ctx context.Context) (*ReconcileResult, error) {
+	var groups []model.Group
+	if err := s.storage.DB().Unscoped().Find(&groups).Error; err != nil {
+		return nil, err
+	}
+
+	result := &ReconcileResult{}
+	for _, g := range groups {
+		err := s.storage.DB().Transaction(func(tx *gorm.DB) error {
+			return reconcileGroupTx(tx, &g, result)
+		})
+		if err != nil {
+			return result, err
+		}
+		result.Groups++
+	}
+	return result, nil
+}
+
+// reconcileGroupTx 修复单个群组的会话成员
+func reconcileGroupTx(tx *gorm.DB, g *model.Group, result *ReconcileResult) error {
+	cid := model.GenerateGroupCid(g.ID)
+	active := g.Status == model.GroupStatusNormal && !g.DeletedAt.Valid
+
+	// 正常群组的会话缺失时补建
+	var expected []string
+	if active {
+		created, err := ensureConversationTx(tx, g)
+		if err != nil {
+			return err
+		}
+		if created {
+			result.CreatedConversation++
+		}
+
+		if err := tx.Model(&model.GroupMember{}).Where("group_id = ?", g.ID).Pluck("user_id", &expected).Error; err != nil {
+			return err
+		}
+	}
+
+	var actual []string
+	if err := tx.Model(&model.ConversationMember{}).Where("conversation_id = ?", cid).Pluck("user_id", &actual).Error; err != nil {
+		return err
+	}
+
+	missing, extra := diffMembers(expected, actual)
+	now := time.Now()
+	for _, uid := range missing {
+		if err := addConversationMemberTx(tx, cid, uid, now); err != nil {
+			return err
+		}
+	}
+	if len(extra) > 0 {
+		if err := tx.Where("conversation_id = ? AND user_id IN ?", cid, extra).Delete(&model.ConversationMember{}).Error; err != nil {
+			return err
+		}
+	}
+
+	result.Added += len(missing)
+	result.Removed += len(extra)
+	return nil
+}
+
+// ensureConversationTx 确保群聊会话存在，返回是否新建（或恢复）了会话
+func ensureConversationTx(tx *gorm.DB, g *model.Group) (bool, error) {
+	cid := model.GenerateGroupCid(g.ID)
+
+	var conv model.Conversation
+	err := tx.Unscoped().Where("id = ?", cid).First(&conv).Error
+	if err == gorm.ErrRecordNotFound {
+		return true, tx.Create(&model.Conversation{
+			ID:     cid,
+			Type:   model.ConversationTypeGroup,
+			Name:   g.Name,
+			Avatar: g.Avatar,
+		}).Error
+	}
+	if err != nil {
+		return false, err
+	}
+	if !conv.DeletedAt.Valid {
+		return false, nil
+	}
+	return true, tx.Unscoped().Model(&conv).Update("deleted_at", nil).Error
+}
+
+// diffMembers 比较期望成员和实际成员，返回缺失的和多余的成员
+func diffMembers(expected, actual []string) (missing, extra []string) {
+	want := make(map[string]bool, len(expected))
+	for _, uid := range expected {
+		want[uid] = true
+	}
+	have := make(map[string]bool, len(actual))
+	for _, uid := range actual {
+		if have[uid] {
+			continue
+		}
+		have[uid] = true
+		if !want[uid] {
+			extra = append(extra, uid)
+		}
+	}
+	for _, uid := range expected {
+		if !have[uid] {
+			missing = append(missing, uid)
+			have[uid] = true
+		}
+	}
+	return missing, extra
+}
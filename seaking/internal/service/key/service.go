@@ -157,9 +157,21 @@ type CreateGroupKeysRequest struct {
 	Version int             `json:"version"`
 }
 
-// CreateGroupKeys 创建群组密钥
+// CreateGroupKeys 创建群组密钥，版本号必须是当前最新版本的下一个版本
 func (s *Service) CreateGroupKeys(ctx context.Context, req *CreateGroupKeysRequest) error {
 	return s.storage.DB().Transaction(func(tx *gorm.DB) error {
+		var latest int
+		if err := tx.Model(&model.GroupKey{}).
+			Where("group_id = ?", req.GroupID).
+			Select("COALESCE(MAX(version), 0)").
+			Scan(&latest).Error; err != nil {
+			return err
+		}
+		if req.Version != latest+1 {
+			return errors.ErrKeyVersionConflict
+		}
+
+		// 并发上传同一版本时由唯一索引 (group_id, user_id, version) 兜底
 		for _, entry := range req.Keys {
 			groupKey := &model.GroupKey{
 				GroupID:      req.GroupID,
@@ -239,3 +251,29 @@ func (s *Service) GroupKeyExists(ctx context.Context, groupID string) (bool, err
 	}
 	return count > 0, nil
 }
+
+// GetUserChatKeys 获取为用户加密的所有私聊密钥（数据导出时使用）
+func (s *Service) GetUserChatKeys(ctx context.Context, userID string) ([]model.ChatKey, error) {
+	var keys []model.ChatKey
+	err := s.storage.DB().Where("user_id = ?", userID).Order("conversation_id").Find(&keys).Error
+	return keys, err
+}
+
+// GetUserGroupKeys 获取为用户加密的所有版本的群组密钥（数据导出时使用）
+func (s *Service) GetUserGroupKeys(ctx context.Context, userID string) ([]model.GroupKey, error) {
+	var keys []model.GroupKey
+	err := s.storage.DB().Where("user_id = ?", userID).Order("group_id, version").Find(&keys).Error
+	return keys, err
+}
+
+// DeleteUserKeys 删除用户的密钥对以及为其加密的私聊和群组密钥（账号注销时使用）
+func (s *Service) DeleteUserKeys(ctx context.Context, userID string) error {
+	return s.storage.DB().Transaction(func(tx *gorm.DB) error {
+		for _, m := range []interface{}{&model.UserKey{}, &model.ChatKey{}, &model.GroupKey{}} {
+			if err := tx.Where("user_id = ?", userID).Delete(m).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
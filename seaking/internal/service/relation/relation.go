@@ -8,6 +8,7 @@ import (
 	"github.com/my-chat/seaking/internal/model"
 	"github.com/my-chat/seaking/internal/storage"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 // Service 关系服务
@@ -22,6 +23,11 @@ func NewService(storage *storage.Storage) *Service {
 
 // SendFriendRequest 发送好友请求
 func (s *Service) SendFriendRequest(ctx context.Context, fromUID, toUID, message string) error {
+	// 任一方拉黑对方时不允许发送
+	if s.IsBlockedEither(ctx, fromUID, toUID) {
+		return errors.ErrBlocked
+	}
+
 	// 检查是否已经是好友
 	var friendship model.Friendship
 	err := s.storage.DB().Where("user_id = ? AND friend_id = ?", fromUID, toUID).First(&friendship).Error
@@ -63,6 +69,10 @@ func (s *Service) AcceptFriendRequest(ctx context.Context, requestID uint, uid s
 		return errors.New(errors.ErrCodeInvalidParam, "request already handled")
 	}
 
+	if s.IsBlockedEither(ctx, req.FromUID, req.ToUID) {
+		return errors.ErrBlocked
+	}
+
 	// 使用事务
 	return s.storage.DB().Transaction(func(tx *gorm.DB) error {
 		// 更新请求状态
@@ -106,18 +116,51 @@ func (s *Service) DeleteFriend(ctx context.Context, uid, friendID string) error
 	})
 }
 
-// BlockFriend 拉黑好友
-func (s *Service) BlockFriend(ctx context.Context, uid, friendID string) error {
-	return s.storage.DB().Model(&model.Friendship{}).
-		Where("user_id = ? AND friend_id = ?", uid, friendID).
-		Update("status", model.FriendStatusBlocked).Error
+// BlockUser 拉黑用户，非好友也可拉黑；同时拒绝双方之间待处理的好友请求
+func (s *Service) BlockUser(ctx context.Context, uid, targetUID string) error {
+	if uid == targetUID {
+		return errors.ErrInvalidParam
+	}
+
+	return s.storage.DB().Transaction(func(tx *gorm.DB) error {
+		block := &model.UserBlock{UserID: uid, BlockedID: targetUID}
+		if err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(block).Error; err != nil {
+			return err
+		}
+
+		// 已是好友时同步好友关系状态，好友列表中不再显示
+		if err := tx.Model(&model.Friendship{}).
+			Where("user_id = ? AND friend_id = ?", uid, targetUID).
+			Update("status", model.FriendStatusBlocked).Error; err != nil {
+			return err
+		}
+
+		return tx.Model(&model.FriendRequest{}).
+			Where("((from_uid = ? AND to_uid = ?) OR (from_uid = ? AND to_uid = ?)) AND status = ?",
+				uid, targetUID, targetUID, uid, model.FriendRequestPending).
+			Update("status", model.FriendRequestRejected).Error
+	})
+}
+
+// UnblockUser 取消拉黑，仍是好友时恢复好友关系
+func (s *Service) UnblockUser(ctx context.Context, uid, targetUID string) error {
+	return s.storage.DB().Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("user_id = ? AND blocked_id = ?", uid, targetUID).
+			Delete(&model.UserBlock{}).Error; err != nil {
+			return err
+		}
+
+		return tx.Model(&model.Friendship{}).
+			Where("user_id = ? AND friend_id = ? AND status = ?", uid, targetUID, model.FriendStatusBlocked).
+			Update("status", model.FriendStatusNormal).Error
+	})
 }
 
-// UnblockFriend 取消拉黑
-func (s *Service) UnblockFriend(ctx context.Context, uid, friendID string) error {
-	return s.storage.DB().Model(&model.Friendship{}).
-		Where("user_id = ? AND friend_id = ?", uid, friendID).
-		Update("status", model.FriendStatusNormal).Error
+// GetBlockedUsers 获取拉黑列表
+func (s *Service) GetBlockedUsers(ctx context.Context, uid string) ([]model.UserBlock, error) {
+	var blocks []model.UserBlock
+	err := s.storage.DB().Where("user_id = ?", uid).Order("created_at DESC").Find(&blocks).Error
+	return blocks, err
 }
 
 // GetFriends 获取好友列表
@@ -134,6 +177,13 @@ func (s *Service) GetPendingRequests(ctx context.Context, uid string) ([]model.F
 	return requests, err
 }
 
+// GetUserFriendRequests 获取用户发出和收到的所有好友请求（数据导出时使用）
+func (s *Service) GetUserFriendRequests(ctx context.Context, uid string) ([]model.FriendRequest, error) {
+	var requests []model.FriendRequest
+	err := s.storage.DB().Where("from_uid = ? OR to_uid = ?", uid, uid).Order("created_at DESC").Find(&requests).Error
+	return requests, err
+}
+
 // IsFriend 检查是否是好友
 func (s *Service) IsFriend(ctx context.Context, uid1, uid2 string) bool {
 	var friendship model.Friendship
@@ -144,8 +194,26 @@ func (s *Service) IsFriend(ctx context.Context, uid1, uid2 string) bool {
 
 // IsBlocked 检查是否被拉黑
 func (s *Service) IsBlocked(ctx context.Context, uid, targetUID string) bool {
-	var friendship model.Friendship
-	err := s.storage.DB().Where("user_id = ? AND friend_id = ? AND status = ?",
-		targetUID, uid, model.FriendStatusBlocked).First(&friendship).Error
-	return err == nil
+	var count int64
+	s.storage.DB().Model(&model.UserBlock{}).
+		Where("user_id = ? AND blocked_id = ?", targetUID, uid).Count(&count)
+	return count > 0
+}
+
+// IsBlockedEither 检查双方是否存在任一方向的拉黑
+func (s *Service) IsBlockedEither(ctx context.Context, uid1, uid2 string) bool {
+	return s.IsBlocked(ctx, uid1, uid2) || s.IsBlocked(ctx, uid2, uid1)
+}
+
+// PurgeUser 删除用户的所有好友关系、好友请求和拉黑记录（双向，账号注销时使用）
+func (s *Service) PurgeUser(ctx context.Context, uid string) error {
+	return s.storage.DB().Transaction(func(tx *gorm.DB) error {
+		if err := tx.Unscoped().Where("user_id = ? OR friend_id = ?", uid, uid).Delete(&model.Friendship{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Unscoped().Where("from_uid = ? OR to_uid = ?", uid, uid).Delete(&model.FriendRequest{}).Error; err != nil {
+			return err
+		}
+		return tx.Where("user_id = ? OR blocked_id = ?", uid, uid).Delete(&model.UserBlock{}).Error
+	})
 }
@@ -0,0 +1,161 @@
+package user
+
+import (
+	"context"
+	"time"
+
+	"github.com/my-chat/common/pkg/crypto"
+	"github.com/my-chat/common/pkg/errors"
+	"github.com/my-chat/seaking/internal/model"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// DeletionProcessingTimeout 领取后超过该时间未完成视为worker异常，可被重新领取
+const DeletionProcessingTimeout = 10 * time.Minute
+
+// deletionGrace 注销冷静期
+func (s *Service) deletionGrace() time.Duration {
+	if s.config.DeletionGraceHours > 0 {
+		return time.Duration(s.config.DeletionGraceHours) * time.Hour
+	}
+	return 0
+}
+
+// newDeletion 创建待处理的注销任务，冷静期从当前时间算起
+func (s *Service) newDeletion(uid string) model.AccountDeletion {
+	return model.AccountDeletion{
+		UserID:  uid,
+		Status:  model.DeletionStatusPending,
+		PurgeAt: s.now().Add(s.deletionGrace()),
+	}
+}
+
+// RequestDeletion 申请注销账号：校验密码后立即禁用账号，冷静期结束后清理数据
+// 重复申请返回已有的注销任务
+func (s *Service) RequestDeletion(ctx context.Context, uid, password, ip, deviceId string) (*model.AccountDeletion, error) {
+	if err := s.checkReauthThrottle(ctx, uid); err != nil {
+		return nil, err
+	}
+	user, err := s.GetByID(ctx, uid)
+	if err != nil {
+		return nil, err
+	}
+	if !crypto.CheckPassword(password, user.Password) {
+		s.recordReauthResult(ctx, uid, errors.ErrPasswordWrong)
+		return nil, errors.ErrPasswordWrong
+	}
+	s.recordReauthResult(ctx, uid, nil)
+
+	if existing, err := s.GetDeletion(ctx, uid); err == nil {
+		return existing, nil
+	}
+
+	deletion := s.newDeletion(uid)
+	err = s.storage.DB().Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&model.User{}).Where("id = ?", uid).
+			Update("status", model.UserStatusDisabled).Error; err != nil {
+			return err
+		}
+		return tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&deletion).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	s.logSecurityEvent(ctx, uid, model.SecurityEventDeletionRequested, ip, deviceId)
+	return &deletion, nil
+}
+
+// GetDeletion 获取用户的注销任务
+func (s *Service) GetDeletion(ctx context.Context, uid string) (*model.AccountDeletion, error) {
+	var deletion model.AccountDeletion
+	if err := s.storage.DB().First(&deletion, "user_id = ?", uid).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, errors.ErrNotFound
+		}
+		return nil, err
+	}
+	return &deletion, nil
+}
+
+// CancelDeletion 在冷静期内撤销注销（运维操作），恢复账号
+func (s *Service) CancelDeletion(ctx context.Context, uid string) error {
+	return s.storage.DB().Transaction(func(tx *gorm.DB) error {
+		res := tx.Where("user_id = ? AND status = ?", uid, model.DeletionStatusPending).
+			Delete(&model.AccountDeletion{})
+		if res.Error != nil {
+			return res.Error
+		}
+		if res.RowsAffected == 0 {
+			return errors.New(errors.ErrCodeInvalidParam, "no pending account deletion")
+		}
+		return tx.Model(&model.User{}).Where("id = ?", uid).
+			Update("status", model.UserStatusNormal).Error
+	})
+}
+
+// ClaimDueDeletions 领取冷静期已结束的注销任务（多个worker并发领取互不重复）
+func (s *Service) ClaimDueDeletions(ctx context.Context, limit int) ([]model.AccountDeletion, error) {
+	if limit <= 0 {
+		limit = 10
+	}
+	now := s.now()
+
+	due := s.storage.DB().Model(&model.AccountDeletion{}).
+		Select("user_id").
+		Where("(status = ? AND purge_at <= ?) OR (status = ? AND updated_at < ?)",
+			model.DeletionStatusPending, now,
+			model.DeletionStatusProcessing, now.Add(-DeletionProcessingTimeout)).
+		Order("purge_at ASC").
+		Limit(limit).
+		Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"})
+
+	var claimed []model.AccountDeletion
+	err := s.storage.DB().Model(&claimed).
+		Clauses(clause.Returning{}).
+		Where("user_id IN (?)", due).
+		Updates(map[string]interface{}{
+			"status":     model.DeletionStatusProcessing,
+			"updated_at": now,
+		}).Error
+	return claimed, err
+}
+
+// PurgeUser 清理用户自身的数据并匿名化用户记录，标记注销完成
+// 关系、群组、会话和密钥由各自的服务清理，需在此之前完成；可重复调用
+func (s *Service) PurgeUser(ctx context.Context, uid string) error {
+	return s.storage.DB().Transaction(func(tx *gorm.DB) error {
+		for _, m := range []interface{}{
+			&model.UserTOTP{},
+			&model.RecoveryCode{},
+			&model.SecurityLog{},
+		} {
+			if err := tx.Where("user_id = ?", uid).Delete(m).Error; err != nil {
+				return err
+			}
+		}
+
+		// 保留用户ID以便历史引用，释放用户名并清除个人资料
+		if err := tx.Unscoped().Model(&model.User{}).Where("id = ?", uid).Updates(map[string]interface{}{
+			"username":       "deleted_" + uid,
+			"nickname":       "",
+			"avatar":         "",
+			"password":       "",
+			"phone":          "",
+			"email":          "",
+			"email_verified": false,
+			"phone_verified": false,
+			"discoverable":   0,
+			"status":         model.UserStatusDisabled,
+		}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("id = ?", uid).Delete(&model.User{}).Error; err != nil {
+			return err
+		}
+
+		return tx.Model(&model.AccountDeletion{}).Where("user_id = ?", uid).
+			Update("status", model.DeletionStatusCompleted).Error
+	})
+}
@@ -0,0 +1,199 @@
+package user
+
+import (
+	"context"
+	"time"
+
+	"github.com/my-chat/common/pkg/errors"
+	"github.com/my-chat/seaking/internal/model"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	// ExportProcessingTimeout 领取后超过该时间未完成视为worker异常，可被重新领取
+	ExportProcessingTimeout = 30 * time.Minute
+	// ExportCooldown 用户本人两次申请导出的最小间隔（运维申请不受限制）
+	ExportCooldown = 24 * time.Hour
+	// maxListExports 查询导出记录时最多返回的条数
+	maxListExports = 10
+)
+
+// exportRetention 导出文件保留时间
+func (s *Service) exportRetention() time.Duration {
+	if s.config.ExportRetentionHours > 0 {
+		return time.Duration(s.config.ExportRetentionHours) * time.Hour
+	}
+	return model.DefaultExportRetentionHours * time.Hour
+}
+
+// RequestExport 申请导出个人数据，requestedBy 为用户本人或运维操作人
+// 已有未完成的导出任务时直接返回该任务
+func (s *Service) RequestExport(ctx context.Context, uid, requestedBy, ip, deviceId string) (*model.DataExport, error) {
+	if _, err := s.GetByID(ctx, uid); err != nil {
+		return nil, err
+	}
+
+	var active model.DataExport
+	err := s.storage.DB().
+		Where("user_id = ? AND status IN ?", uid, []int{model.ExportStatusPending, model.ExportStatusProcessing}).
+		First(&active).Error
+	if err == nil {
+		return &active, nil
+	}
+	if err != gorm.ErrRecordNotFound {
+		return nil, err
+	}
+
+	if requestedBy == uid {
+		var recent int64
+		err := s.storage.DB().Model(&model.DataExport{}).
+			Where("user_id = ? AND requested_by = ? AND status <> ? AND created_at > ?",
+				uid, uid, model.ExportStatusFailed, s.now().Add(-ExportCooldown)).
+			Count(&recent).Error
+		if err != nil {
+			return nil, err
+		}
+		if recent > 0 {
+			return nil, errors.New(errors.ErrCodeRateLimit, "data export already requested recently")
+		}
+	}
+
+	export := model.DataExport{
+		UserID:      uid,
+		RequestedBy: requestedBy,
+		Status:      model.ExportStatusPending,
+	}
+	if err := s.storage.DB().Create(&export).Error; err != nil {
+		return nil, err
+	}
+
+	s.logSecurityEvent(ctx, uid, model.SecurityEventDataExportRequested, ip, deviceId)
+	return &export, nil
+}
+
+// ListExports 获取用户最近的导出记录（按时间倒序）
+func (s *Service) ListExports(ctx context.Context, uid string) ([]model.DataExport, error) {
+	var exports []model.DataExport
+	err := s.storage.DB().
+		Where("user_id = ?", uid).
+		Order("id DESC").
+		Limit(maxListExports).
+		Find(&exports).Error
+	return exports, err
+}
+
+// ClaimDueExports 领取待打包的导出任务（多个worker并发领取互不重复）
+func (s *Service) ClaimDueExports(ctx context.Context, limit int) ([]model.DataExport, error) {
+	if limit <= 0 {
+		limit = 5
+	}
+	now := s.now()
+
+	due := s.storage.DB().Model(&model.DataExport{}).
+		Select("id").
+		Where("status = ? OR (status = ? AND updated_at < ?)",
+			model.ExportStatusPending,
+			model.ExportStatusProcessing, now.Add(-ExportProcessingTimeout)).
+		Order("id ASC").
+		Limit(limit).
+		Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"})
+
+	var claimed []model.DataExport
+	err := s.storage.DB().Model(&claimed).
+		Clauses(clause.Returning{}).
+		Where("id IN (?)", due).
+		Updates(map[string]interface{}{
+			"status":     model.ExportStatusProcessing,
+			"updated_at": now,
+		}).Error
+	return claimed, err
+}
+
+// exportCompletion 生成打包结果的更新字段，成功时从当前时间起计算文件过期时间
+func (s *Service) exportCompletion(fileKey string, size int64, errMsg string) map[string]interface{} {
+	if errMsg != "" {
+		return map[string]interface{}{
+			"status": model.ExportStatusFailed,
+			"error":  errMsg,
+		}
+	}
+	return map[string]interface{}{
+		"status":     model.ExportStatusCompleted,
+		"file_key":   fileKey,
+		"size":       size,
+		"expires_at": s.now().Add(s.exportRetention()),
+	}
+}
+
+// CompleteExport 记录打包结果，errMsg 不为空表示失败
+func (s *Service) CompleteExport(ctx context.Context, id uint, fileKey string, size int64, errMsg string) (*model.DataExport, error) {
+	updates := s.exportCompletion(fileKey, size, errMsg)
+
+	var export model.DataExport
+	res := s.storage.DB().Model(&export).
+		Clauses(clause.Returning{}).
+		Where("id = ? AND status = ?", id, model.ExportStatusProcessing).
+		Updates(updates)
+	if res.Error != nil {
+		return nil, res.Error
+	}
+	if res.RowsAffected == 0 {
+		return nil, errors.New(errors.ErrCodeInvalidParam, "data export not in processing")
+	}
+	return &export, nil
+}
+
+// GetExpiredExports 获取文件已过期但尚未删除的导出任务
+func (s *Service) GetExpiredExports(ctx context.Context, limit int) ([]model.DataExport, error) {
+	if limit <= 0 {
+		limit = 100
+	}
+	var exports []model.DataExport
+	err := s.storage.DB().
+		Where("status = ? AND expires_at <= ?", model.ExportStatusCompleted, s.now()).
+		Order("expires_at ASC").
+		Limit(limit).
+		Find(&exports).Error
+	return exports, err
+}
+
+// ExpireExport 导出文件删除后标记任务已过期
+func (s *Service) ExpireExport(ctx context.Context, id uint) error {
+	return s.storage.DB().Model(&model.DataExport{}).
+		Where("id = ? AND status = ?", id, model.ExportStatusCompleted).
+		Updates(map[string]interface{}{
+			"status":   model.ExportStatusExpired,
+			"file_key": "",
+		}).Error
+}
+
+// AccountExport 导出的账号数据（不含密码哈希和两步验证密钥）
+type AccountExport struct {
+	Profile          *model.User         `json:"profile"`
+	TwoFactorEnabled bool                `json:"two_factor_enabled"`
+	SecurityLogs     []model.SecurityLog `json:"security_logs"`
+}
+
+// ExportAccount 收集用户资料和安全日志
+func (s *Service) ExportAccount(ctx context.Context, uid string) (*AccountExport, error) {
+	user, err := s.GetByID(ctx, uid)
+	if err != nil {
+		return nil, err
+	}
+	enabled, err := s.TOTPEnabled(ctx, uid)
+	if err != nil {
+		return nil, err
+	}
+
+	var logs []model.SecurityLog
+	if err := s.storage.DB().Where("user_id = ?", uid).Order("id ASC").Find(&logs).Error; err != nil {
+		return nil, err
+	}
+
+	return &AccountExport{
+		Profile:          user,
+		TwoFactorEnabled: enabled,
+		SecurityLogs:     logs,
+	}, nil
+}
@@ -0,0 +1,241 @@
+package user
+
+import (
+	"context"
+	"math"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/my-chat/common/pkg/errors"
+	"github.com/my-chat/common/pkg/log"
+	"github.com/redis/go-redis/v9"
+)
+
+// loginPolicy 登录失败限制策略
+type loginPolicy struct {
+	freeAttempts int           // 无需等待的失败次数
+	baseDelay    time.Duration // 超出后首次等待时间，之后每次失败翻倍
+	maxDelay     time.Duration // 等待时间上限
+	lockAfter    int           // 达到该失败次数后锁定
+	lockDuration time.Duration // 锁定时长，同时是失败记录的保留时长
+}
+
+var (
+	usernameLoginPolicy = loginPolicy{
+		freeAttempts: 3,
+		baseDelay:    time.Second,
+		maxDelay:     30 * time.Second,
+		lockAfter:    10,
+		lockDuration: 15 * time.Minute,
+	}
+	// 同一IP后可能有多个用户（NAT），阈值更宽松
+	ipLoginPolicy = loginPolicy{
+		freeAttempts: 10,
+		baseDelay:    time.Second,
+		maxDelay:     30 * time.Second,
+		lockAfter:    50,
+		lockDuration: 15 * time.Minute,
+	}
+)
+
+// maxLocalAttempts 内存中最多保留的失败记录数，超出时清理过期记录
+const maxLocalAttempts = 10000
+
+// attemptRecord 登录失败记录
+type attemptRecord struct {
+	failures int
+	last     time.Time
+	expires  time.Time
+}
+
+// wait 计算下一次允许尝试前需要等待的时间
+func (p loginPolicy) wait(rec attemptRecord, now time.Time) time.Duration {
+	var d time.Duration
+	switch {
+	case rec.failures >= p.lockAfter:
+		d = p.lockDuration
+	case rec.failures >= p.freeAttempts:
+		d = p.baseDelay
+		for i := p.freeAttempts; i < rec.failures && d < p.maxDelay; i++ {
+			d *= 2
+		}
+		if d > p.maxDelay {
+			d = p.maxDelay
+		}
+	default:
+		return 0
+	}
+
+	if remaining := rec.last.Add(d).Sub(now); remaining > 0 {
+		return remaining
+	}
+	return 0
+}
+
+// LoginLimiter 登录失败限制
+// 按用户名和IP分别统计失败次数，逐步增加等待时间，超过阈值后临时锁定。
+// 失败记录保存在Redis中，Redis不可用时退回到进程内存
+type LoginLimiter struct {
+	redis *redis.Client
+	mu    sync.Mutex
+	local map[string]attemptRecord
+	now   func() time.Time
+}
+
+// NewLoginLimiter 创建登录失败限制器，rdb 为空时只使用内存
+func NewLoginLimiter(rdb *redis.Client) *LoginLimiter {
+	return &LoginLimiter{
+		redis: rdb,
+		local: make(map[string]attemptRecord),
+		now:   time.Now,
+	}
+}
+
+func usernameAttemptKey(username string) string {
+	return "login_fail:user:" + username
+}
+
+func ipAttemptKey(ip string) string {
+	return "login_fail:ip:" + ip
+}
+
+func reauthAttemptKey(uid string) string {
+	return "login_fail:reauth:" + uid
+}
+
+// errLoginThrottled 登录尝试过于频繁的错误，消息中带有需要等待的秒数
+func errLoginThrottled(wait time.Duration) error {
+	return errors.Newf(errors.ErrCodeRateLimit,
+		"too many login attempts, retry after %d seconds", int(math.Ceil(wait.Seconds())))
+}
+
+// Check 返回下一次允许尝试前需要等待的时间，0 表示允许
+func (l *LoginLimiter) Check(ctx context.Context, username, ip string) time.Duration {
+	now := l.now()
+	wait := usernameLoginPolicy.wait(l.get(ctx, usernameAttemptKey(username)), now)
+	if ip != "" {
+		if w := ipLoginPolicy.wait(l.get(ctx, ipAttemptKey(ip)), now); w > wait {
+			wait = w
+		}
+	}
+	return wait
+}
+
+// RecordFailure 记录一次登录失败，返回用户名是否因此被锁定
+func (l *LoginLimiter) RecordFailure(ctx context.Context, username, ip string) bool {
+	now := l.now()
+	failures := l.incr(ctx, usernameAttemptKey(username), now, usernameLoginPolicy.lockDuration)
+	if ip != "" {
+		l.incr(ctx, ipAttemptKey(ip), now, ipLoginPolicy.lockDuration)
+	}
+	return failures == usernameLoginPolicy.lockAfter
+}
+
+// Reset 登录成功后清除用户名的失败记录
+func (l *LoginLimiter) Reset(ctx context.Context, username string) {
+	l.del(ctx, usernameAttemptKey(username))
+}
+
+// Unlock 解除用户名和/或IP的限制
+func (l *LoginLimiter) Unlock(ctx context.Context, username, ip string) {
+	if username != "" {
+		l.del(ctx, usernameAttemptKey(username))
+	}
+	if ip != "" {
+		l.del(ctx, ipAttemptKey(ip))
+	}
+}
+
+// CheckReauth 返回已登录用户下一次再次确认身份（修改密码、注销账号、两步验证管理）前需等待的时间
+// 按用户ID统计，与登录的用户名计数分开，策略相同
+func (l *LoginLimiter) CheckReauth(ctx context.Context, uid string) time.Duration {
+	return usernameLoginPolicy.wait(l.get(ctx, reauthAttemptKey(uid)), l.now())
+}
+
+// RecordReauthFailure 记录一次再次确认身份的失败，返回是否因此被锁定
+func (l *LoginLimiter) RecordReauthFailure(ctx context.Context, uid string) bool {
+	failures := l.incr(ctx, reauthAttemptKey(uid), l.now(), usernameLoginPolicy.lockDuration)
+	return failures == usernameLoginPolicy.lockAfter
+}
+
+// ResetReauth 再次确认身份成功后清除失败记录
+func (l *LoginLimiter) ResetReauth(ctx context.Context, uid string) {
+	l.del(ctx, reauthAttemptKey(uid))
+}
+
+// get 读取失败记录
+func (l *LoginLimiter) get(ctx context.Context, key string) attemptRecord {
+	if l.redis != nil {
+		vals, err := l.redis.HMGet(ctx, key, "failures", "last").Result()
+		if err == nil {
+			var rec attemptRecord
+			if s, ok := vals[0].(string); ok {
+				rec.failures, _ = strconv.Atoi(s)
+			}
+			if s, ok := vals[1].(string); ok {
+				ms, _ := strconv.ParseInt(s, 10, 64)
+				rec.last = time.UnixMilli(ms)
+			}
+			return rec
+		}
+		log.Warn().Err(err).Msg("login limiter: redis unavailable, using local store")
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	rec, ok := l.local[key]
+	if !ok || !l.now().Before(rec.expires) {
+		return attemptRecord{}
+	}
+	return rec
+}
+
+// incr 增加失败次数并刷新保留时长，返回当前失败次数
+func (l *LoginLimiter) incr(ctx context.Context, key string, now time.Time, ttl time.Duration) int {
+	if l.redis != nil {
+		var failures *redis.IntCmd
+		_, err := l.redis.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+			failures = pipe.HIncrBy(ctx, key, "failures", 1)
+			pipe.HSet(ctx, key, "last", now.UnixMilli())
+			pipe.Expire(ctx, key, ttl)
+			return nil
+		})
+		if err == nil {
+			return int(failures.Val())
+		}
+		log.Warn().Err(err).Msg("login limiter: redis unavailable, using local store")
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if len(l.local) >= maxLocalAttempts {
+		for k, r := range l.local {
+			if !now.Before(r.expires) {
+				delete(l.local, k)
+			}
+		}
+	}
+	rec := l.local[key]
+	if !now.Before(rec.expires) {
+		rec = attemptRecord{}
+	}
+	rec.failures++
+	rec.last = now
+	rec.expires = now.Add(ttl)
+	l.local[key] = rec
+	return rec.failures
+}
+
+// del 删除失败记录
+func (l *LoginLimiter) del(ctx context.Context, key string) {
+	if l.redis != nil {
+		if err := l.redis.Del(ctx, key).Err(); err != nil {
+			log.Warn().Err(err).Msg("login limiter: redis unavailable, using local store")
+		}
+	}
+
+	l.mu.Lock()
+	delete(l.local, key)
+	l.mu.Unlock()
+}
@@ -0,0 +1,173 @@
+package user
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/my-chat/common/pkg/errors"
+	"github.com/my-chat/seaking/internal/model"
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	// DefaultSearchLimit 默认每页搜索结果数
+	DefaultSearchLimit = 20
+	// MaxSearchLimit 每页搜索结果数上限
+	MaxSearchLimit = 50
+
+	// searchPrefixMinLen 用户名前缀匹配的最短长度，更短时只做精确匹配
+	searchPrefixMinLen = 3
+	// searchRateWindow 搜索频率限制的统计窗口
+	searchRateWindow = time.Minute
+)
+
+var phonePattern = regexp.MustCompile(`^\+?[0-9]{6,20}$`)
+
+// likeEscaper 转义 LIKE 通配符
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+// SearchRequest 搜索用户请求
+type SearchRequest struct {
+	Query  string `json:"query"`
+	Cursor string `json:"cursor"` // 上一页最后一个用户名
+	Limit  int    `json:"limit"`
+}
+
+// SearchUsers 搜索用户
+// 邮箱、手机号只精确匹配已验证的记录，同时精确匹配同名的用户名；其余按用户名精确或前缀匹配；
+// 结果按用户名排序，返回下一页游标（为空表示没有更多）
+func (s *Service) SearchUsers(ctx context.Context, callerID string, req *SearchRequest) ([]model.User, string, error) {
+	query := strings.TrimSpace(req.Query)
+	if query == "" {
+		return nil, "", errors.ErrInvalidParam
+	}
+
+	allowed, err := s.allowSearch(ctx, callerID)
+	if err != nil {
+		return nil, "", errors.ErrInternal
+	}
+	if !allowed {
+		return nil, "", errors.ErrRateLimit
+	}
+
+	limit := req.Limit
+	if limit <= 0 {
+		limit = DefaultSearchLimit
+	}
+	if limit > MaxSearchLimit {
+		limit = MaxSearchLimit
+	}
+
+	// 已拉黑调用者的用户不出现在结果中
+	blockedBy := s.storage.DB().Model(&model.UserBlock{}).Select("user_id").Where("blocked_id = ?", callerID)
+
+	cond, args := searchCondition(query, searchMethods(query))
+	db := s.storage.DB().Model(&model.User{}).
+		Where("status = ? AND id <> ?", model.UserStatusNormal, callerID).
+		Where("id NOT IN (?)", blockedBy).
+		Where(cond, args...)
+
+	if req.Cursor != "" {
+		db = db.Where("username > ?", req.Cursor)
+	}
+
+	var users []model.User
+	if err := db.Order("username ASC").Limit(limit + 1).Find(&users).Error; err != nil {
+		return nil, "", err
+	}
+
+	var nextCursor string
+	if len(users) > limit {
+		users = users[:limit]
+		nextCursor = users[limit-1].Username
+	}
+
+	return users, nextCursor, nil
+}
+
+// SetDiscoverable 设置可被搜索的方式
+func (s *Service) SetDiscoverable(ctx context.Context, id string, discoverable int) error {
+	if discoverable&^model.DiscoverAll != 0 {
+		return errors.ErrInvalidParam
+	}
+
+	return s.storage.DB().Model(&model.User{}).Where("id = ?", id).
+		Update("discoverable", discoverable).Error
+}
+
+// searchMethods 根据查询内容判断搜索方式（按位组合）
+// 用户名没有字符限制，形如邮箱或手机号的查询同时按用户名精确匹配
+func searchMethods(query string) int {
+	switch {
+	case strings.Contains(query, "@"):
+		return model.DiscoverByEmail | model.DiscoverByUsername
+	case phonePattern.MatchString(query):
+		return model.DiscoverByPhone | model.DiscoverByUsername
+	default:
+		return model.DiscoverByUsername
+	}
+}
+
+// searchCondition 生成搜索条件，每种方式只匹配允许通过该方式被搜索的用户
+// 仅按用户名搜索时支持前缀匹配，同时按邮箱或手机号搜索时用户名只精确匹配
+func searchCondition(query string, methods int) (string, []interface{}) {
+	var conds []string
+	var args []interface{}
+	if methods&model.DiscoverByEmail != 0 {
+		conds = append(conds, "(discoverable & ? <> 0 AND email = ? AND email_verified = ?)")
+		args = append(args, model.DiscoverByEmail, query, true)
+	}
+	if methods&model.DiscoverByPhone != 0 {
+		conds = append(conds, "(discoverable & ? <> 0 AND phone = ? AND phone_verified = ?)")
+		args = append(args, model.DiscoverByPhone, query, true)
+	}
+	if methods&model.DiscoverByUsername != 0 {
+		if methods == model.DiscoverByUsername && utf8.RuneCountInString(query) >= searchPrefixMinLen {
+			conds = append(conds, `(discoverable & ? <> 0 AND username LIKE ? ESCAPE '\')`)
+			args = append(args, model.DiscoverByUsername, likeEscaper.Replace(query)+"%")
+		} else {
+			conds = append(conds, "(discoverable & ? <> 0 AND username = ?)")
+			args = append(args, model.DiscoverByUsername, query)
+		}
+	}
+	return "(" + strings.Join(conds, " OR ") + ")", args
+}
+
+// allowSearch 按调用者限制搜索频率，防止枚举用户
+func (s *Service) allowSearch(ctx context.Context, uid string) (bool, error) {
+	rdb := s.storage.Redis()
+	if rdb == nil {
+		return true, nil
+	}
+
+	// 窗口内首次搜索时创建带过期时间的计数器，与计数在同一事务中执行，避免计数器永不过期
+	key := fmt.Sprintf("search_rate:%s", uid)
+	var count *redis.IntCmd
+	_, err := rdb.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.SetNX(ctx, key, 0, searchRateWindow)
+		count = pipe.Incr(ctx, key)
+		return nil
+	})
+	if err != nil {
+		return false, err
+	}
+
+	return s.withinSearchLimit(count.Val()), nil
+}
+
+// withinSearchLimit 判断窗口内第count次搜索是否仍在限额内
+func (s *Service) withinSearchLimit(count int64) bool {
+	return count > 0 && count <= int64(s.searchRateLimit())
+}
+
+// searchRateLimit 每个用户每分钟最多搜索次数
+func (s *Service) searchRateLimit() int {
+	if s.config.SearchRateLimit > 0 {
+		return s.config.SearchRateLimit
+	}
+	return model.DefaultSearchRateLimit
+}
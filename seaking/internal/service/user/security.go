@@ -0,0 +1,76 @@
+package user
+
+import (
+	"context"
+	"sync"
+
+	"github.com/my-chat/common/pkg/crypto"
+	"github.com/my-chat/common/pkg/log"
+	"github.com/my-chat/seaking/internal/model"
+)
+
+const (
+	// DefaultSecurityLogLimit 默认每页安全日志条数
+	DefaultSecurityLogLimit = 20
+	// MaxSecurityLogLimit 每页安全日志条数上限
+	MaxSecurityLogLimit = 100
+)
+
+var (
+	dummyHashOnce sync.Once
+	dummyHash     string
+)
+
+// dummyPasswordHash 用户不存在时用于比对的哈希，使耗时与真实校验一致
+func dummyPasswordHash() string {
+	dummyHashOnce.Do(func() {
+		dummyHash, _ = crypto.HashPassword("dummy-password-for-timing")
+	})
+	return dummyHash
+}
+
+// logSecurityEvent 记录安全日志，写入失败不影响主流程
+func (s *Service) logSecurityEvent(ctx context.Context, uid, event, ip, deviceId string) {
+	entry := &model.SecurityLog{
+		UserID:   uid,
+		Event:    event,
+		IP:       ip,
+		DeviceID: deviceId,
+	}
+	if err := s.storage.DB().Create(entry).Error; err != nil {
+		log.Error().Err(err).Str("uid", uid).Str("event", event).Msg("failed to write security log")
+	}
+}
+
+// GetSecurityLogs 获取用户的安全日志，按时间倒序，beforeID 为上一页最后一条的ID
+func (s *Service) GetSecurityLogs(ctx context.Context, uid string, beforeID uint, limit int) ([]model.SecurityLog, error) {
+	if limit <= 0 {
+		limit = DefaultSecurityLogLimit
+	}
+	if limit > MaxSecurityLogLimit {
+		limit = MaxSecurityLogLimit
+	}
+
+	db := s.storage.DB().Where("user_id = ?", uid)
+	if beforeID > 0 {
+		db = db.Where("id < ?", beforeID)
+	}
+
+	var logs []model.SecurityLog
+	err := db.Order("id DESC").Limit(limit).Find(&logs).Error
+	return logs, err
+}
+
+// UnlockLogin 解除用户名和/或IP的登录限制（运维操作）
+func (s *Service) UnlockLogin(ctx context.Context, username, ip string) error {
+	s.limiter.Unlock(ctx, username, ip)
+
+	if username == "" {
+		return nil
+	}
+	var user model.User
+	if err := s.storage.DB().Where("username = ?", username).First(&user).Error; err == nil {
+		s.logSecurityEvent(ctx, user.ID, model.SecurityEventLoginUnlocked, ip, "")
+	}
+	return nil
+}
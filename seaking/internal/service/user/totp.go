@@ -0,0 +1,315 @@
+package user
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base32"
+	"strings"
+
+	"github.com/my-chat/common/pkg/crypto"
+	"github.com/my-chat/common/pkg/errors"
+	"github.com/my-chat/seaking/internal/model"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var recoveryEncoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+// totpIssuer 验证器应用中显示的服务名
+func (s *Service) totpIssuer() string {
+	if s.config.TOTPIssuer != "" {
+		return s.config.TOTPIssuer
+	}
+	return model.DefaultTOTPIssuer
+}
+
+// generateRecoveryCodes 生成恢复码，返回明文（只展示一次）和对应的哈希
+func generateRecoveryCodes(n int) ([]string, []string, error) {
+	codes := make([]string, 0, n)
+	hashes := make([]string, 0, n)
+	b := make([]byte, 5)
+	for i := 0; i < n; i++ {
+		if _, err := rand.Read(b); err != nil {
+			return nil, nil, err
+		}
+		c := strings.ToLower(recoveryEncoding.EncodeToString(b))
+		codes = append(codes, c[:4]+"-"+c[4:])
+		hashes = append(hashes, hashRecoveryCode(c))
+	}
+	return codes, hashes, nil
+}
+
+// hashRecoveryCode 计算恢复码哈希，忽略大小写、空格和分隔符
+func hashRecoveryCode(code string) string {
+	code = strings.ToLower(strings.NewReplacer("-", "", " ", "").Replace(code))
+	return crypto.SHA256Hash([]byte(code))
+}
+
+// replaceRecoveryCodes 删除旧恢复码并写入新恢复码
+func replaceRecoveryCodes(tx *gorm.DB, uid string, hashes []string) error {
+	if err := tx.Where("user_id = ?", uid).Delete(&model.RecoveryCode{}).Error; err != nil {
+		return err
+	}
+	rows := make([]model.RecoveryCode, 0, len(hashes))
+	for _, h := range hashes {
+		rows = append(rows, model.RecoveryCode{UserID: uid, CodeHash: h})
+	}
+	return tx.Create(&rows).Error
+}
+
+// checkReauthThrottle 需再次确认身份的操作前检查失败次数限制，防止持有会话者暴力破解密码或验证码
+func (s *Service) checkReauthThrottle(ctx context.Context, uid string) error {
+	if wait := s.limiter.CheckReauth(ctx, uid); wait > 0 {
+		return errLoginThrottled(wait)
+	}
+	return nil
+}
+
+// recordReauthResult 记录再次确认身份时验证码或密码的校验结果
+func (s *Service) recordReauthResult(ctx context.Context, uid string, err error) {
+	if err == nil {
+		s.limiter.ResetReauth(ctx, uid)
+		return
+	}
+	var event string
+	switch {
+	case errors.IsError(err, errors.ErrCodeInvalidOTP):
+		event = model.SecurityEventTOTPFailed
+	case errors.IsError(err, errors.ErrCodePasswordWrong):
+		event = model.SecurityEventPasswordConfirmFailed
+	default:
+		return
+	}
+
+	locked := s.limiter.RecordReauthFailure(ctx, uid)
+	s.logSecurityEvent(ctx, uid, event, "", "")
+	if locked {
+		s.logSecurityEvent(ctx, uid, model.SecurityEventLoginLocked, "", "")
+	}
+}
+
+// getTOTP 获取用户的两步验证配置
+func (s *Service) getTOTP(ctx context.Context, uid string) (*model.UserTOTP, error) {
+	var rec model.UserTOTP
+	if err := s.storage.DB().First(&rec, "user_id = ?", uid).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, errors.ErrOTPNotEnabled
+		}
+		return nil, err
+	}
+	return &rec, nil
+}
+
+// EnrollTOTP 开始启用两步验证，返回密钥和 otpauth URI
+// 需调用 ConfirmTOTP 验证一次验证码后才生效，重复调用会生成新密钥
+func (s *Service) EnrollTOTP(ctx context.Context, uid string) (string, string, error) {
+	user, err := s.GetByID(ctx, uid)
+	if err != nil {
+		return "", "", err
+	}
+	if rec, err := s.getTOTP(ctx, uid); err == nil && rec.Enabled {
+		return "", "", errors.ErrOTPEnabled
+	}
+
+	secret, err := crypto.GenerateTOTPSecret()
+	if err != nil {
+		return "", "", err
+	}
+
+	rec := &model.UserTOTP{UserID: uid, Secret: secret}
+	err = s.storage.DB().Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "user_id"}},
+		DoUpdates: clause.Assignments(map[string]interface{}{"secret": secret, "enabled": false, "last_step": 0}),
+	}).Create(rec).Error
+	if err != nil {
+		return "", "", err
+	}
+
+	return secret, crypto.TOTPURI(s.totpIssuer(), user.Username, secret), nil
+}
+
+// ConfirmTOTP 验证验证码后启用两步验证，返回恢复码明文，失败次数计入限制
+func (s *Service) ConfirmTOTP(ctx context.Context, uid, code string) ([]string, error) {
+	if err := s.checkReauthThrottle(ctx, uid); err != nil {
+		return nil, err
+	}
+	rec, err := s.getTOTP(ctx, uid)
+	if err != nil {
+		return nil, err
+	}
+	if rec.Enabled {
+		return nil, errors.ErrOTPEnabled
+	}
+
+	step, ok := crypto.ValidateTOTP(rec.Secret, strings.TrimSpace(code), s.now(), rec.LastStep)
+	if !ok {
+		s.recordReauthResult(ctx, uid, errors.ErrInvalidOTP)
+		return nil, errors.ErrInvalidOTP
+	}
+	s.recordReauthResult(ctx, uid, nil)
+
+	codes, hashes, err := generateRecoveryCodes(model.RecoveryCodeCount)
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.storage.DB().Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&model.UserTOTP{}).Where("user_id = ?", uid).
+			Updates(map[string]interface{}{"enabled": true, "last_step": step}).Error; err != nil {
+			return err
+		}
+		return replaceRecoveryCodes(tx, uid, hashes)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	s.logSecurityEvent(ctx, uid, model.SecurityEventTOTPEnabled, "", "")
+	return codes, nil
+}
+
+// VerifyTOTP 校验两步验证码或恢复码，成功后该验证码/恢复码不能再次使用
+func (s *Service) VerifyTOTP(ctx context.Context, uid, code string) error {
+	rec, err := s.getTOTP(ctx, uid)
+	if err != nil {
+		return err
+	}
+	if !rec.Enabled {
+		return errors.ErrOTPNotEnabled
+	}
+
+	code = strings.TrimSpace(code)
+	if len(code) == crypto.TOTPDigits {
+		step, ok := crypto.ValidateTOTP(rec.Secret, code, s.now(), rec.LastStep)
+		if !ok {
+			return errors.ErrInvalidOTP
+		}
+		// 条件更新，并发请求中只有一个能使用同一时间步
+		res := s.storage.DB().Model(&model.UserTOTP{}).
+			Where("user_id = ? AND last_step < ?", uid, step).
+			Update("last_step", step)
+		if res.Error != nil {
+			return res.Error
+		}
+		if res.RowsAffected == 0 {
+			return errors.ErrInvalidOTP
+		}
+		return nil
+	}
+
+	res := s.storage.DB().Model(&model.RecoveryCode{}).
+		Where("user_id = ? AND code_hash = ? AND used_at IS NULL", uid, hashRecoveryCode(code)).
+		Update("used_at", s.now())
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return errors.ErrInvalidOTP
+	}
+	s.logSecurityEvent(ctx, uid, model.SecurityEventRecoveryCodeUsed, "", "")
+	return nil
+}
+
+// VerifyLogin 完成两步验证登录，失败次数计入登录限制
+func (s *Service) VerifyLogin(ctx context.Context, uid, code, ip, deviceId string) (*model.User, error) {
+	user, err := s.GetByID(ctx, uid)
+	if err != nil {
+		return nil, err
+	}
+	if user.Status == model.UserStatusDisabled {
+		return nil, errors.ErrUserDisabled
+	}
+
+	if wait := s.limiter.Check(ctx, user.Username, ip); wait > 0 {
+		return nil, errLoginThrottled(wait)
+	}
+
+	if err := s.VerifyTOTP(ctx, uid, code); err != nil {
+		if errors.IsError(err, errors.ErrCodeInvalidOTP) {
+			locked := s.limiter.RecordFailure(ctx, user.Username, ip)
+			s.logSecurityEvent(ctx, uid, model.SecurityEventTOTPFailed, ip, deviceId)
+			if locked {
+				s.logSecurityEvent(ctx, uid, model.SecurityEventLoginLocked, ip, deviceId)
+			}
+		}
+		return nil, err
+	}
+
+	s.limiter.Reset(ctx, user.Username)
+	return user, nil
+}
+
+// DisableTOTP 关闭两步验证，需要当前密码和验证码（或恢复码），失败次数计入限制
+func (s *Service) DisableTOTP(ctx context.Context, uid, password, code string) error {
+	if err := s.checkReauthThrottle(ctx, uid); err != nil {
+		return err
+	}
+	user, err := s.GetByID(ctx, uid)
+	if err != nil {
+		return err
+	}
+	if !crypto.CheckPassword(password, user.Password) {
+		s.recordReauthResult(ctx, uid, errors.ErrPasswordWrong)
+		return errors.ErrPasswordWrong
+	}
+	err = s.VerifyTOTP(ctx, uid, code)
+	s.recordReauthResult(ctx, uid, err)
+	if err != nil {
+		return err
+	}
+
+	err = s.storage.DB().Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("user_id = ?", uid).Delete(&model.UserTOTP{}).Error; err != nil {
+			return err
+		}
+		return tx.Where("user_id = ?", uid).Delete(&model.RecoveryCode{}).Error
+	})
+	if err != nil {
+		return err
+	}
+
+	s.logSecurityEvent(ctx, uid, model.SecurityEventTOTPDisabled, "", "")
+	return nil
+}
+
+// RegenerateRecoveryCodes 重新生成恢复码，旧恢复码全部失效，失败次数计入限制
+func (s *Service) RegenerateRecoveryCodes(ctx context.Context, uid, code string) ([]string, error) {
+	if err := s.checkReauthThrottle(ctx, uid); err != nil {
+		return nil, err
+	}
+	err := s.VerifyTOTP(ctx, uid, code)
+	s.recordReauthResult(ctx, uid, err)
+	if err != nil {
+		return nil, err
+	}
+
+	codes, hashes, err := generateRecoveryCodes(model.RecoveryCodeCount)
+	if err != nil {
+		return nil, err
+	}
+	err = s.storage.DB().Transaction(func(tx *gorm.DB) error {
+		return replaceRecoveryCodes(tx, uid, hashes)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return codes, nil
+}
+
+// TOTPEnabled 用户是否已启用两步验证
+func (s *Service) TOTPEnabled(ctx context.Context, uid string) (bool, error) {
+	var count int64
+	err := s.storage.DB().Model(&model.UserTOTP{}).
+		Where("user_id = ? AND enabled = ?", uid, true).
+		Count(&count).Error
+	return count > 0, err
+}
+
+// RemainingRecoveryCodes 未使用的恢复码数量
+func (s *Service) RemainingRecoveryCodes(ctx context.Context, uid string) (int64, error) {
+	var count int64
+	err := s.storage.DB().Model(&model.RecoveryCode{}).
+		Where("user_id = ? AND used_at IS NULL", uid).
+		Count(&count).Error
+	return count, err
+}
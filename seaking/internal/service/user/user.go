@@ -2,22 +2,39 @@ package user
 
 import (
 	"context"
+	"time"
+	"unicode/utf8"
 
 	"github.com/my-chat/common/pkg/crypto"
 	"github.com/my-chat/common/pkg/errors"
+	"github.com/my-chat/seaking/internal/conf"
 	"github.com/my-chat/seaking/internal/model"
 	"github.com/my-chat/seaking/internal/storage"
+	"github.com/redis/go-redis/v9"
 	"github.com/rs/xid"
+	"gorm.io/gorm"
 )
 
 // Service 用户服务
 type Service struct {
 	storage *storage.Storage
+	config  conf.SeaKingConfiguration
+	limiter *LoginLimiter
+	now     func() time.Time
 }
 
 // NewService 创建用户服务
-func NewService(storage *storage.Storage) *Service {
-	return &Service{storage: storage}
+func NewService(storage *storage.Storage, config conf.SeaKingConfiguration) *Service {
+	var rdb *redis.Client
+	if storage != nil {
+		rdb = storage.Redis()
+	}
+	return &Service{
+		storage: storage,
+		config:  config,
+		limiter: NewLoginLimiter(rdb),
+		now:     time.Now,
+	}
 }
 
 // RegisterRequest 注册请求
@@ -69,23 +86,43 @@ func (s *Service) Register(ctx context.Context, req *RegisterRequest) (*model.Us
 type LoginRequest struct {
 	Username string `json:"username" binding:"required"`
 	Password string `json:"password" binding:"required"`
+	IP       string `json:"ip"`
+	DeviceId string `json:"device_id"`
 }
 
 // Login 用户登录
+// 失败次数过多时按用户名和IP限制尝试；用户名不存在与密码错误返回相同错误，避免枚举账号
 func (s *Service) Login(ctx context.Context, req *LoginRequest) (*model.User, error) {
+	if wait := s.limiter.Check(ctx, req.Username, req.IP); wait > 0 {
+		return nil, errLoginThrottled(wait)
+	}
+
 	var user model.User
 	if err := s.storage.DB().Where("username = ?", req.Username).First(&user).Error; err != nil {
-		return nil, errors.ErrUserNotFound
+		// 与密码校验耗时保持一致
+		crypto.CheckPassword(req.Password, dummyPasswordHash())
+		s.limiter.RecordFailure(ctx, req.Username, req.IP)
+		return nil, errors.ErrInvalidCredentials
 	}
 
+	if !crypto.CheckPassword(req.Password, user.Password) {
+		locked := s.limiter.RecordFailure(ctx, req.Username, req.IP)
+		s.logSecurityEvent(ctx, user.ID, model.SecurityEventLoginFailed, req.IP, req.DeviceId)
+		if locked {
+			s.logSecurityEvent(ctx, user.ID, model.SecurityEventLoginLocked, req.IP, req.DeviceId)
+		}
+		return nil, errors.ErrInvalidCredentials
+	}
+
+	// 密码正确后才暴露账号状态
 	if user.Status == model.UserStatusDisabled {
 		return nil, errors.ErrUserDisabled
 	}
 
-	if !crypto.CheckPassword(req.Password, user.Password) {
-		return nil, errors.ErrPasswordWrong
+	// 启用两步验证时，失败计数在 VerifyLogin 通过后才清除，避免借重新登录绕过验证码尝试次数限制
+	if enabled, err := s.TOTPEnabled(ctx, user.ID); err == nil && !enabled {
+		s.limiter.Reset(ctx, req.Username)
 	}
-
 	return &user, nil
 }
 
@@ -107,8 +144,19 @@ func (s *Service) GetByUsername(ctx context.Context, username string) (*model.Us
 	return &user, nil
 }
 
+const (
+	// MaxNicknameLength 昵称最大字符数
+	MaxNicknameLength = 64
+	// MaxAvatarLength 头像URL最大长度
+	MaxAvatarLength = 256
+)
+
 // UpdateProfile 更新用户资料
 func (s *Service) UpdateProfile(ctx context.Context, id string, nickname, avatar string) error {
+	if utf8.RuneCountInString(nickname) > MaxNicknameLength || len(avatar) > MaxAvatarLength {
+		return errors.ErrInvalidParam
+	}
+
 	updates := map[string]interface{}{}
 	if nickname != "" {
 		updates["nickname"] = nickname
@@ -124,21 +172,59 @@ func (s *Service) UpdateProfile(ctx context.Context, id string, nickname, avatar
 	return s.storage.DB().Model(&model.User{}).Where("id = ?", id).Updates(updates).Error
 }
 
-// ChangePassword 修改密码
-func (s *Service) ChangePassword(ctx context.Context, id, oldPassword, newPassword string) error {
+// ChangePasswordRequest 修改密码请求
+// 用户已上传密钥时，必须同时提供用新密码重新加密的私钥和新盐值
+type ChangePasswordRequest struct {
+	OldPassword         string `json:"old_password"`
+	NewPassword         string `json:"new_password" binding:"required,min=6,max=32"`
+	EncryptedPrivateKey string `json:"encrypted_private_key"`
+	KeySalt             string `json:"key_salt"`
+}
+
+// ChangePassword 修改密码，密码和私钥在同一事务中更新
+func (s *Service) ChangePassword(ctx context.Context, id string, req *ChangePasswordRequest) error {
+	if len(req.NewPassword) < 6 || len(req.NewPassword) > 32 {
+		return errors.ErrInvalidParam
+	}
+
+	if err := s.checkReauthThrottle(ctx, id); err != nil {
+		return err
+	}
+
 	var user model.User
 	if err := s.storage.DB().First(&user, "id = ?", id).Error; err != nil {
 		return errors.ErrUserNotFound
 	}
 
-	if !crypto.CheckPassword(oldPassword, user.Password) {
+	if !crypto.CheckPassword(req.OldPassword, user.Password) {
+		s.recordReauthResult(ctx, id, errors.ErrPasswordWrong)
 		return errors.ErrPasswordWrong
 	}
+	s.recordReauthResult(ctx, id, nil)
 
-	hashedPassword, err := crypto.HashPassword(newPassword)
+	hashedPassword, err := crypto.HashPassword(req.NewPassword)
 	if err != nil {
 		return errors.ErrInternal
 	}
 
-	return s.storage.DB().Model(&user).Update("password", hashedPassword).Error
+	return s.storage.DB().Transaction(func(tx *gorm.DB) error {
+		var keyCount int64
+		if err := tx.Model(&model.UserKey{}).Where("user_id = ?", id).Count(&keyCount).Error; err != nil {
+			return err
+		}
+		if keyCount > 0 {
+			// 私钥仍由旧密码加密会导致新设备无法解密
+			if req.EncryptedPrivateKey == "" || req.KeySalt == "" {
+				return errors.New(errors.ErrCodeInvalidParam, "re-encrypted private key required")
+			}
+			if err := tx.Model(&model.UserKey{}).Where("user_id = ?", id).Updates(map[string]interface{}{
+				"encrypted_private_key": req.EncryptedPrivateKey,
+				"key_salt":              req.KeySalt,
+			}).Error; err != nil {
+				return err
+			}
+		}
+
+		return tx.Model(&user).Update("password", hashedPassword).Error
+	})
 }
@@ -1,7 +1,14 @@
 package user
 
 import (
+	"context"
+	"strings"
 	"testing"
+	"time"
+
+	"github.com/my-chat/common/pkg/errors"
+	"github.com/my-chat/seaking/internal/conf"
+	"github.com/my-chat/seaking/internal/model"
 )
 
 func TestRegisterRequest_Validation(t *testing.T) {
@@ -96,3 +103,290 @@ func TestLoginRequest_Validation(t *testing.T) {
 		})
 	}
 }
+
+func TestSearchMethod(t *testing.T) {
+	tests := []struct {
+		query string
+		want  int
+	}{
+		{"alice@example.com", model.DiscoverByEmail | model.DiscoverByUsername},
+		{"alice@home", model.DiscoverByEmail | model.DiscoverByUsername},
+		{"+8613800138000", model.DiscoverByPhone | model.DiscoverByUsername},
+		{"13800138000", model.DiscoverByPhone | model.DiscoverByUsername},
+		{"123456", model.DiscoverByPhone | model.DiscoverByUsername},
+		{"alice", model.DiscoverByUsername},
+		{"123", model.DiscoverByUsername},
+	}
+
+	for _, tt := range tests {
+		if got := searchMethods(tt.query); got != tt.want {
+			t.Errorf("searchMethods(%q) = %d, want %d", tt.query, got, tt.want)
+		}
+	}
+}
+
+func TestSearchCondition(t *testing.T) {
+	// 形如手机号的用户名只精确匹配，不做前缀匹配
+	cond, args := searchCondition("13800138000", searchMethods("13800138000"))
+	if !strings.Contains(cond, "phone = ?") || !strings.Contains(cond, "username = ?") || strings.Contains(cond, "LIKE") {
+		t.Errorf("searchCondition(phone) = %s", cond)
+	}
+	if len(args) != 5 || args[3] != model.DiscoverByUsername || args[4] != "13800138000" {
+		t.Errorf("searchCondition(phone) args = %v", args)
+	}
+
+	cond, args = searchCondition("ali_ce", model.DiscoverByUsername)
+	if !strings.Contains(cond, "username LIKE ?") || strings.Contains(cond, "email") {
+		t.Errorf("searchCondition(username) = %s", cond)
+	}
+	if len(args) != 2 || args[1] != `ali\_ce%` {
+		t.Errorf("searchCondition(username) args = %v", args)
+	}
+
+	cond, _ = searchCondition("al", model.DiscoverByUsername)
+	if !strings.Contains(cond, "username = ?") {
+		t.Errorf("searchCondition(short username) = %s", cond)
+	}
+}
+
+func TestLikeEscaper(t *testing.T) {
+	if got := likeEscaper.Replace(`a_b%c\d`); got != `a\_b\%c\\d` {
+		t.Errorf("likeEscaper.Replace = %s", got)
+	}
+}
+
+func TestWithinSearchLimit(t *testing.T) {
+	def := int64(model.DefaultSearchRateLimit)
+	tests := []struct {
+		name  string
+		limit int
+		count int64
+		want  bool
+	}{
+		{"default first", 0, 1, true},
+		{"default at limit", 0, def, true},
+		{"default over limit", 0, def + 1, false},
+		{"configured at limit", 5, 5, true},
+		{"configured over limit", 5, 6, false},
+		{"configured above default", int(def) + 10, def + 1, true},
+		{"zero count", 5, 0, false},
+	}
+
+	for _, tt := range tests {
+		s := NewService(nil, conf.SeaKingConfiguration{SearchRateLimit: tt.limit})
+		if got := s.withinSearchLimit(tt.count); got != tt.want {
+			t.Errorf("%s: withinSearchLimit(%d) = %v, want %v", tt.name, tt.count, got, tt.want)
+		}
+	}
+}
+
+func TestLoginPolicy_Wait(t *testing.T) {
+	now := time.Now()
+	p := usernameLoginPolicy
+
+	tests := []struct {
+		name     string
+		failures int
+		want     time.Duration
+	}{
+		{"no failures", 0, 0},
+		{"within free attempts", p.freeAttempts - 1, 0},
+		{"first delay", p.freeAttempts, p.baseDelay},
+		{"doubled delay", p.freeAttempts + 1, 2 * p.baseDelay},
+		{"capped delay", p.lockAfter - 1, p.maxDelay},
+		{"locked", p.lockAfter, p.lockDuration},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := p.wait(attemptRecord{failures: tt.failures, last: now}, now)
+			if got != tt.want {
+				t.Errorf("wait() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	if got := p.wait(attemptRecord{failures: p.freeAttempts, last: now.Add(-time.Minute)}, now); got != 0 {
+		t.Errorf("wait() after delay elapsed = %v, want 0", got)
+	}
+}
+
+func TestLoginLimiter_Local(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now()
+	l := NewLoginLimiter(nil)
+	l.now = func() time.Time { return now }
+
+	var locked bool
+	for i := 0; i < usernameLoginPolicy.lockAfter; i++ {
+		locked = l.RecordFailure(ctx, "alice", "1.2.3.4")
+	}
+	if !locked {
+		t.Error("RecordFailure() should report lock at lockAfter failures")
+	}
+	if got := l.Check(ctx, "alice", "1.2.3.4"); got != usernameLoginPolicy.lockDuration {
+		t.Errorf("Check() = %v, want %v", got, usernameLoginPolicy.lockDuration)
+	}
+
+	// 其他用户名不受锁定影响，但同一IP已超过免等待次数
+	if got := l.Check(ctx, "bob", "5.6.7.8"); got != 0 {
+		t.Errorf("Check() for other user = %v, want 0", got)
+	}
+	if got := l.Check(ctx, "bob", "1.2.3.4"); got != ipLoginPolicy.baseDelay {
+		t.Errorf("Check() for same ip = %v, want %v", got, ipLoginPolicy.baseDelay)
+	}
+
+	now = now.Add(usernameLoginPolicy.lockDuration)
+	if got := l.Check(ctx, "alice", "1.2.3.4"); got != 0 {
+		t.Errorf("Check() after lock expired = %v, want 0", got)
+	}
+
+	for i := 0; i < usernameLoginPolicy.freeAttempts; i++ {
+		l.RecordFailure(ctx, "alice", "")
+	}
+	if l.Check(ctx, "alice", "") == 0 {
+		t.Error("Check() should require a delay after free attempts")
+	}
+	l.Unlock(ctx, "alice", "")
+	if got := l.Check(ctx, "alice", ""); got != 0 {
+		t.Errorf("Check() after Unlock = %v, want 0", got)
+	}
+}
+
+func TestGenerateRecoveryCodes(t *testing.T) {
+	codes, hashes, err := generateRecoveryCodes(model.RecoveryCodeCount)
+	if err != nil {
+		t.Fatalf("generateRecoveryCodes failed: %v", err)
+	}
+	if len(codes) != model.RecoveryCodeCount || len(hashes) != model.RecoveryCodeCount {
+		t.Fatalf("got %d codes, %d hashes, want %d", len(codes), len(hashes), model.RecoveryCodeCount)
+	}
+
+	seen := make(map[string]bool)
+	for i, c := range codes {
+		if len(c) != 9 || c[4] != '-' {
+			t.Errorf("unexpected code format: %s", c)
+		}
+		if seen[c] {
+			t.Errorf("duplicate code: %s", c)
+		}
+		seen[c] = true
+		if hashRecoveryCode(c) != hashes[i] {
+			t.Errorf("hash mismatch for %s", c)
+		}
+	}
+}
+
+func TestHashRecoveryCode_Normalize(t *testing.T) {
+	want := hashRecoveryCode("abcd-efgh")
+	for _, in := range []string{"ABCD-EFGH", "abcdefgh", "abcd efgh"} {
+		if got := hashRecoveryCode(in); got != want {
+			t.Errorf("hashRecoveryCode(%q) differs from normalized code", in)
+		}
+	}
+}
+
+func TestReauthThrottle(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now()
+	s := NewService(nil, conf.SeaKingConfiguration{})
+	s.limiter.now = func() time.Time { return now }
+
+	for i := 0; i < usernameLoginPolicy.freeAttempts; i++ {
+		if err := s.checkReauthThrottle(ctx, "user1"); err != nil {
+			t.Fatalf("attempt %d throttled: %v", i, err)
+		}
+		s.limiter.RecordReauthFailure(ctx, "user1")
+	}
+	err := s.checkReauthThrottle(ctx, "user1")
+	if !errors.IsError(err, errors.ErrCodeRateLimit) {
+		t.Fatalf("checkReauthThrottle() = %v, want rate limit error", err)
+	}
+
+	// 按用户ID单独计数，不影响其他用户和同名的登录计数
+	if err := s.checkReauthThrottle(ctx, "user2"); err != nil {
+		t.Errorf("other user throttled: %v", err)
+	}
+	if got := s.limiter.Check(ctx, "user1", ""); got != 0 {
+		t.Errorf("login Check() = %v, want 0", got)
+	}
+
+	// 达到阈值后锁定，等待时间过后恢复
+	var locked bool
+	for i := usernameLoginPolicy.freeAttempts; i < usernameLoginPolicy.lockAfter; i++ {
+		locked = s.limiter.RecordReauthFailure(ctx, "user1")
+	}
+	if !locked {
+		t.Error("RecordReauthFailure() should report lock at lockAfter failures")
+	}
+	now = now.Add(usernameLoginPolicy.lockDuration)
+	if err := s.checkReauthThrottle(ctx, "user1"); err != nil {
+		t.Errorf("throttled after lock expired: %v", err)
+	}
+
+	s.limiter.RecordReauthFailure(ctx, "user1")
+	s.limiter.ResetReauth(ctx, "user1")
+	if got := s.limiter.CheckReauth(ctx, "user1"); got != 0 {
+		t.Errorf("CheckReauth() after reset = %v, want 0", got)
+	}
+}
+
+func TestNewDeletion(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name       string
+		graceHours int
+		want       time.Time
+	}{
+		{"no grace purges immediately", 0, now},
+		{"grace delays purge", 168, now.Add(7 * 24 * time.Hour)},
+	}
+
+	for _, tt := range tests {
+		s := NewService(nil, conf.SeaKingConfiguration{DeletionGraceHours: tt.graceHours})
+		s.now = func() time.Time { return now }
+
+		d := s.newDeletion("user1")
+		if d.UserID != "user1" || d.Status != model.DeletionStatusPending {
+			t.Errorf("%s: newDeletion() = %+v, want pending task for user1", tt.name, d)
+		}
+		if !d.PurgeAt.Equal(tt.want) {
+			t.Errorf("%s: PurgeAt = %v, want %v", tt.name, d.PurgeAt, tt.want)
+		}
+	}
+}
+
+func TestExportCompletion(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	s := NewService(nil, conf.SeaKingConfiguration{ExportRetentionHours: 24})
+	s.now = func() time.Time { return now }
+
+	ok := s.exportCompletion("exports/user1.zip", 1024, "")
+	if ok["status"] != model.ExportStatusCompleted || ok["file_key"] != "exports/user1.zip" || ok["size"] != int64(1024) {
+		t.Errorf("exportCompletion() success = %v", ok)
+	}
+	if got, _ := ok["expires_at"].(time.Time); !got.Equal(now.Add(24 * time.Hour)) {
+		t.Errorf("expires_at = %v, want %v", ok["expires_at"], now.Add(24*time.Hour))
+	}
+	if _, exists := ok["error"]; exists {
+		t.Error("exportCompletion() success should not set error")
+	}
+
+	s = NewService(nil, conf.SeaKingConfiguration{})
+	s.now = func() time.Time { return now }
+	ok = s.exportCompletion("exports/user1.zip", 1024, "")
+	want := now.Add(model.DefaultExportRetentionHours * time.Hour)
+	if got, _ := ok["expires_at"].(time.Time); !got.Equal(want) {
+		t.Errorf("default expires_at = %v, want %v", ok["expires_at"], want)
+	}
+
+	failed := s.exportCompletion("exports/user1.zip", 1024, "upload failed")
+	if failed["status"] != model.ExportStatusFailed || failed["error"] != "upload failed" {
+		t.Errorf("exportCompletion() failure = %v", failed)
+	}
+	for _, field := range []string{"file_key", "size", "expires_at"} {
+		if _, exists := failed[field]; exists {
+			t.Errorf("exportCompletion() failure should not set %s", field)
+		}
+	}
+}